@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/database/backup"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev db command: pg_dump / pg_restore with the configured connection, see internal/database/backup
+
+go-boilerplate db backup                        → <database.backup.dir>/<name>-<timestamp>.dump
+go-boilerplate db backup -out x.dump -compress 9
+go-boilerplate db backup -upload                → also uploaded to database.backup.s3
+go-boilerplate db restore [-clean] x.dump
+go-boilerplate db restore s3://bucket/backups/x.dump
+*/
+
+func runDB(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: go-boilerplate db backup|restore [flags]")
+	}
+
+	switch args[0] {
+	case "backup":
+		return runDBBackup(args[1:])
+	case "restore":
+		return runDBRestore(args[1:])
+	default:
+		return fmt.Errorf("unknown db command %q, expected backup or restore", args[0])
+	}
+}
+
+func runDBBackup(args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	flags := flag.NewFlagSet("db backup", flag.ContinueOnError)
+	out := flags.String("out", "", "archive path, <database.backup.dir>/<name>-<timestamp>.dump by default")
+	compress := flags.Int("compress", cfg.Database.Backup.Compression, "compression level 0-9")
+	upload := flags.Bool("upload", cfg.Database.Backup.S3.Bucket != "", "upload the archive to database.backup.s3")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	return withCommandLogger(cfg, func(ctx context.Context, log *zerolog.Logger) error {
+		location, err := backup.Backup(ctx, cfg, log, backup.Options{
+			Output:      *out,
+			Compression: *compress,
+			Upload:      *upload,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(location)
+		return nil
+	})
+}
+
+func runDBRestore(args []string) error {
+	flags := flag.NewFlagSet("db restore", flag.ContinueOnError)
+	clean := flags.Bool("clean", false, "drop the objects of the archive before restoring them")
+	jobs := flags.Int("jobs", 1, "parallel restore jobs")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-boilerplate db restore [-clean] [-jobs n] path|s3://bucket/key")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("exactly one backup to restore is required")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	return withCommandLogger(cfg, func(ctx context.Context, log *zerolog.Logger) error {
+		return backup.Restore(ctx, cfg, log, backup.RestoreOptions{
+			Source: flags.Arg(0),
+			Clean:  *clean,
+			Jobs:   *jobs,
+		})
+	})
+}
+
+// withCommandLogger: logger and signal context of a command, pg_dump / pg_restore are killed on ctrl-c
+func withCommandLogger(cfg *config.Config, fn func(ctx context.Context, log *zerolog.Logger) error) error {
+	loggerService := loggerConfig.NewLoggerService(cfg.Observability)
+	defer loggerService.Shutdown()
+	log := loggerConfig.NewLoggerWithService(cfg.Observability, loggerService)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return fn(ctx, &log)
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/app"
+	"github.com/anuragShingare30/go-boilerplate/internal/audit"
+	"github.com/anuragShingare30/go-boilerplate/internal/cache"
+	"github.com/anuragShingare30/go-boilerplate/internal/clientip"
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/crypto"
+	"github.com/anuragShingare30/go-boilerplate/internal/database"
+	"github.com/anuragShingare30/go-boilerplate/internal/database/seeds"
+	"github.com/anuragShingare30/go-boilerplate/internal/handler"
+	"github.com/anuragShingare30/go-boilerplate/internal/health"
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+	"github.com/anuragShingare30/go-boilerplate/internal/idempotency"
+	"github.com/anuragShingare30/go-boilerplate/internal/lock"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/anuragShingare30/go-boilerplate/internal/maintenance"
+	"github.com/anuragShingare30/go-boilerplate/internal/middleware"
+	"github.com/anuragShingare30/go-boilerplate/internal/openapi"
+	"github.com/anuragShingare30/go-boilerplate/internal/outbox"
+	"github.com/anuragShingare30/go-boilerplate/internal/pubsub"
+	"github.com/anuragShingare30/go-boilerplate/internal/ratelimit"
+	"github.com/anuragShingare30/go-boilerplate/internal/redis"
+	"github.com/anuragShingare30/go-boilerplate/internal/repository"
+	"github.com/anuragShingare30/go-boilerplate/internal/router"
+	"github.com/anuragShingare30/go-boilerplate/internal/server"
+	"github.com/anuragShingare30/go-boilerplate/internal/sse"
+	"github.com/anuragShingare30/go-boilerplate/internal/static"
+	"github.com/anuragShingare30/go-boilerplate/internal/ws"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+)
+
+// @dev application entry point: config -> logger -> app hooks (internal/app) -> running until SIGINT / SIGTERM
+// @dev subcommands: "migrate" (migrate.go), "seed" (seed.go), "db" (db.go), without one the server is started
+
+func main() {
+	if len(os.Args) > 1 {
+		var run func(args []string) error
+		switch os.Args[1] {
+		case "migrate":
+			run = runMigrate
+		case "seed":
+			run = runSeed
+		case "db":
+			run = runDB
+		}
+		if run != nil {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[1], err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		panic("failed to load config: " + err.Error())
+	}
+
+	loggerService := loggerConfig.NewLoggerService(cfg.Observability)
+
+	log := loggerConfig.NewLoggerWithService(cfg.Observability, loggerService)
+	// fallback for loggerConfig.FromContext when the context carries no request scoped logger
+	loggerConfig.SetDefault(&log)
+	// libraries using log/slog write through the same logger
+	loggerConfig.SetSlogDefault(log, cfg.Observability)
+
+	// SIGTERM: drain (http, workers) within server.drain_timeout → flush logs / New Relic → close database / redis
+	application := app.New(&log, cfg.Server.ShutdownTimeout, cfg.Server.DrainTimeout)
+	registerHooks(application, cfg, loggerService, &log)
+	if err := application.Run(context.Background()); err != nil {
+		log.Error().Err(err).Msg("app stopped with error")
+		os.Exit(1)
+	}
+}
+
+// registerHooks: the subsystems of the server, started top to bottom as far as their dependencies allow and stopped in reverse
+// state shared between hooks lives in these variables, a hook reads them once the hooks it depends on have started
+func registerHooks(application *app.App, cfg *config.Config, loggerService *loggerConfig.LoggerService, log *zerolog.Logger) {
+	var (
+		db          *database.Database
+		redisClient *redis.Client
+		ps          *pubsub.PubSub
+		hub         *ws.Hub
+		checker     *health.Checker
+	)
+
+	// stopped once requests and workers are done, New Relic sends what they logged on their way down
+	// the connections close after it, a hanging pool doesn't cost the telemetry of the shutdown
+	application.Register(app.Hook{
+		Name:  "logger",
+		Phase: app.PhaseFlush,
+		Start: func(ctx context.Context) error {
+			// SIGHUP re-reads the logging level from env
+			go loggerService.WatchSIGHUP(ctx, log)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			loggerService.Shutdown()
+			return nil
+		},
+	})
+
+	// field encryption of the crypto.Encrypted* column types
+	if cfg.Crypto.Enabled() {
+		application.Register(app.Hook{
+			Name: "crypto",
+			Start: func(ctx context.Context) error {
+				cipher, err := crypto.NewWithKMS(ctx, cfg.Crypto)
+				if err != nil {
+					return fmt.Errorf("failed to initialize field encryption: %w", err)
+				}
+				crypto.SetDefault(cipher)
+				return nil
+			},
+		})
+	}
+
+	application.Register(app.Hook{
+		Name:      "migrations",
+		DependsOn: []string{"logger"},
+		Start: func(ctx context.Context) error {
+			if err := database.Migrate(ctx, log, cfg); err != nil {
+				return fmt.Errorf("failed to migrate database: %w", err)
+			}
+			if cfg.Database.Tenancy.Enabled {
+				if err := database.MigrateTenants(ctx, log, cfg); err != nil {
+					return fmt.Errorf("failed to migrate tenant schemas: %w", err)
+				}
+			}
+			return nil
+		},
+	})
+
+	// stops after everything that queries: running queries drain first, then the pool closes
+	application.Register(app.Hook{
+		Name:      "database",
+		DependsOn: []string{"migrations"},
+		Phase:     app.PhaseClose,
+		Start: func(context.Context) (err error) {
+			db, err = database.New(cfg, log, loggerService)
+			if err != nil {
+				return fmt.Errorf("failed to initialize database: %w", err)
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			drainErr := db.Drain(ctx)
+			return errors.Join(drainErr, db.Close())
+		},
+	})
+
+	application.Register(app.Hook{
+		Name:      "redis",
+		DependsOn: []string{"logger"},
+		Phase:     app.PhaseClose,
+		Start: func(context.Context) (err error) {
+			redisClient, err = redis.New(cfg, log, loggerService)
+			if err != nil {
+				return fmt.Errorf("failed to initialize redis: %w", err)
+			}
+			return nil
+		},
+		Stop: func(context.Context) error {
+			return redisClient.Close()
+		},
+	})
+
+	application.Register(app.Hook{
+		Name:      "cache",
+		DependsOn: []string{"redis"},
+		Start: func(context.Context) error {
+			// cache.Default() is shared by handlers and repositories
+			appCache, err := cache.New(cfg, redisClient, log)
+			if err != nil {
+				return fmt.Errorf("failed to initialize cache: %w", err)
+			}
+			cache.SetDefault(appCache)
+			// lock.WithLock(ctx, key, fn) coordinates work between the replicas
+			lock.SetDefault(lock.New(redisClient, cfg.Lock, log))
+			return nil
+		},
+	})
+
+	application.Register(app.Hook{
+		Name:      "pubsub",
+		DependsOn: []string{"redis"},
+		Start: func(ctx context.Context) error {
+			// pubsub.Subscribe handlers are registered before Start, later ones subscribe on the running connection
+			ps = pubsub.New(redisClient, cfg.PubSub, log)
+			pubsub.SetDefault(ps)
+			return ps.Start(ctx)
+		},
+		Stop: func(context.Context) error {
+			ps.Close()
+			return nil
+		},
+	})
+
+	application.Register(app.Hook{
+		Name:      "realtime",
+		DependsOn: []string{"pubsub"},
+		Start: func(context.Context) error {
+			// heartbeat and queue size of sse.Stream
+			sse.SetDefault(cfg.Server.SSE)
+			// handlers serve their websockets through the hub, Broadcast reaches the other replicas with the bridge
+			hub = ws.NewHub(cfg.Server.WebSocket, log)
+			if cfg.Server.WebSocket.Bridge {
+				if err := hub.Bridge(ps); err != nil {
+					return fmt.Errorf("failed to bridge websocket hub: %w", err)
+				}
+			}
+			return nil
+		},
+	})
+
+	if cfg.Database.SeedOnStartup {
+		application.Register(app.Hook{
+			Name:      "seeds",
+			DependsOn: []string{"database"},
+			Start: func(ctx context.Context) error {
+				if _, err := seeds.Run(ctx, db, cfg.Primary.Env, log); err != nil {
+					return fmt.Errorf("failed to seed database: %w", err)
+				}
+				return nil
+			},
+		})
+	}
+
+	// LISTEN/NOTIFY: handlers and services get the listener from newRouter and register with listener.Handle,
+	// it starts after the http hook built them, and only with a channel to listen on
+	listener := database.NewListener(cfg, log)
+	application.Register(app.Hook{
+		Name:      "listener",
+		DependsOn: []string{"migrations", "http"},
+		Start: func(ctx context.Context) error {
+			if listener.Active() {
+				listener.Start(ctx)
+			}
+			return nil
+		},
+		Stop: func(context.Context) error {
+			listener.Close()
+			return nil
+		},
+	})
+
+	// transactional outbox relay, events are enqueued with outbox.Enqueue inside db.WithTx
+	if cfg.Outbox.Enabled {
+		var relay *outbox.Relay
+		application.Register(app.Hook{
+			Name:      "outbox",
+			DependsOn: []string{"database"},
+			Start: func(ctx context.Context) error {
+				sink, err := outbox.NewSink(cfg)
+				if err != nil {
+					return fmt.Errorf("failed to initialize outbox sink: %w", err)
+				}
+				relay = outbox.NewRelay(db, sink, cfg.Outbox, log)
+				relay.Start(ctx)
+				return nil
+			},
+			Stop: func(context.Context) error {
+				return relay.Close()
+			},
+		})
+	}
+
+	// soft deleted rows past their retention are removed for good
+	if len(cfg.Database.SoftDelete.PurgeTables) > 0 {
+		var purger *repository.Purger
+		application.Register(app.Hook{
+			Name:      "purger",
+			DependsOn: []string{"database"},
+			Start: func(ctx context.Context) error {
+				purger = repository.NewPurger(db, cfg.Database.SoftDelete, log)
+				purger.Start(ctx)
+				return nil
+			},
+			Stop: func(context.Context) error {
+				purger.Close()
+				return nil
+			},
+		})
+	}
+
+	var auditLogger *audit.Logger
+	application.Register(app.Hook{
+		Name:      "audit",
+		DependsOn: []string{"database"},
+		Start: func(context.Context) (err error) {
+			auditLogger, err = audit.New(cfg, db.Pool, loggerService.GetApplication(), log)
+			if err != nil {
+				return fmt.Errorf("failed to initialize audit logger: %w", err)
+			}
+			// audit.Event(ctx) writes through this logger
+			audit.SetDefault(auditLogger)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			return auditLogger.Close()
+		},
+	})
+
+	// readiness: the checks listed in observability.health_checks.checks, custom ones are registered here as well
+	application.Register(app.Hook{
+		Name:      "health",
+		DependsOn: []string{"database", "redis"},
+		Start: func(ctx context.Context) error {
+			checker = health.New(cfg.Observability.HealthChecks, log)
+			checker.Register("db", health.Database(db))
+			checker.Register("redis", health.Redis(redisClient))
+			checker.Start(ctx)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			checker.Close()
+			return nil
+		},
+	})
+
+	// stops first: no new requests, running ones get the stop timeout (server.shutdown_timeout) to finish, within the drain timeout
+	var srv *server.Server
+	application.Register(app.Hook{
+		Name:      "http",
+		DependsOn: []string{"cache", "realtime", "audit", "health"},
+		Start: func(context.Context) error {
+			// formats of httpx.Render, the first one is the default
+			encoders := make([]httpx.Encoder, 0, len(cfg.Server.Negotiation.Formats))
+			for _, format := range cfg.Server.Negotiation.Formats {
+				encoders = append(encoders, httpx.NamedEncoder(format))
+			}
+			httpx.SetEncoders(encoders...)
+
+			r, adminRouter, err := newRouter(cfg, log, loggerService, db, redisClient, checker, listener)
+			if err != nil {
+				return err
+			}
+			srv, err = server.New(cfg, log, r)
+			if err != nil {
+				return fmt.Errorf("failed to initialize server: %w", err)
+			}
+			if adminRouter != nil {
+				srv.ServeAdmin(adminRouter)
+			}
+
+			if cfg.Server.Debug.Enabled && cfg.Server.Debug.Address != "" {
+				// no New Relic transactions for profiles, the listener is reachable from inside only
+				debugRouter := router.New(log, nil)
+				debugRouter.Use(middleware.RequestID, middleware.Recover)
+				if cfg.Server.Debug.Token != "" {
+					debugRouter.Use(middleware.RequireAdminToken(cfg.Server.Debug.Token))
+				}
+				debugRouter.Group("/debug", handler.NewDebugHandler().Register)
+				srv.ServeDebug(debugRouter)
+			}
+
+			go func() {
+				if err := srv.Start(); err != nil {
+					application.Fail(err)
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			// /readyz answers 503 from now on
+			checker.Shutdown()
+			// event streams and websockets never finish on their own, their clients reconnect elsewhere
+			sse.Shutdown()
+			hub.Close(ctx)
+			return srv.Shutdown(ctx)
+		},
+	})
+}
+
+// newRouter: middlewares and routes of the main listener, and of the admin listener when server.admin_listener is set (nil otherwise)
+// handlers and services reacting to postgres notifications register with listener.Handle here, it isn't started yet
+func newRouter(cfg *config.Config, log *zerolog.Logger, loggerService *loggerConfig.LoggerService, db *database.Database, redisClient *redis.Client, checker *health.Checker, listener *database.Listener) (*router.Router, *router.Router, error) {
+	// routes are named after their pattern in New Relic, handlers get the request scoped logger
+	r := router.New(log, loggerService.GetApplication())
+	if cfg.Observability.IsDatadog() {
+		r.UseDatadogTraceContext()
+	}
+	// client ip behind the proxies of server.trusted_proxies, before the access log and the rate limit read it
+	resolver, err := clientip.New(cfg.Server.TrustedProxies)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize client ip resolver: %w", err)
+	}
+	r.Use(middleware.RequestID, middleware.ClientIP(resolver), middleware.AccessLog(cfg.Server.AccessLog), middleware.Recover)
+	// HSTS, nosniff, framing, referrer and CSP of server.security_headers.profile, on every response including errors
+	r.Use(middleware.SecurityHeaders(cfg.Server.SecurityHeaders))
+
+	// request / response bodies of server.body_capture.routes (or the debug header) in the log, redacted
+	bodyCapture, err := middleware.BodyCapture(cfg.Server.BodyCapture, cfg.Primary.Env, loggerService.Redactor())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize body capture middleware: %w", err)
+	}
+	r.Use(bodyCapture)
+
+	// 503 for everything but probes and /admin while server.maintenance is on
+	maintenanceSwitch := maintenance.New(cfg.Server.Maintenance, redisClient)
+	r.Use(middleware.Maintenance(cfg.Server.Maintenance, maintenanceSwitch))
+
+	// per ip limits, the redis backend shares one budget between the replicas
+	limiter, err := ratelimit.New(cfg.Server.RateLimit, redisClient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize rate limiter: %w", err)
+	}
+	rateLimit, err := middleware.RateLimit(cfg.Server.RateLimit, limiter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize rate limit middleware: %w", err)
+	}
+	r.Use(rateLimit)
+
+	bodyLimit, err := middleware.BodyLimit(cfg.Server.BodyLimit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize body limit middleware: %w", err)
+	}
+	r.Use(bodyLimit)
+
+	// request deadline, queries and redis calls of the handler are cancelled with it
+	timeout, err := middleware.Timeout(cfg.Server.RequestTimeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize timeout middleware: %w", err)
+	}
+	r.Use(timeout)
+
+	// ETag + 304 for GET / HEAD, handlers with their own validators use internal/etag
+	r.Use(middleware.ETag(cfg.Server.ETag))
+	// cached GET responses of server.response_cache.routes, inside ETag so hits still answer 304
+	responseCache, err := middleware.ResponseCache(cfg.Server.ResponseCache, cache.Default())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize response cache middleware: %w", err)
+	}
+	r.Use(responseCache)
+	idempotencyMiddleware, err := middleware.Idempotency(cfg.Server.Idempotency, idempotency.NewRedis(redisClient))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize idempotency middleware: %w", err)
+	}
+	r.Use(idempotencyMiddleware)
+
+	// probes, metrics and /admin move to the admin listener when it is set, the main port doesn't serve them then
+	// no New Relic transactions for them there, scrapes and probes would drown the API ones
+	internal := r
+	var adminRouter *router.Router
+	if cfg.Server.AdminListener.Address != "" {
+		adminRouter = router.New(log, nil)
+		adminRouter.Use(middleware.RequestID, middleware.ClientIP(resolver), middleware.AccessLog(cfg.Server.AccessLog), middleware.Recover)
+		internal = adminRouter
+	}
+
+	healthHandler := handler.NewHealthHandler(checker)
+	internal.Get("/livez", healthHandler.Live)
+	internal.Get("/readyz", healthHandler.Ready)
+	internal.Get("/healthz", healthHandler.Ready)
+
+	// Prometheus scrape endpoint, default registry
+	internal.Handle("GET /metrics", promhttp.Handler())
+
+	// release and schema version
+	buildInfoHandler := handler.NewBuildInfoHandler(db, log)
+	r.Get("/version", buildInfoHandler.Get).Describe(handler.BuildInfoDoc)
+
+	// API contract of the routes registered with Describe
+	r.Get("/openapi.json", openapi.Handler(r, openapi.Info{
+		Title:   cfg.Observability.ServiceName,
+		Version: loggerConfig.ProcessMetadata().Version,
+	}))
+	if cfg.Primary.Env != "production" {
+		r.Get("/docs", openapi.UI(cfg.Observability.ServiceName, "/openapi.json", "/docs/assets"))
+		r.Mount("/docs/assets", openapi.Assets())
+	}
+
+	logLevelHandler := handler.NewLogLevelHandler(loggerService, log)
+	maintenanceHandler := handler.NewMaintenanceHandler(maintenanceSwitch, log)
+	internal.Group("/admin", func(admin *router.Router) {
+		admin.Use(middleware.RequireAdminToken(cfg.Auth.SecretKey))
+		admin.Get("/loglevel", logLevelHandler.Get)
+		admin.Put("/loglevel", logLevelHandler.Put)
+		admin.Get("/maintenance", maintenanceHandler.Get)
+		admin.Put("/maintenance", maintenanceHandler.Put)
+		admin.Delete("/maintenance", maintenanceHandler.Delete)
+		// pprof next to the admin routes unless it has a listener of its own
+		if cfg.Server.Debug.Enabled && cfg.Server.Debug.Address == "" {
+			admin.Group("/debug", handler.NewDebugHandler().Register)
+		}
+	})
+
+	// frontend bundle on every path no route claims, SPA routes fall back to index.html
+	if cfg.Server.Static.Enabled {
+		staticHandler, err := static.New(cfg.Server.Static)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize static file handler: %w", err)
+		}
+		r.Handle("/", staticHandler)
+	}
+	return r, adminRouter, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/database"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+)
+
+/**
+@dev migrate command: schema changes without starting the server
+
+go-boilerplate migrate                → every pending migration (same as on startup)
+go-boilerplate migrate -to 2          → up or down to version 2
+go-boilerplate migrate -rollback 1    → revert the last migration
+go-boilerplate migrate -dry-run ...   → print the migrations and SQL instead of running them (CI review step)
+go-boilerplate migrate status         → current / latest version and the pending migrations
+go-boilerplate migrate new add_orders → internal/database/migrations/NNN_add_orders.sql, run from backend/
+go-boilerplate migrate new -add-version orders → version column for repository.UpdateWithVersion
+go-boilerplate migrate new -add-soft-delete orders → deleted_at column for repository.SoftDeleteRepository
+*/
+
+func runMigrate(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "status":
+			return runMigrateStatus()
+		case "new":
+			return runMigrateNew(args[1:])
+		}
+	}
+
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	to := flags.Int("to", -1, "migrate up or down to this version, 0 reverts every migration")
+	rollback := flags.Int("rollback", 0, "revert this many of the applied migrations")
+	dryRun := flags.Bool("dry-run", false, "print the plan and its SQL without executing it")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-boilerplate migrate [-dry-run] [-to version | -rollback steps]")
+		fmt.Fprintln(flags.Output(), "       go-boilerplate migrate status")
+		fmt.Fprintln(flags.Output(), "       go-boilerplate migrate new [-dir path] name | -add-version table | -add-soft-delete table")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *to >= 0 && *rollback > 0 {
+		return errors.New("-to and -rollback can't be combined")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	loggerService := loggerConfig.NewLoggerService(cfg.Observability)
+	defer loggerService.Shutdown()
+	log := loggerConfig.NewLoggerWithService(cfg.Observability, loggerService)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *dryRun {
+		return printMigrationPlan(ctx, cfg, int32(*to), *rollback)
+	}
+
+	switch {
+	case *rollback > 0:
+		return database.Rollback(ctx, &log, cfg, *rollback)
+	case *to >= 0:
+		return database.MigrateTo(ctx, &log, cfg, int32(*to))
+	default:
+		if err := database.Migrate(ctx, &log, cfg); err != nil {
+			return err
+		}
+		if cfg.Database.Tenancy.Enabled {
+			return database.MigrateTenants(ctx, &log, cfg)
+		}
+		return nil
+	}
+}
+
+// printMigrationPlan: dry run of migrate with the same -to / -rollback semantics
+func printMigrationPlan(ctx context.Context, cfg *config.Config, to int32, rollback int) error {
+	target := database.LatestVersion
+	switch {
+	case rollback > 0:
+		status, err := database.MigrationStatus(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		if int32(rollback) > status.Current {
+			return fmt.Errorf("can't roll back %d migrations, database is at version %d", rollback, status.Current)
+		}
+		target = status.Current - int32(rollback)
+	case to >= 0:
+		target = to
+	}
+
+	plan, err := database.PlanMigrations(ctx, cfg, target)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		fmt.Println("-- nothing to migrate, database schema up to date")
+		return nil
+	}
+
+	for _, step := range plan {
+		fmt.Printf("-- %s %s (version after: %d)\n", step.Direction, step.Name, step.Version)
+		fmt.Println(step.SQL)
+		fmt.Println()
+	}
+	return nil
+}
+
+// runMigrateStatus: prints the schema status, exits non-zero while migrations are pending
+// so it can gate deploy pipelines
+func runMigrateStatus() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	status, err := database.MigrationStatus(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("current version: %d\n", status.Current)
+	fmt.Printf("latest version:  %d\n", status.Latest)
+	if status.Current > status.Latest {
+		fmt.Println("database is ahead of this binary, it was migrated by a newer release")
+		return nil
+	}
+	if len(status.Pending) == 0 {
+		fmt.Println("database schema up to date")
+		return nil
+	}
+
+	fmt.Println("pending migrations:")
+	for _, migration := range status.Pending {
+		fmt.Printf("  %03d  %s\n", migration.Version, migration.Name)
+	}
+	return fmt.Errorf("%d pending migration(s)", len(status.Pending))
+}
+
+const migrateNewUsage = "usage: go-boilerplate migrate new [-dir path] name | -add-version table | -add-soft-delete table"
+
+// runMigrateNew: scaffolds the next numbered migration file
+func runMigrateNew(args []string) error {
+	flags := flag.NewFlagSet("migrate new", flag.ContinueOnError)
+	dir := flags.String("dir", database.MigrationsSourceDir, "migrations directory")
+	addVersion := flags.String("add-version", "", "migration adding the optimistic locking version column to this table")
+	addSoftDelete := flags.String("add-soft-delete", "", "migration adding the soft delete deleted_at column to this table")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	var path string
+	var err error
+	switch {
+	case *addVersion != "" && *addSoftDelete == "" && flags.NArg() == 0:
+		path, err = database.NewVersionColumnMigration(*dir, *addVersion)
+	case *addSoftDelete != "" && *addVersion == "" && flags.NArg() == 0:
+		path, err = database.NewSoftDeleteMigration(*dir, *addSoftDelete)
+	case *addVersion == "" && *addSoftDelete == "" && flags.NArg() == 1:
+		path, err = database.NewMigrationFile(*dir, flags.Arg(0))
+	default:
+		return errors.New(migrateNewUsage)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("created", path)
+	return nil
+}
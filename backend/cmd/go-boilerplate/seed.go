@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/database"
+	"github.com/anuragShingare30/go-boilerplate/internal/database/seeds"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+)
+
+// @dev seed command: go-boilerplate seed [-env staging], seeders of primary.env by default
+// @dev migrations run first, seeders expect the current schema
+
+func runSeed(args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	env := flags.String("env", cfg.Primary.Env, "environment whose seeders run")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	loggerService := loggerConfig.NewLoggerService(cfg.Observability)
+	defer loggerService.Shutdown()
+	log := loggerConfig.NewLoggerWithService(cfg.Observability, loggerService)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := database.Migrate(ctx, &log, cfg); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	db, err := database.New(cfg, &log, loggerService)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	applied, err := seeds.Run(ctx, db, *env, &log)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("applied %d seeder(s) for %s\n", applied, *env)
+	return nil
+}
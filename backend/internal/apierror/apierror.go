@@ -0,0 +1,163 @@
+package apierror
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+)
+
+/**
+@dev API errors: handlers return errors, one place decides the status, the code and what the client sees
+
+user, err := h.users.Get(ctx, id)
+if err != nil {
+	apierror.Write(w, r, err)   → repository.ErrNotFound → 404 {"error": {"code": "not_found", ...}}
+	return
+}
+
+if taken {
+	apierror.Write(w, r, apierror.Conflict("email is already taken"))   → 409 with that message
+	return
+}
+
+errors of the other packages are mapped in mapping.go, apierror.Register adds feature errors at startup
+anything unknown is a 500 with a generic message, the cause only goes to the log and New Relic
+*/
+
+// Code: machine readable error code of the envelope, clients switch on it instead of on messages
+type Code string
+
+const (
+	CodeBadRequest           Code = "bad_request"
+	CodeValidation           Code = "validation_failed"
+	CodeUnauthorized         Code = "unauthorized"
+	CodeForbidden            Code = "forbidden"
+	CodeNotFound             Code = "not_found"
+	CodeConflict             Code = "conflict"
+	CodePreconditionFailed   Code = "precondition_failed"
+	CodePreconditionRequired Code = "precondition_required"
+	CodePayloadTooLarge      Code = "body_too_large"
+	CodeUnsupportedMedia     Code = "unsupported_media_type"
+	CodeRateLimited          Code = "rate_limited"
+	CodeCanceled             Code = "canceled"
+	CodeInternal             Code = "internal_error"
+	CodeUnavailable          Code = "unavailable"
+	CodeTimeout              Code = "timeout"
+)
+
+// Error: an error with the response it should become
+type Error struct {
+	Status  int
+	Code    Code
+	Message string // shown to the client, never put internals in it
+	Details []httpx.FieldError
+	// RetryAfter: Retry-After header of 429 and 503 responses, 0 leaves it out
+	RetryAfter time.Duration
+	Err        error // cause, logged but not shown
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return string(e.Code) + ": " + e.Message + ": " + e.Err.Error()
+	}
+	return string(e.Code) + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode: error.code field of logged errors (logger.ErrorCoder)
+func (e *Error) ErrorCode() string {
+	return string(e.Code)
+}
+
+// Is: errors.Is(err, apierror.ErrNotFound) holds for every not found error, whatever its message
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap: copy of e with err as cause
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// errors.Is targets, one per code which handlers check for
+var (
+	ErrNotFound     = &Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: "resource not found"}
+	ErrConflict     = &Error{Status: http.StatusConflict, Code: CodeConflict, Message: "resource conflict"}
+	ErrUnauthorized = &Error{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Message: "authentication required"}
+	ErrForbidden    = &Error{Status: http.StatusForbidden, Code: CodeForbidden, Message: "not allowed"}
+	ErrInternal     = &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "internal server error"}
+)
+
+// New: error with status, code and message
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest: 400, the request can't be handled as it is
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// Validation: 400 with the invalid fields
+func Validation(fields ...httpx.FieldError) *Error {
+	err := New(http.StatusBadRequest, CodeValidation, "request validation failed")
+	err.Details = fields
+	return err
+}
+
+// Unauthorized: 401, missing or invalid credentials
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden: 403, authenticated but not allowed
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound: 404, resource is what the client asked for, e.g. "user"
+func NotFound(resource string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, resource+" not found")
+}
+
+// Conflict: 409, the request clashes with the current state (taken email, stale version)
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// RateLimited: 429, retryAfter is sent as Retry-After
+func RateLimited(retryAfter time.Duration) *Error {
+	err := New(http.StatusTooManyRequests, CodeRateLimited, "too many requests")
+	err.RetryAfter = retryAfter
+	return err
+}
+
+// Unavailable: 503, the service can't answer right now (draining, dependency down)
+func Unavailable(message string) *Error {
+	return New(http.StatusServiceUnavailable, CodeUnavailable, message)
+}
+
+// Internal: 500 with err as cause, the client only gets the generic message
+func Internal(err error) *Error {
+	return ErrInternal.Wrap(err)
+}
+
+// From: err as *Error, through the mappings of mapping.go, unknown errors are internal
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	if mapped := lookup(err); mapped != nil {
+		return mapped
+	}
+	return Internal(err)
+}
@@ -0,0 +1,71 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/database"
+	"github.com/anuragShingare30/go-boilerplate/internal/database/pagination"
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+	"github.com/anuragShingare30/go-boilerplate/internal/lock"
+	"github.com/anuragShingare30/go-boilerplate/internal/repository"
+	"github.com/anuragShingare30/go-boilerplate/internal/session"
+)
+
+// @dev sentinel errors of the other packages and the response they become, checked with errors.Is in order
+// @dev feature packages add theirs with Register instead of importing apierror into the repositories
+
+type mapping struct {
+	target error
+	err    *Error
+}
+
+var (
+	mappingsMu sync.RWMutex
+	mappings   = []mapping{
+		{repository.ErrNotFound, ErrNotFound},
+		// StaleObjectError is an ErrConflict as well
+		{repository.ErrStaleObject, Conflict("resource was changed by someone else, reload and retry")},
+		{repository.ErrConflict, ErrConflict},
+		{repository.ErrInvalidSort, BadRequest("invalid sort column")},
+		{pagination.ErrInvalidCursor, BadRequest("invalid cursor")},
+		{session.ErrNotFound, Unauthorized("no valid session")},
+		{lock.ErrNotAcquired, Conflict("resource is busy, retry later")},
+		{database.ErrDraining, Unavailable("service is shutting down")},
+		{context.DeadlineExceeded, New(http.StatusGatewayTimeout, CodeTimeout, "request timed out")},
+		// the client is gone, 499 like nginx, only the access log sees it
+		{context.Canceled, New(499, CodeCanceled, "request canceled")},
+	}
+)
+
+// Register: err (errors.Is target) is answered as apiErr, call it at startup
+// later registrations win over the built in ones
+func Register(err error, apiErr *Error) {
+	mappingsMu.Lock()
+	defer mappingsMu.Unlock()
+	mappings = append([]mapping{{err, apiErr}}, mappings...)
+}
+
+// lookup: the mapped response of err with err as cause, nil for unknown errors
+func lookup(err error) *Error {
+	var bindErr *httpx.BindError
+	if errors.As(err, &bindErr) {
+		return &Error{Status: bindErr.Status, Code: Code(bindErr.Code), Message: bindErr.Message, Details: bindErr.Fields, Err: err}
+	}
+	// handlers reading the body themselves hit the body limit as well
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return New(http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "request body too large").Wrap(err)
+	}
+
+	mappingsMu.RLock()
+	defer mappingsMu.RUnlock()
+	for _, m := range mappings {
+		if errors.Is(err, m.target) {
+			return m.err.Wrap(err)
+		}
+	}
+	return nil
+}
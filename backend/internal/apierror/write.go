@@ -0,0 +1,62 @@
+package apierror
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/rs/zerolog"
+)
+
+// Write: err as error envelope with the status of its mapping
+// server errors are logged with their cause and noticed on the New Relic transaction, client errors only at debug level
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := From(err)
+	ctx := r.Context()
+
+	if apiErr.Status >= http.StatusInternalServerError {
+		loggerConfig.Err(ctx, err).
+			Int("status", apiErr.Status).
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Msg("request failed")
+
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(newrelic.Error{
+				Message: err.Error(),
+				Class:   string(apiErr.Code),
+				Attributes: map[string]any{
+					"http.status": apiErr.Status,
+				},
+			})
+		}
+	} else {
+		loggerConfig.ErrWithLevel(ctx, zerolog.DebugLevel, err).
+			Int("status", apiErr.Status).
+			Str("path", r.URL.Path).
+			Msg("request rejected")
+	}
+
+	if apiErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", retryAfterHeader(apiErr.RetryAfter))
+	}
+	httpx.WriteError(w, r, apiErr.Status, httpx.ErrorDetail{
+		Code:    string(apiErr.Code),
+		Message: apiErr.Message,
+		Details: apiErr.Details,
+	})
+}
+
+// Status: the status err is answered with, for middlewares which only need the status
+func Status(err error) int {
+	return From(err).Status
+}
+
+// retryAfterHeader: whole seconds, at least 1
+func retryAfterHeader(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second)
+	return strconv.Itoa(max(seconds, 1))
+}
@@ -0,0 +1,294 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev lifecycle of the process: subsystems register hooks, Run starts them in dependency order and stops them in reverse
+
+application := app.New(&log, cfg.Server.ShutdownTimeout, cfg.Server.DrainTimeout)
+application.Register(app.Hook{Name: "redis", Phase: app.PhaseClose, Start: ..., Stop: ...})
+application.Register(app.Hook{Name: "cache", DependsOn: []string{"redis"}, Start: ...})
+application.Run(ctx)
+    → order: every hook after the ones it depends on, registration order otherwise
+      unknown dependencies, cycles and duplicate names fail before anything starts
+    → Start one after the other, a failing Start stops the hooks started before it in reverse and Run returns its error
+    → running until SIGINT / SIGTERM, ctx is done, or a background part called Fail (e.g. the listener stopped)
+    → the ctx of Start is cancelled, background loops started with it wind down
+    → Stop phase by phase, in reverse start order within a phase:
+        drain  no new requests, in-flight requests and background jobs finish, all of them within the drain timeout
+        flush  buffered logs, traces and New Relic data go out
+        close  database / redis connections are closed
+    → each phase has one deadline shared by its hooks: the drain timeout for drain, the stop timeout of New for flush and close
+      each Stop has its StopTimeout (the stop timeout of New when 0) within it, at most the rest of the phase:
+      a hook that doesn't finish in time is left behind and the next one stops, one stuck subsystem can't hold the rest forever
+      the whole shutdown takes drain timeout + 2 × stop timeout at most
+    → "stopped" per hook and "shutdown phase done" per phase with their durations, a slow shutdown shows where it hung
+
+Stop errors are logged and joined into the result of Run, every started hook gets its Stop
+*/
+
+// Phase: when a hook stops during the shutdown
+type Phase int
+
+const (
+	// PhaseDrain: stops taking work and finishes what is running, the default
+	PhaseDrain Phase = iota
+	// PhaseFlush: sends buffered telemetry, after everything that produces it has stopped
+	PhaseFlush
+	// PhaseClose: closes connections, after everything that uses them has stopped
+	PhaseClose
+)
+
+var phaseNames = [...]string{"drain", "flush", "close"}
+
+func (p Phase) String() string {
+	if p < 0 || int(p) >= len(phaseNames) {
+		return fmt.Sprintf("phase(%d)", int(p))
+	}
+	return phaseNames[p]
+}
+
+// Hook: one subsystem of the process
+type Hook struct {
+	Name      string
+	DependsOn []string
+	Phase     Phase
+	// Start: sets the subsystem up and returns once it is usable, long running work goes into goroutines
+	// ctx is cancelled when the shutdown begins
+	Start func(ctx context.Context) error
+	// Stop: releases the subsystem, ctx has the StopTimeout of the hook, nil for nothing to stop
+	Stop        func(ctx context.Context) error
+	StopTimeout time.Duration
+}
+
+// App: the hooks of the process
+type App struct {
+	log          *zerolog.Logger
+	stopTimeout  time.Duration
+	drainTimeout time.Duration
+	hooks        []Hook
+
+	failOnce sync.Once
+	failed   chan error
+}
+
+// New: app whose hooks get stopTimeout to stop unless they set their own, the drain phase as a whole gets drainTimeout
+// flush and close get stopTimeout per phase, drainTimeout 0 gives the drain phase stopTimeout too
+func New(logger *zerolog.Logger, stopTimeout, drainTimeout time.Duration) *App {
+	return &App{
+		log:          logger,
+		stopTimeout:  stopTimeout,
+		drainTimeout: drainTimeout,
+		failed:       make(chan error, 1),
+	}
+}
+
+// Register: adds hooks, call it before Run
+func (a *App) Register(hooks ...Hook) {
+	a.hooks = append(a.hooks, hooks...)
+}
+
+// Fail: a background part of a hook stopped for good, Run shuts the app down and returns err
+// the first call wins, later ones are logged only
+func (a *App) Fail(err error) {
+	reported := false
+	a.failOnce.Do(func() {
+		a.failed <- err
+		reported = true
+	})
+	if !reported {
+		a.log.Error().Err(err).Msg("app failure during shutdown")
+	}
+}
+
+// Run: starts every hook, waits for a signal / ctx / Fail and stops them again
+func (a *App) Run(ctx context.Context) error {
+	ordered, err := a.order()
+	if err != nil {
+		return err
+	}
+
+	ctx, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var runErr error
+	started := make([]Hook, 0, len(ordered))
+	for _, hook := range ordered {
+		startedAt := time.Now()
+		if hook.Start != nil {
+			if err := call(func() error { return hook.Start(runCtx) }); err != nil {
+				runErr = fmt.Errorf("start %s: %w", hook.Name, err)
+				break
+			}
+		}
+		a.log.Debug().Str("hook", hook.Name).Dur("duration", time.Since(startedAt)).Msg("started")
+		started = append(started, hook)
+	}
+
+	if runErr == nil {
+		a.log.Info().Int("hooks", len(started)).Msg("app started")
+		select {
+		case <-ctx.Done():
+			a.log.Info().Msg("shutdown signal received")
+		case runErr = <-a.failed:
+			a.log.Error().Err(runErr).Msg("app failed, shutting down")
+		}
+	} else {
+		a.log.Error().Err(runErr).Msg("app failed to start, stopping started hooks")
+	}
+
+	// default signal handling again, a second Ctrl-C kills the process right away
+	stopSignals()
+	cancel()
+
+	return errors.Join(runErr, a.stop(started))
+}
+
+// stop: Stop of the started hooks phase by phase, in reverse order within a phase, each within its timeout and the phase deadline
+func (a *App) stop(started []Hook) error {
+	stoppingAt := time.Now()
+
+	var errs []error
+	for phase := PhaseDrain; phase <= PhaseClose; phase++ {
+		phaseAt := time.Now()
+		var deadline time.Time
+		if timeout := a.phaseTimeout(phase); timeout > 0 {
+			deadline = phaseAt.Add(timeout)
+		}
+		stopped := 0
+		for i := len(started) - 1; i >= 0; i-- {
+			hook := started[i]
+			if hook.Phase != phase || hook.Stop == nil {
+				continue
+			}
+
+			timeout := hook.StopTimeout
+			if timeout <= 0 {
+				timeout = a.stopTimeout
+			}
+			if err := a.stopHook(hook, timeout, deadline); err != nil {
+				a.log.Warn().Err(err).Str("hook", hook.Name).Str("phase", phase.String()).Msg("stop incomplete")
+				errs = append(errs, fmt.Errorf("stop %s: %w", hook.Name, err))
+			}
+			stopped++
+		}
+		if stopped > 0 {
+			a.log.Info().Str("phase", phase.String()).Int("hooks", stopped).Dur("duration", time.Since(phaseAt)).Msg("shutdown phase done")
+		}
+	}
+
+	a.log.Info().Dur("duration", time.Since(stoppingAt)).Msg("app stopped")
+	return errors.Join(errs...)
+}
+
+// phaseTimeout: time all hooks of phase share, 0 for no limit
+func (a *App) phaseTimeout(phase Phase) time.Duration {
+	if phase == PhaseDrain && a.drainTimeout > 0 {
+		return a.drainTimeout
+	}
+	return a.stopTimeout
+}
+
+// stopHook: Stop of hook, given up on once timeout or the phase deadline (zero for none) has passed even if Stop ignores its ctx
+func (a *App) stopHook(hook Hook, timeout time.Duration, deadline time.Time) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, fmt.Errorf("timed out after %s", timeout))
+		defer cancel()
+	}
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadlineCause(ctx, deadline, fmt.Errorf("%s phase deadline passed", hook.Phase))
+		defer cancel()
+	}
+
+	stoppedAt := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- call(func() error { return hook.Stop(ctx) })
+	}()
+
+	select {
+	case err := <-done:
+		a.log.Info().Str("hook", hook.Name).Str("phase", hook.Phase.String()).Dur("duration", time.Since(stoppedAt)).Msg("stopped")
+		return err
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// call: fn with a panic turned into an error
+func call(fn func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return fn()
+}
+
+// order: hooks after their dependencies, registration order between independent ones
+func (a *App) order() ([]Hook, error) {
+	byName := make(map[string]Hook, len(a.hooks))
+	for _, hook := range a.hooks {
+		if hook.Name == "" {
+			return nil, errors.New("app: hook without name")
+		}
+		if _, ok := byName[hook.Name]; ok {
+			return nil, fmt.Errorf("app: hook %s registered twice", hook.Name)
+		}
+		byName[hook.Name] = hook
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(a.hooks))
+	ordered := make([]Hook, 0, len(a.hooks))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("app: dependency cycle %s", strings.Join(append(path, name), " → "))
+		}
+		state[name] = visiting
+		hook := byName[name]
+		for _, dep := range hook.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return fmt.Errorf("app: hook %s depends on unknown hook %s", name, dep)
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		ordered = append(ordered, hook)
+		return nil
+	}
+
+	for _, hook := range a.hooks {
+		if err := visit(hook.Name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,321 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/clientip"
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/rs/zerolog"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+/**
+@dev audit trail: who did what to which resource, kept apart from the application logs
+
+Flow:
+
+audit.Event(ctx).Action("user.delete").Resource("user").Target(id).Emit()
+    → actor and request id are taken from the context
+        → Record is written to every configured sink (audit.sinks)
+            → file: append-only JSON lines in their own rotating file (audit.file)
+            → database: row in audit_events, the table rejects UPDATE / DELETE
+            → newrelic: AuditEvent custom event
+
+the level / sampling / redaction of the application logger never applies, an audit record is always written
+*/
+
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultDenied  = "denied"
+
+	// newRelicEventType: custom event type queried with FROM AuditEvent SELECT ...
+	newRelicEventType = "AuditEvent"
+	// unknownActor: used when neither the builder nor the context names an actor
+	unknownActor = "system"
+)
+
+// Record: one entry of the audit trail, the schema is the same in every sink
+type Record struct {
+	Time      time.Time      `json:"time"`
+	Actor     string         `json:"actor"`
+	Action    string         `json:"action"`
+	Resource  string         `json:"resource,omitempty"`
+	Target    string         `json:"target,omitempty"`
+	Result    string         `json:"result"`
+	RequestID string         `json:"request_id,omitempty"`
+	ClientIP  string         `json:"client_ip,omitempty"` // per server.trusted_proxies, of the request the record was emitted in
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// Sink: destination of audit records
+type Sink interface {
+	Write(ctx context.Context, record Record) error
+}
+
+// Logger: writes audit records to the configured sinks
+type Logger struct {
+	sinks []Sink
+	file  *lumberjack.Logger
+}
+
+// New: creates the audit logger for cfg.Audit
+// pool is needed by the database sink and nrApp by the newrelic sink, both may be nil otherwise
+func New(cfg *config.Config, pool *pgxpool.Pool, nrApp *newrelic.Application, logger *zerolog.Logger) (*Logger, error) {
+	l := &Logger{}
+	if !cfg.Audit.Enabled {
+		logger.Info().Msg("audit trail disabled")
+		return l, nil
+	}
+
+	for _, name := range cfg.Audit.Sinks {
+		switch name {
+		case "file":
+			l.file = &lumberjack.Logger{
+				Filename:   cfg.Audit.File.Path,
+				MaxSize:    cfg.Audit.File.MaxSizeMB,
+				MaxAge:     cfg.Audit.File.MaxAgeDays,
+				MaxBackups: cfg.Audit.File.MaxBackups,
+				Compress:   cfg.Audit.File.Compress,
+				LocalTime:  true,
+			}
+			l.sinks = append(l.sinks, &fileSink{logger: zerolog.New(l.file)})
+		case "database":
+			if pool == nil {
+				return nil, errors.New("audit sink database needs a database pool")
+			}
+			l.sinks = append(l.sinks, &databaseSink{pool: pool})
+		case "newrelic":
+			if nrApp == nil {
+				// license key missing, no point in failing startup for it
+				logger.Warn().Msg("audit sink newrelic configured but New Relic is not initialized, skipping")
+				continue
+			}
+			l.sinks = append(l.sinks, &newRelicSink{app: nrApp})
+		default:
+			return nil, fmt.Errorf("unknown audit sink %q", name)
+		}
+	}
+
+	logger.Info().Strs("sinks", cfg.Audit.Sinks).Msg("audit trail enabled")
+	return l, nil
+}
+
+// Write: writes the record to every sink, errors of all sinks are joined
+func (l *Logger) Write(ctx context.Context, record Record) error {
+	var errs []error
+	for _, sink := range l.sinks {
+		if err := sink.Write(ctx, record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close: closes the audit file, database and New Relic are closed by their owners
+func (l *Logger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// Event: starts a new audit record, send it with Emit
+func (l *Logger) Event(ctx context.Context) *EventBuilder {
+	return &EventBuilder{
+		logger: l,
+		ctx:    ctx,
+		record: Record{
+			Actor:     ActorFromContext(ctx),
+			Result:    ResultSuccess,
+			RequestID: loggerConfig.RequestIDFromContext(ctx),
+			ClientIP:  clientip.FromContext(ctx),
+		},
+	}
+}
+
+// defaultLogger: used by the package level Event, writes nowhere until SetDefault is called
+var defaultLogger = &Logger{}
+
+// SetDefault: makes l the logger of the package level Event
+func SetDefault(l *Logger) {
+	defaultLogger = l
+}
+
+// Event: starts a new audit record on the default audit logger
+func Event(ctx context.Context) *EventBuilder {
+	return defaultLogger.Event(ctx)
+}
+
+// EventBuilder: fluent builder of one audit record
+type EventBuilder struct {
+	logger *Logger
+	ctx    context.Context
+	record Record
+}
+
+// Actor: overrides the actor taken from the context
+func (b *EventBuilder) Actor(actor string) *EventBuilder {
+	b.record.Actor = actor
+	return b
+}
+
+// Action: what was done, "<resource>.<verb>" like user.delete
+func (b *EventBuilder) Action(action string) *EventBuilder {
+	b.record.Action = action
+	return b
+}
+
+// Resource: type of the object the action was done on
+func (b *EventBuilder) Resource(resource string) *EventBuilder {
+	b.record.Resource = resource
+	return b
+}
+
+// Target: id of the object the action was done on
+func (b *EventBuilder) Target(target string) *EventBuilder {
+	b.record.Target = target
+	return b
+}
+
+// Result: outcome of the action, ResultSuccess when not set
+func (b *EventBuilder) Result(result string) *EventBuilder {
+	b.record.Result = result
+	return b
+}
+
+// Failure: marks the action as failed and keeps the error message
+func (b *EventBuilder) Failure(err error) *EventBuilder {
+	b.record.Result = ResultFailure
+	if err != nil {
+		b.Meta("error", err.Error())
+	}
+	return b
+}
+
+// Meta: adds free-form detail, values have to be JSON encodable
+func (b *EventBuilder) Meta(key string, value any) *EventBuilder {
+	if b.record.Metadata == nil {
+		b.record.Metadata = make(map[string]any)
+	}
+	b.record.Metadata[key] = value
+	return b
+}
+
+// Emit: writes the record, a failing sink is logged and reported to the caller
+func (b *EventBuilder) Emit() error {
+	b.record.Time = time.Now().UTC()
+	if b.record.Actor == "" {
+		b.record.Actor = unknownActor
+	}
+
+	if err := b.logger.Write(b.ctx, b.record); err != nil {
+		loggerConfig.Err(b.ctx, err).Str("action", b.record.Action).Msg("failed to write audit record")
+		return err
+	}
+	return nil
+}
+
+type actorKey struct{}
+
+// ContextWithActor: stores the authenticated actor, audit records created with ctx use it
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext: actor stored by ContextWithActor, "" when there is none
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// fileSink: one JSON line per record
+type fileSink struct {
+	logger zerolog.Logger
+}
+
+func (s *fileSink) Write(_ context.Context, record Record) error {
+	event := s.logger.Log().
+		Time("time", record.Time).
+		Str("actor", record.Actor).
+		Str("action", record.Action).
+		Str("resource", record.Resource).
+		Str("target", record.Target).
+		Str("result", record.Result)
+	if record.RequestID != "" {
+		event = event.Str(loggerConfig.RequestIDField, record.RequestID)
+	}
+	if record.ClientIP != "" {
+		event = event.Str("client_ip", record.ClientIP)
+	}
+	if len(record.Metadata) > 0 {
+		event = event.Interface("metadata", record.Metadata)
+	}
+	event.Send()
+	return nil
+}
+
+// databaseSink: inserts into audit_events (migrations/002_audit_events.sql, client_ip since 005)
+type databaseSink struct {
+	pool *pgxpool.Pool
+}
+
+func (s *databaseSink) Write(ctx context.Context, record Record) error {
+	metadata := []byte("{}")
+	if len(record.Metadata) > 0 {
+		encoded, err := json.Marshal(record.Metadata)
+		if err != nil {
+			return fmt.Errorf("encoding audit metadata: %w", err)
+		}
+		metadata = encoded
+	}
+
+	// the record must survive a cancelled request, the action already happened
+	ctx = context.WithoutCancel(ctx)
+
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO audit_events (occurred_at, actor, action, resource, target, result, request_id, client_ip, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		record.Time, record.Actor, record.Action, record.Resource, record.Target, record.Result, record.RequestID, record.ClientIP, metadata,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting audit event: %w", err)
+	}
+	return nil
+}
+
+// newRelicSink: AuditEvent custom events, metadata keys are flattened as metadata.<key>
+type newRelicSink struct {
+	app *newrelic.Application
+}
+
+func (s *newRelicSink) Write(_ context.Context, record Record) error {
+	params := map[string]any{
+		"actor":     record.Actor,
+		"action":    record.Action,
+		"resource":  record.Resource,
+		"target":    record.Target,
+		"result":    record.Result,
+		"requestId": record.RequestID,
+		"clientIp":  record.ClientIP,
+	}
+	for key, value := range record.Metadata {
+		switch value.(type) {
+		case string, bool, int, int64, float64:
+			params["metadata."+key] = value
+		default:
+			// custom event attributes only take primitives
+			params["metadata."+key] = fmt.Sprint(value)
+		}
+	}
+
+	s.app.RecordCustomEvent(newRelicEventType, params)
+	return nil
+}
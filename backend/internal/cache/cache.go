@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/singleflight"
+)
+
+/**
+@dev cache: one caching idiom for handlers and repositories, values are bytes, typed values go through json.go
+
+cache.New(cfg, redisClient, &log) → backend of cache.backend
+    → redis: shared between instances of the service, survives restarts
+    → memory: per process, for tests / local runs without redis, at most cache.max_entries values
+users := c.Namespace("users") → keys are "<cache.namespace>:users:<key>", hits / misses are counted per namespace
+
+value, err := users.GetOrSet(ctx, id, time.Minute, func(ctx context.Context) ([]byte, error) { ... })
+    → hit: cached value
+    → miss: load is called and its value cached for ttl (cache.default_ttl when 0)
+    → backend down: load is called and the error logged, a broken cache doesn't fail the request
+cache.Remember(ctx, key, ttl, load) → the same, stampede safe and typed (remember.go)
+*/
+
+// ErrMiss: key is not cached (or expired)
+var ErrMiss = errors.New("cache: miss")
+
+// Cache: namespaced key/value cache with TTL
+type Cache interface {
+	// Get: cached value of key, ErrMiss when there is none
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set: caches value for ttl, cache.default_ttl when ttl is 0
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete: removes the keys, missing keys are no error
+	Delete(ctx context.Context, keys ...string) error
+	// GetOrSet: cached value of key, or the value of load which is cached for ttl
+	GetOrSet(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) ([]byte, error)) ([]byte, error)
+	// Remember: GetOrSet with one load per key at a time and optional negative caching (remember.go)
+	Remember(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) ([]byte, error), opts ...RememberOption) ([]byte, error)
+	// Namespace: cache with keys below "<namespace>:<name>:"
+	Namespace(name string) Cache
+}
+
+// store: backend of a cache, keys are already namespaced
+type store interface {
+	get(ctx context.Context, key string) ([]byte, bool, error)
+	set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	delete(ctx context.Context, keys ...string) error
+}
+
+// cache: namespacing, default TTL, GetOrSet and metrics over a store
+type cache struct {
+	store      store
+	namespace  string
+	defaultTTL time.Duration
+	log        *zerolog.Logger
+	// shared with the namespaces of the cache, flights are keyed by the namespaced key
+	group *singleflight.Group
+}
+
+// New: cache of cache.backend, client is only used by the redis backend
+func New(cfg *config.Config, client goredis.UniversalClient, logger *zerolog.Logger) (Cache, error) {
+	switch cfg.Cache.Backend {
+	case "memory":
+		return NewMemory(cfg.Cache, logger), nil
+	case "redis":
+		if client == nil {
+			return nil, fmt.Errorf("redis cache backend needs a redis client")
+		}
+		return NewRedis(client, cfg.Cache, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.Cache.Backend)
+	}
+}
+
+func newCache(s store, cfg config.CacheConfig, logger *zerolog.Logger) *cache {
+	return &cache{
+		store:      s,
+		namespace:  cfg.Namespace,
+		defaultTTL: cfg.DefaultTTL,
+		log:        logger,
+		group:      &singleflight.Group{},
+	}
+}
+
+func (c *cache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, found, err := c.store.get(ctx, c.key(key))
+	if err != nil {
+		cacheErrors.WithLabelValues(c.namespace, "get").Inc()
+		return nil, fmt.Errorf("failed to get %s from cache: %w", c.key(key), err)
+	}
+	if !found {
+		cacheMisses.WithLabelValues(c.namespace).Inc()
+		return nil, ErrMiss
+	}
+	cacheHits.WithLabelValues(c.namespace).Inc()
+	return value, nil
+}
+
+func (c *cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = c.defaultTTL
+	}
+	if err := c.store.set(ctx, c.key(key), value, ttl); err != nil {
+		cacheErrors.WithLabelValues(c.namespace, "set").Inc()
+		return fmt.Errorf("failed to set %s in cache: %w", c.key(key), err)
+	}
+	return nil
+}
+
+func (c *cache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	namespaced := make([]string, len(keys))
+	for i, key := range keys {
+		namespaced[i] = c.key(key)
+	}
+	if err := c.store.delete(ctx, namespaced...); err != nil {
+		cacheErrors.WithLabelValues(c.namespace, "delete").Inc()
+		return fmt.Errorf("failed to delete from cache: %w", err)
+	}
+	return nil
+}
+
+func (c *cache) GetOrSet(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	value, err := c.Get(ctx, key)
+	if err == nil {
+		return value, nil
+	}
+	if !errors.Is(err, ErrMiss) {
+		c.logger(ctx).Warn().Err(err).Msg("cache unavailable, loading value")
+	}
+
+	value, err = load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Set(ctx, key, value, ttl); err != nil {
+		c.logger(ctx).Warn().Err(err).Msg("failed to cache loaded value")
+	}
+	return value, nil
+}
+
+func (c *cache) Namespace(name string) Cache {
+	child := *c
+	child.namespace = joinKey(c.namespace, name)
+	return &child
+}
+
+// key: key below the namespace of c
+func (c *cache) key(key string) string {
+	return joinKey(c.namespace, key)
+}
+
+// logger: request scoped logger when there is one, so the line carries request_id / trace.id
+func (c *cache) logger(ctx context.Context) *zerolog.Logger {
+	if ctxLogger := zerolog.Ctx(ctx); ctxLogger != zerolog.DefaultContextLogger && ctxLogger.GetLevel() != zerolog.Disabled {
+		return ctxLogger
+	}
+	return c.log
+}
+
+func joinKey(namespace, key string) string {
+	if namespace == "" {
+		return key
+	}
+	return namespace + ":" + key
+}
+
+// defaultCache: used by handlers and repositories, nil until SetDefault is called
+var defaultCache Cache
+
+// SetDefault: makes c the shared cache, call it once at startup
+func SetDefault(c Cache) {
+	defaultCache = c
+}
+
+// Default: the cache set with SetDefault, nil without
+func Default() Cache {
+	return defaultCache
+}
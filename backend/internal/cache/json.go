@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// @dev typed values: stored as JSON, e.g. cache.GetOrSetJSON(ctx, users, id, 0, func(ctx context.Context) (User, error) { ... })
+
+// GetJSON: cached value of key decoded into T, ErrMiss when there is none
+func GetJSON[T any](ctx context.Context, c Cache, key string) (T, error) {
+	var value T
+	data, err := c.Get(ctx, key)
+	if err != nil {
+		return value, err
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, fmt.Errorf("failed to decode cached %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// SetJSON: caches value as JSON for ttl
+func SetJSON[T any](ctx context.Context, c Cache, key string, value T, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s for cache: %w", key, err)
+	}
+	return c.Set(ctx, key, data, ttl)
+}
+
+// GetOrSetJSON: GetOrSet with a typed value, a cached value which doesn't decode is loaded again
+func GetOrSetJSON[T any](ctx context.Context, c Cache, key string, ttl time.Duration, load func(ctx context.Context) (T, error)) (T, error) {
+	var loaded T
+	var loadedSet bool
+
+	data, err := c.GetOrSet(ctx, key, ttl, func(ctx context.Context) ([]byte, error) {
+		value, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		loaded, loadedSet = value, true
+		return json.Marshal(value)
+	})
+	if err != nil {
+		return loaded, err
+	}
+	if loadedSet {
+		return loaded, nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		// stale shape after a deploy, replace it
+		value, err = load(ctx)
+		if err != nil {
+			return value, err
+		}
+		// failing to replace it only costs the next lookup another load
+		_ = SetJSON(ctx, c, key, value, ttl)
+	}
+	return value, nil
+}
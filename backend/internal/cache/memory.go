@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// memoryStore: per process map, expired values are dropped when read or when the store is full
+type memoryStore struct {
+	mu         sync.Mutex
+	entries    map[string]memoryEntry
+	maxEntries int // unlimited when 0
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemory: in-process cache, not shared between instances
+func NewMemory(cfg config.CacheConfig, logger *zerolog.Logger) Cache {
+	return newCache(&memoryStore{
+		entries:    make(map[string]memoryEntry),
+		maxEntries: cfg.MaxEntries,
+	}, cfg, logger)
+}
+
+func (s *memoryStore) get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	// callers may change the slice, the cached value stays as it was set
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+func (s *memoryStore) set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; !exists && s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		s.evictLocked()
+	}
+	s.entries[key] = memoryEntry{
+		value:   append([]byte(nil), value...),
+		expires: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+func (s *memoryStore) delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// evictLocked: drops the expired values, or the one expiring first when none has expired
+func (s *memoryStore) evictLocked() {
+	now := time.Now()
+	var soonest string
+	var soonestExpires time.Time
+
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, key)
+			continue
+		}
+		if soonest == "" || entry.expires.Before(soonestExpires) {
+			soonest, soonestExpires = key, entry.expires
+		}
+	}
+	if len(s.entries) >= s.maxEntries && soonest != "" {
+		delete(s.entries, soonest)
+	}
+}
@@ -0,0 +1,25 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// @dev cache metrics: hit ratio per namespace = rate(cache_hits_total) / (rate(cache_hits_total) + rate(cache_misses_total))
+
+var (
+	cacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "Cache lookups which found a value, by namespace.",
+	}, []string{"namespace"})
+
+	cacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_misses_total",
+		Help: "Cache lookups which found no value, by namespace.",
+	}, []string{"namespace"})
+
+	cacheErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_errors_total",
+		Help: "Failed cache operations, by namespace and operation.",
+	}, []string{"namespace", "operation"})
+)
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// redisStore: values as plain redis strings with an expiry
+type redisStore struct {
+	client goredis.UniversalClient
+}
+
+// NewRedis: cache on client, shared by every instance of the service
+func NewRedis(client goredis.UniversalClient, cfg config.CacheConfig, logger *zerolog.Logger) Cache {
+	return newCache(&redisStore{client: client}, cfg, logger)
+}
+
+func (s *redisStore) get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (s *redisStore) set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *redisStore) delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 1 {
+		return s.client.Del(ctx, keys[0]).Err()
+	}
+	// one DEL per key, keys of a namespace can live in different cluster slots
+	_, err := s.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(ctx, key)
+		}
+		return nil
+	})
+	return err
+}
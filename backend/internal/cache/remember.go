@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+/**
+@dev cache-aside with stampede protection
+
+user, err := cache.Remember(ctx, "user:"+id, time.Minute, func(ctx context.Context) (User, error) {
+	return repo.Get(ctx, id)
+}, cache.Negative(10*time.Second, repository.ErrNotFound))
+    → hit: cached value
+    → miss: one load per key and process, concurrent callers of the same key wait for it (singleflight)
+        → the cache is read again inside the flight, a caller arriving right after a load doesn't load again
+    → Negative: a load failing with that error caches a tombstone for its ttl,
+      lookups of missing rows return the error again without reaching the database
+
+a caller whose ctx is done stops waiting, the shared load goes on for the others
+a panicking load is returned as an error to every waiting caller, nothing is cached for it
+*/
+
+// ErrNoCache: Remember was used before SetDefault
+var ErrNoCache = errors.New("cache: no default cache, call cache.SetDefault")
+
+// negativeValue: tombstone of a negative entry, no JSON value starts with a NUL byte
+var negativeValue = []byte("\x00cache:negative")
+
+// RememberOption: changes the behaviour of Remember
+type RememberOption func(*rememberOptions)
+
+type rememberOptions struct {
+	negativeTTL time.Duration
+	negativeErr error
+}
+
+// Negative: loads failing with err (errors.Is) are cached for ttl and return err on the following lookups
+// ttl 0 caches the tombstone for cache.default_ttl like Set does, pass a short ttl of its own to retry missing rows soon
+func Negative(ttl time.Duration, err error) RememberOption {
+	return func(o *rememberOptions) {
+		o.negativeTTL = ttl
+		o.negativeErr = err
+	}
+}
+
+func (c *cache) Remember(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) ([]byte, error), opts ...RememberOption) ([]byte, error) {
+	var o rememberOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if value, err := c.lookup(ctx, key, &o); err == nil || !errors.Is(err, ErrMiss) {
+		return value, err
+	}
+
+	// the caller's ctx may end before the others', the shared load runs without its cancellation
+	flightCtx := context.WithoutCancel(ctx)
+	result := c.group.DoChan(c.key(key), func() (any, error) {
+		if value, err := c.lookup(flightCtx, key, &o); err == nil || !errors.Is(err, ErrMiss) {
+			return value, err
+		}
+
+		value, err := safeLoad(flightCtx, load)
+		if err != nil {
+			if o.negativeErr != nil && errors.Is(err, o.negativeErr) {
+				if setErr := c.Set(flightCtx, key, negativeValue, o.negativeTTL); setErr != nil {
+					c.logger(ctx).Warn().Err(setErr).Msg("failed to cache negative result")
+				}
+			}
+			return nil, err
+		}
+
+		if err := c.Set(flightCtx, key, value, ttl); err != nil {
+			c.logger(ctx).Warn().Err(err).Msg("failed to cache loaded value")
+		}
+		return value, nil
+	})
+
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// safeLoad: load with a panic turned into an error, a panic inside the flight would kill the process
+// instead of reaching the callers waiting on it
+func safeLoad(ctx context.Context, load func(ctx context.Context) ([]byte, error)) (value []byte, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("cache: load panicked: %v", p)
+		}
+	}()
+	return load(ctx)
+}
+
+// lookup: cached value, the negative error for a tombstone, ErrMiss when the value has to be loaded
+// a failing backend counts as a miss, the value is loaded then
+func (c *cache) lookup(ctx context.Context, key string, o *rememberOptions) ([]byte, error) {
+	value, err := c.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, ErrMiss) {
+			c.logger(ctx).Warn().Err(err).Msg("cache unavailable, loading value")
+		}
+		return nil, ErrMiss
+	}
+	if o.negativeErr != nil && string(value) == string(negativeValue) {
+		return nil, o.negativeErr
+	}
+	return value, nil
+}
+
+// RememberJSON: Cache.Remember with a typed value stored as JSON
+func RememberJSON[T any](ctx context.Context, c Cache, key string, ttl time.Duration, load func(ctx context.Context) (T, error), opts ...RememberOption) (T, error) {
+	var value T
+	data, err := c.Remember(ctx, key, ttl, func(ctx context.Context) ([]byte, error) {
+		loaded, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(loaded)
+	}, opts...)
+	if err != nil {
+		return value, err
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, fmt.Errorf("failed to decode cached %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// Remember: RememberJSON on the default cache
+func Remember[T any](ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) (T, error), opts ...RememberOption) (T, error) {
+	if defaultCache == nil {
+		var value T
+		return value, ErrNoCache
+	}
+	return RememberJSON(ctx, defaultCache, key, ttl, load, opts...)
+}
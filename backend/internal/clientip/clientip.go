@@ -0,0 +1,166 @@
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+)
+
+/**
+@dev client ip: the address of the user, not of the ALB / Cloudflare edge in front of the server
+
+server.trusted_proxies.cidrs: ["10.0.0.0/8"]
+peer 203.0.113.7 (not trusted)                         → 203.0.113.7, headers are ignored, anyone can send them
+peer 10.0.1.5, X-Forwarded-For: 198.51.100.2, 10.0.2.9 → right to left, trusted hops skipped → 198.51.100.2
+peer 10.0.1.5, Forwarded: for="[2001:db8::1]:4711"     → 2001:db8::1
+peer 10.0.1.5, X-Real-IP / CF-Connecting-IP: 192.0.2.4 → 192.0.2.4, single value headers are taken as they are
+
+server.trusted_proxies.headers are tried in order, the first one giving an address wins
+the left end of X-Forwarded-For is whatever the client wrote, it is only used when every hop is trusted
+*/
+
+// Resolver: client ip of requests behind the trusted proxies
+type Resolver struct {
+	trusted []netip.Prefix
+	headers []string
+}
+
+// New: resolver of cfg, fails on invalid CIDRs
+func New(cfg config.TrustedProxyConfig) (*Resolver, error) {
+	trusted := make([]netip.Prefix, 0, len(cfg.CIDRs))
+	for _, cidr := range cfg.CIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("server.trusted_proxies.cidrs: %w", err)
+		}
+		trusted = append(trusted, prefix.Masked())
+	}
+	headers := make([]string, len(cfg.Headers))
+	for i, header := range cfg.Headers {
+		headers[i] = http.CanonicalHeaderKey(header)
+	}
+	return &Resolver{trusted: trusted, headers: headers}, nil
+}
+
+// ClientIP: address of the client of r, the peer address unless it is a trusted proxy
+func (res *Resolver) ClientIP(r *http.Request) string {
+	peer, ok := PeerIP(r)
+	if !ok {
+		return r.RemoteAddr
+	}
+	if !res.Trusted(peer) {
+		return peer.String()
+	}
+
+	for _, header := range res.headers {
+		values := r.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+		var ip netip.Addr
+		switch header {
+		case "X-Forwarded-For":
+			ip = res.walk(splitList(values), parseAddr)
+		case "Forwarded":
+			ip = res.walk(splitList(values), forwardedFor)
+		default:
+			ip, _ = parseAddr(strings.TrimSpace(values[0]))
+		}
+		if ip.IsValid() {
+			return ip.String()
+		}
+	}
+	return peer.String()
+}
+
+// Trusted: ip is one of server.trusted_proxies.cidrs
+func (res *Resolver) Trusted(ip netip.Addr) bool {
+	for _, prefix := range res.trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// walk: first hop from the right which isn't trusted, each proxy appends the address it got the request from
+// an entry which doesn't parse ends the walk, the hops right of it were added by trusted proxies
+func (res *Resolver) walk(hops []string, parse func(string) (netip.Addr, bool)) netip.Addr {
+	var last netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, ok := parse(hops[i])
+		if !ok {
+			break
+		}
+		if !res.Trusted(ip) {
+			return ip
+		}
+		last = ip
+	}
+	return last
+}
+
+// PeerIP: address of the connection, the last proxy when there is one
+func PeerIP(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return parseAddr(host)
+}
+
+// splitList: the comma separated entries of every value of a list header
+func splitList(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for hop := range strings.SplitSeq(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// forwardedFor: address of the for= parameter of one Forwarded element (RFC 7239)
+// "unknown" and obfuscated identifiers ("_hidden") are no address
+func forwardedFor(element string) (netip.Addr, bool) {
+	for pair := range strings.SplitSeq(element, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if !strings.EqualFold(name, "for") {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		// IPv6 comes as "[2001:db8::1]:4711", IPv4 possibly with a port
+		if host, _, err := net.SplitHostPort(value); err == nil {
+			value = host
+		}
+		return parseAddr(strings.Trim(value, "[]"))
+	}
+	return netip.Addr{}, false
+}
+
+// parseAddr: ip of s, IPv4 mapped IPv6 addresses as IPv4 so the CIDRs of either form match
+func parseAddr(s string) (netip.Addr, bool) {
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap().WithZone(""), true
+}
+
+type ipKey struct{}
+
+// ContextWithIP: stores the client ip, set by middleware.ClientIP for the rest of the request
+func ContextWithIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ipKey{}, ip)
+}
+
+// FromContext: client ip stored by ContextWithIP, "" when there is none
+func FromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(ipKey{}).(string)
+	return ip
+}
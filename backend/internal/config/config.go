@@ -2,12 +2,14 @@ package config
 
 // importing packages
 import (
+	"fmt"
+	"net"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/go-playground/validator/v10"
-	_ "github.com/joho/godotenv/autoload"
-	"github.com/knadh/koanf/providers/env"
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/knadh/koanf/v2"
 	"github.com/rs/zerolog"
 )
@@ -15,87 +17,1056 @@ import (
 // @dev to load all env variables in struct when server starts
 // @dev this loads all env variable into struct
 
-
 type Config struct {
-	Primary  Primary        `koanf:"primary" validation:"required"`
-	Server   ServerConfig   `koanf:"server" validation:"required"`
-	Redis    RedisConfig    `koanf:"redis" validation:"required"`
-	Database DatabaseConfig `koanf:"database" validation:"required"`
-	Auth     AuthConfig     `koanf:"auth" validation:"required"`
-	Observability *ObservabilityConfig `koanf:"observability" validation:"required"`
+	Primary       Primary              `koanf:"primary" validate:"required"`
+	Server        ServerConfig         `koanf:"server" validate:"required"`
+	Redis         RedisConfig          `koanf:"redis" validate:"required"`
+	Database      DatabaseConfig       `koanf:"database" validate:"required"`
+	Auth          AuthConfig           `koanf:"auth" validate:"required"`
+	Observability *ObservabilityConfig `koanf:"observability"`
+	Audit         AuditConfig          `koanf:"audit"`
+	Outbox        OutboxConfig         `koanf:"outbox"`
+	Crypto        CryptoConfig         `koanf:"crypto"`
+	Cache         CacheConfig          `koanf:"cache"`
+	Lock          LockConfig           `koanf:"lock"`
+	Session       SessionConfig        `koanf:"session"`
+	PubSub        PubSubConfig         `koanf:"pubsub"`
+	Streams       StreamsConfig        `koanf:"streams"`
+}
+
+// OutboxConfig: relay of the transactional outbox (internal/outbox)
+type OutboxConfig struct {
+	Enabled        bool          `koanf:"enabled"`
+	Sink           string        `koanf:"sink" validate:"required_if=Enabled true,omitempty,oneof=webhook kafka redis"`
+	PollInterval   time.Duration `koanf:"poll_interval" validate:"gt=0"`
+	BatchSize      int           `koanf:"batch_size" validate:"gt=0"`
+	PublishTimeout time.Duration `koanf:"publish_timeout" validate:"gt=0"`
+	MaxBackoff     time.Duration `koanf:"max_backoff" validate:"gt=0"`
+	// published messages are deleted after this long, kept forever when 0
+	Retention time.Duration           `koanf:"retention" validate:"min_duration=0s"`
+	Webhook   OutboxWebhookConfig     `koanf:"webhook"`
+	Kafka     OutboxKafkaConfig       `koanf:"kafka"`
+	Redis     OutboxRedisStreamConfig `koanf:"redis"`
+}
+
+type OutboxWebhookConfig struct {
+	URL     string        `koanf:"url" validate:"omitempty,url"`
+	Secret  string        `koanf:"secret"` // signs the body, Outbox-Signature header
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+type OutboxKafkaConfig struct {
+	Brokers          []string `koanf:"brokers"`
+	AutoCreateTopics bool     `koanf:"auto_create_topics"`
+}
+
+// OutboxRedisStreamConfig: stream on the redis of redis.address
+type OutboxRedisStreamConfig struct {
+	Stream string `koanf:"stream"` // "{topic}" is replaced with the event topic
+	MaxLen int64  `koanf:"max_len" validate:"min=0"`
+}
+
+// Validate: the selected sink needs its settings
+func (c *OutboxConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Sink {
+	case "webhook":
+		if c.Webhook.URL == "" {
+			return fmt.Errorf("outbox.webhook.url is required for the webhook sink")
+		}
+	case "kafka":
+		if len(c.Kafka.Brokers) == 0 {
+			return fmt.Errorf("outbox.kafka.brokers is required for the kafka sink")
+		}
+	}
+	return nil
+}
+
+func DefaultOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		PollInterval:   time.Second,
+		BatchSize:      100,
+		PublishTimeout: 10 * time.Second,
+		MaxBackoff:     10 * time.Minute,
+		Retention:      7 * 24 * time.Hour,
+		Webhook:        OutboxWebhookConfig{Timeout: 10 * time.Second},
+		Redis:          OutboxRedisStreamConfig{Stream: "outbox:{topic}"},
+	}
+}
+
+// CacheConfig: shared cache of handlers and repositories (internal/cache)
+type CacheConfig struct {
+	Backend    string        `koanf:"backend" validate:"oneof=redis memory"`
+	Namespace  string        `koanf:"namespace"` // prefix of every key, the service name by default
+	DefaultTTL time.Duration `koanf:"default_ttl" validate:"gt=0"`
+	// memory backend only, unlimited when 0
+	MaxEntries int `koanf:"max_entries" validate:"min=0"`
+}
+
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		Backend:    "redis",
+		DefaultTTL: 5 * time.Minute,
+		MaxEntries: 10000,
+	}
+}
+
+// LockConfig: distributed locks on redis (internal/lock)
+type LockConfig struct {
+	// expiry of a lock whose holder died, WithLock extends it while the work runs
+	TTL           time.Duration `koanf:"ttl" validate:"min_duration=1s"`
+	RetryInterval time.Duration `koanf:"retry_interval" validate:"gt=0"`
+}
+
+func DefaultLockConfig() LockConfig {
+	return LockConfig{
+		TTL:           30 * time.Second,
+		RetryInterval: 100 * time.Millisecond,
+	}
+}
+
+// PubSubConfig: redis pub/sub subscriptions (internal/pubsub)
+type PubSubConfig struct {
+	// goroutines per subscription, 1 keeps the messages of a topic in order
+	Workers int `koanf:"workers" validate:"min=1"`
+	// messages waiting per subscription, more are dropped
+	BufferSize int `koanf:"buffer_size" validate:"min=0"`
+}
+
+func DefaultPubSubConfig() PubSubConfig {
+	return PubSubConfig{
+		Workers:    1,
+		BufferSize: 256,
+	}
+}
+
+// StreamsConfig: redis streams producer / consumer groups (internal/stream)
+type StreamsConfig struct {
+	// XADD trims the stream to about this many entries, unlimited when 0
+	MaxLen    int64         `koanf:"max_len" validate:"min=0"`
+	BatchSize int64         `koanf:"batch_size" validate:"min=1"`
+	Block     time.Duration `koanf:"block" validate:"gt=0"`
+	// pending entries idle this long are taken over, their consumer crashed or the handler failed
+	ClaimMinIdle  time.Duration `koanf:"claim_min_idle" validate:"gt=0"`
+	ClaimInterval time.Duration `koanf:"claim_interval" validate:"gt=0"`
+	// deliveries before an entry goes to the dead letter stream
+	MaxDeliveries int `koanf:"max_deliveries" validate:"min=1"`
+}
+
+func DefaultStreamsConfig() StreamsConfig {
+	return StreamsConfig{
+		MaxLen:        100000,
+		BatchSize:     10,
+		Block:         5 * time.Second,
+		ClaimMinIdle:  time.Minute,
+		ClaimInterval: 30 * time.Second,
+		MaxDeliveries: 5,
+	}
+}
+
+// SessionConfig: server side sessions in redis (internal/session)
+type SessionConfig struct {
+	// a session without request for this long expires
+	// above the touch interval of internal/session (1m), a session used every 59s would expire otherwise
+	IdleTimeout time.Duration `koanf:"idle_timeout" validate:"min_duration=2m"`
+	// expiry after login, no matter how active the session is
+	AbsoluteTimeout time.Duration       `koanf:"absolute_timeout" validate:"gtefield=IdleTimeout"`
+	Cookie          SessionCookieConfig `koanf:"cookie"`
+}
+
+type SessionCookieConfig struct {
+	Name     string `koanf:"name" validate:"required"`
+	Domain   string `koanf:"domain"`
+	Path     string `koanf:"path"`
+	Secure   bool   `koanf:"secure"` // only disable for local http development
+	SameSite string `koanf:"same_site" validate:"oneof=lax strict none"`
+}
+
+// Validate: browsers drop SameSite=None cookies without Secure
+func (c *SessionConfig) Validate() error {
+	if c.Cookie.SameSite == "none" && !c.Cookie.Secure {
+		return fmt.Errorf("session cookie with same_site none must be secure")
+	}
+	return nil
+}
+
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{
+		IdleTimeout:     30 * time.Minute,
+		AbsoluteTimeout: 24 * time.Hour,
+		Cookie: SessionCookieConfig{
+			Name:     "session_id",
+			Path:     "/",
+			Secure:   true,
+			SameSite: "lax",
+		},
+	}
+}
+
+// CryptoConfig: keys of the field encryption (internal/crypto), disabled without keys
+type CryptoConfig struct {
+	// aes-gcm (default) or xchacha20-poly1305, values encrypted with the other one still decrypt
+	Algorithm string `koanf:"algorithm" validate:"omitempty,oneof=aes-gcm xchacha20-poly1305"`
+	// id of the key new values are encrypted with, the other keys only decrypt (rotation)
+	KeyID string `koanf:"key_id"`
+	// base64 encoded 32 byte keys by id
+	Keys map[string]string `koanf:"keys"`
+	// data keys encrypted with AWS KMS by id (base64 ciphertext blobs), decrypted at startup
+	KMSKeys   map[string]string `koanf:"kms_keys"`
+	KMSRegion string            `koanf:"kms_region"`
+}
+
+// Enabled: any key configured
+func (c *CryptoConfig) Enabled() bool {
+	return len(c.Keys) > 0 || len(c.KMSKeys) > 0
+}
+
+// Validate: the encryption key has to be one of the configured keys
+func (c *CryptoConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.KeyID == "" {
+		return fmt.Errorf("crypto key_id is required when keys are configured")
+	}
+	for id := range c.Keys {
+		if _, ok := c.KMSKeys[id]; ok {
+			return fmt.Errorf("crypto key %q is configured as plain and as kms key", id)
+		}
+	}
+	_, plain := c.Keys[c.KeyID]
+	_, kms := c.KMSKeys[c.KeyID]
+	if !plain && !kms {
+		return fmt.Errorf("crypto key_id %q is not one of the configured keys", c.KeyID)
+	}
+	return nil
+}
+
+// AuditConfig: audit trail kept apart from application logs
+// sinks: file (append-only JSON lines), database (audit_events table), newrelic (AuditEvent custom events)
+type AuditConfig struct {
+	Enabled bool          `koanf:"enabled"`
+	Sinks   []string      `koanf:"sinks" validate:"dive,oneof=file database newrelic"`
+	File    LogFileConfig `koanf:"file"`
 }
 
 type Primary struct {
-	Env string `koanf:"env" validation:"required"`
+	Env string `koanf:"env" validate:"required"`
 }
 
 type ServerConfig struct {
-	Port               string   `koanf:"port" validation:"required"`
-	ReadTimeout        int      `koanf:"read_timeout" validation:"required"`
-	WriteTimeout       int      `koanf:"write_timeout" validation:"required"`
-	IdleTimeout        int      `koanf:"idle_timeout" validation:"required"`
-	CORSAllowedOrigins []string `koanf:"cors_allowed_origins" validation:"required"`
+	Port               string      `koanf:"port" validate:"required,port"`
+	ReadTimeout        int         `koanf:"read_timeout" validate:"required,gt=0"`
+	WriteTimeout       int         `koanf:"write_timeout" validate:"required,gt=0"`
+	IdleTimeout        int         `koanf:"idle_timeout" validate:"required,gt=0"`
+	CORSAllowedOrigins []string    `koanf:"cors_allowed_origins" validate:"required"`
+	TLS                TLSConfig   `koanf:"tls"`
+	HTTP2              HTTP2Config `koanf:"http2"`
+	// stop timeout of each app hook: running requests get it, then the database drain
+	// also the deadline the flush and the close phase of the shutdown each share across their hooks
+	ShutdownTimeout time.Duration `koanf:"shutdown_timeout" validate:"min_duration=0s"`
+	// deadline of the whole drain phase of the shutdown (http, workers), server.shutdown_timeout when 0
+	// logs are flushed and connections closed after it, keep it below the grace period of the orchestrator
+	DrainTimeout    time.Duration         `koanf:"drain_timeout" validate:"min_duration=0s"`
+	AccessLog       AccessLogConfig       `koanf:"access_log"`
+	RateLimit       RateLimitConfig       `koanf:"rate_limit"`
+	BodyLimit       BodyLimitConfig       `koanf:"body_limit"`
+	RequestTimeout  RequestTimeoutConfig  `koanf:"request_timeout"`
+	Debug           DebugConfig           `koanf:"debug"`
+	ETag            ETagConfig            `koanf:"etag"`
+	Static          StaticConfig          `koanf:"static"`
+	Idempotency     IdempotencyConfig     `koanf:"idempotency"`
+	SSE             SSEConfig             `koanf:"sse"`
+	WebSocket       WebSocketConfig       `koanf:"websocket"`
+	Maintenance     MaintenanceConfig     `koanf:"maintenance"`
+	BodyCapture     BodyCaptureConfig     `koanf:"body_capture"`
+	AdminListener   AdminListenerConfig   `koanf:"admin_listener"`
+	Negotiation     NegotiationConfig     `koanf:"negotiation"`
+	ResponseCache   ResponseCacheConfig   `koanf:"response_cache"`
+	SecurityHeaders SecurityHeadersConfig `koanf:"security_headers"`
+	TrustedProxies  TrustedProxyConfig    `koanf:"trusted_proxies"`
+}
+
+// TrustedProxyConfig: proxies in front of the server whose forwarding headers are believed (clientip.Resolver)
+// rate limits, the access log and audit records use the client ip resolved with it
+type TrustedProxyConfig struct {
+	// CIDRs of the load balancers / CDN edges, e.g. the VPC range of an ALB or the published ranges of Cloudflare
+	// empty: no header is trusted, the peer address is the client
+	CIDRs []string `koanf:"cidrs" validate:"dive,cidr"`
+	// tried in order: "X-Forwarded-For" and "Forwarded" are hop lists, any other (X-Real-IP, CF-Connecting-IP) a single address
+	Headers []string `koanf:"headers"`
 }
 
+// SecurityHeadersConfig: browser hardening headers on every response of the main listener (middleware.SecurityHeaders)
+type SecurityHeadersConfig struct {
+	// "api": responses are data, nothing may render or frame them, "html": pages (server.static) work, "off" sends none
+	// empty: api in production (html with server.static enabled), off elsewhere
+	Profile string `koanf:"profile" validate:"omitempty,oneof=off api html"`
+	// Strict-Transport-Security on https requests (TLS or X-Forwarded-Proto), 0 leaves it out
+	HSTSMaxAge            time.Duration `koanf:"hsts_max_age" validate:"min_duration=0s"`
+	HSTSIncludeSubdomains bool          `koanf:"hsts_include_subdomains"`
+	// Content-Security-Policy instead of the one of the profile
+	CSP string `koanf:"csp"`
+}
+
+// ResponseCacheConfig: GET responses of single routes kept on the cache of cache.backend (middleware.ResponseCache)
+type ResponseCacheConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// by route pattern, e.g. {"GET /products/{id}": {"ttl": "1m", "invalidated_by": ["PUT /products/{id}"]}}
+	Routes map[string]ResponseCacheRoute `koanf:"routes" validate:"dive"`
+	// larger responses are not cached
+	MaxBodyBytes int64 `koanf:"max_body_bytes" validate:"min=0"`
+}
+
+// ResponseCacheRoute: caching of one GET route
+type ResponseCacheRoute struct {
+	TTL time.Duration `koanf:"ttl" validate:"min_duration=1s,max_duration=24h"`
+	// request headers whose values are part of the cache key besides path and query, e.g. ["Accept-Language"]
+	Vary []string `koanf:"vary"`
+	// cached per Authorization / Cookie, without it requests carrying credentials are never cached
+	Private bool `koanf:"private"`
+	// route patterns of mutations whose 2xx responses drop every cached response of this route
+	InvalidatedBy []string `koanf:"invalidated_by"`
+}
+
+// NegotiationConfig: response formats of httpx.Render picked by the Accept header, error envelopes included
+type NegotiationConfig struct {
+	// "json", "msgpack", "xml", the first one answers clients without a matching Accept
+	Formats []string `koanf:"formats" validate:"min=1,dive,oneof=json msgpack xml"`
+}
+
+// AdminListenerConfig: second listener for the health probes, /metrics, /admin and pprof (server.Server.ServeAdmin)
+// bind it to a port the public load balancer doesn't forward, the probes of the orchestrator have to use it then
+type AdminListenerConfig struct {
+	// e.g. ":9091", empty serves them on the main port next to the API
+	Address string `koanf:"address" validate:"omitempty,hostname_port"`
+}
+
+// Validate: the admin listener needs a port of its own, a debug listener off loopback needs a token
+func (c *ServerConfig) Validate() error {
+	if c.Debug.Enabled && c.Debug.Address != "" && c.Debug.Token == "" {
+		host, _, err := net.SplitHostPort(c.Debug.Address)
+		if err != nil {
+			return fmt.Errorf("debug address: %w", err)
+		}
+		if !isLoopbackHost(host) {
+			return fmt.Errorf("debug address %s is reachable from outside the host, set server.debug.token or bind it to 127.0.0.1", c.Debug.Address)
+		}
+	}
+
+	if c.AdminListener.Address == "" {
+		return nil
+	}
+
+	_, port, err := net.SplitHostPort(c.AdminListener.Address)
+	if err != nil {
+		return fmt.Errorf("admin listener address: %w", err)
+	}
+	if port == c.Port {
+		return fmt.Errorf("admin listener port %s is the main port", port)
+	}
+	if c.Debug.Enabled && c.Debug.Address != "" {
+		if _, debugPort, err := net.SplitHostPort(c.Debug.Address); err == nil && debugPort == port {
+			return fmt.Errorf("admin listener port %s is the debug port", port)
+		}
+	}
+	return nil
+}
+
+// isLoopbackHost: host only reachable from the machine itself, an empty host binds every interface
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// BodyCaptureConfig: request and response bodies in the log, for reproducing what a client sent (middleware.BodyCapture)
+// bodies go through the log redaction, still only turn it on where the data may be logged
+type BodyCaptureConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// route patterns captured on every request, e.g. ["POST /orders"]
+	Routes []string `koanf:"routes"`
+	// request header which captures a single request outside production, "" turns the header off
+	Header string `koanf:"header"`
+	// bytes kept of each body, the rest is counted only
+	MaxBytes int `koanf:"max_bytes" validate:"min=1"`
+	// media types whose bodies are logged ("application/json", "text/*"), others show up with their size only
+	ContentTypes []string `koanf:"content_types"`
+}
+
+// MaintenanceConfig: 503 for the API while operators work on it, the process keeps running (middleware.Maintenance)
+type MaintenanceConfig struct {
+	// on from startup, independent of the redis key
+	Enabled bool `koanf:"enabled"`
+	// redis key which turns it on for every replica (PUT /admin/maintenance), "" for the flag only
+	Key string `koanf:"key"`
+	// how long a replica answers from its last read of the key
+	PollInterval time.Duration `koanf:"poll_interval" validate:"min_duration=100ms"`
+	// Retry-After of the 503 when the maintenance has no end time
+	RetryAfter time.Duration `koanf:"retry_after" validate:"min_duration=1s"`
+	// path prefixes still served, the probes and the admin API which turns it off again
+	Exclude []string `koanf:"exclude"`
+}
+
+// WebSocketConfig: connections of ws.Hub
+type WebSocketConfig struct {
+	// host patterns of other origins allowed to connect ("app.example.com", "*.example.com"), the own host always is
+	// browsers don't apply CORS to websockets, the origin check is all there is against cross site connects
+	OriginPatterns []string `koanf:"origin_patterns"`
+	// a connection without pong within PongTimeout of a ping is closed
+	PingInterval time.Duration `koanf:"ping_interval" validate:"min_duration=1s"`
+	PongTimeout  time.Duration `koanf:"pong_timeout" validate:"min_duration=1s"`
+	WriteTimeout time.Duration `koanf:"write_timeout" validate:"min_duration=1s"`
+	// larger inbound messages close the connection
+	MaxMessageBytes int64 `koanf:"max_message_bytes" validate:"min=1"`
+	// outbound messages waiting for a slow client, the connection is closed when it runs full
+	SendQueue int `koanf:"send_queue" validate:"min=1"`
+	// inbound messages per second and connection with bursts up to Burst, 0 is unlimited
+	Rate  float64 `koanf:"rate" validate:"min=0"`
+	Burst int     `koanf:"burst" validate:"min=0"`
+	// Broadcast / SendToUser reach the connections of the other replicas over redis pub/sub
+	Bridge bool `koanf:"bridge"`
+}
+
+// SSEConfig: server-sent event streams of sse.Stream
+type SSEConfig struct {
+	// comment line sent on quiet streams, proxies and load balancers close idle connections otherwise
+	Heartbeat time.Duration `koanf:"heartbeat" validate:"min_duration=1s"`
+	// events waiting for a slow client, the stream is closed when it runs full
+	QueueSize int `koanf:"queue_size" validate:"min=1"`
+	// reconnect delay announced to the browser, 0 keeps the browser default
+	Retry time.Duration `koanf:"retry" validate:"min_duration=0s"`
+}
+
+// IdempotencyConfig: responses of mutating requests replayed for retries with the same Idempotency-Key (middleware.Idempotency)
+type IdempotencyConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// how long a response is replayed, clients must not reuse a key within it
+	TTL time.Duration `koanf:"ttl" validate:"min_duration=1m"`
+	// reservation of a key while its first request runs, a crashed replica frees it after this
+	LockTTL time.Duration `koanf:"lock_ttl" validate:"min_duration=1s"`
+	Methods []string      `koanf:"methods" validate:"dive,oneof=POST PUT PATCH DELETE"`
+	// route patterns which refuse requests without the header, e.g. ["POST /payments"]
+	Required []string `koanf:"required"`
+	// larger responses are not stored, their retries run again
+	MaxBodyBytes int64 `koanf:"max_body_bytes" validate:"min=0"`
+}
+
+// StaticConfig: frontend bundle served from the binary or a directory (static.Handler)
+type StaticConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// bundle directory on disk, empty: the bundle embedded at build time (internal/static/dist)
+	Dir string `koanf:"dir" validate:"omitempty,dir"`
+	// unmatched GET routes without file extension get index.html, the client side router takes over
+	SPA bool `koanf:"spa"`
+	// path prefixes of fingerprinted files (app-3f2a9c.js), cached for CacheMaxAge as immutable
+	ImmutablePrefixes []string      `koanf:"immutable_prefixes"`
+	CacheMaxAge       time.Duration `koanf:"cache_max_age" validate:"min_duration=0s"`
+	// path prefixes which never fall back to index.html, unknown API routes stay JSON 404s
+	Exclude []string `koanf:"exclude"`
+}
+
+// ETagConfig: ETags and 304 answers for GET / HEAD responses (middleware.ETag)
+type ETagConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// W/"..." tags, for responses which are equivalent but not byte for byte the same (e.g. compressed later)
+	Weak bool `koanf:"weak"`
+	// larger responses are streamed without a tag instead of being held in memory
+	MaxBytes int64 `koanf:"max_bytes" validate:"min=0"`
+}
+
+// DebugConfig: pprof profiles and runtime stats (handler.DebugHandler), off unless needed for an incident
+type DebugConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// own listener under /debug, e.g. "127.0.0.1:6060" reached through a port-forward, without write timeout for long profiles
+	// empty: served under /admin/debug behind the admin token, on server.admin_listener when set, the main port otherwise
+	Address string `koanf:"address" validate:"omitempty,hostname_port"`
+	// bearer token of the own listener, required when address binds more than loopback, e.g. ":6060" in a pod
+	Token string `koanf:"token"`
+}
+
+// RequestTimeoutConfig: deadline of the request context (middleware.Timeout)
+// database and redis calls of the handler are cancelled with it, the client gets 504
+type RequestTimeoutConfig struct {
+	// server.write_timeout when 0, a longer one can't be answered before the server cuts the connection
+	Default time.Duration `koanf:"default" validate:"min_duration=0s"`
+	// deadlines of single routes by route pattern, e.g. {"POST /reports": "2m"}, 0 turns it off for the route
+	Routes map[string]time.Duration `koanf:"routes" validate:"dive,min_duration=0s"`
+}
+
+// BodyLimitConfig: maximum size of request bodies in bytes (middleware.BodyLimit), 0 is unlimited
+type BodyLimitConfig struct {
+	MaxBytes int64 `koanf:"max_bytes" validate:"min=0"`
+	// sizes of single routes by route pattern, e.g. {"POST /uploads": 52428800}
+	Routes map[string]int64 `koanf:"routes" validate:"dive,min=0"`
+}
+
+// AccessLogConfig: one log line per request (middleware.AccessLog)
+type AccessLogConfig struct {
+	Enabled bool   `koanf:"enabled"`
+	Level   string `koanf:"level" validate:"oneof=debug info warn error"` // 5xx responses are logged as error regardless
+	// request paths not logged, e.g. probes and scrapes which would drown the rest
+	Exclude []string `koanf:"exclude"`
+}
+
+// RateLimitConfig: requests per client ip (middleware.RateLimit), over the limit answers 429
+type RateLimitConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// memory: budget per instance, redis: one budget shared by every replica
+	Backend  string        `koanf:"backend" validate:"oneof=memory redis"`
+	Requests int           `koanf:"requests" validate:"gt=0"`
+	Window   time.Duration `koanf:"window" validate:"gt=0"`
+	// extra limits of single routes by route pattern, e.g. {"POST /auth/login": {"requests": 5, "window": "1m"}}
+	// they count per ip and route, the global limit applies as well
+	Routes map[string]RateLimitRule `koanf:"routes" validate:"dive"`
+	// request paths never limited, e.g. probes of the orchestrator
+	Exclude []string `koanf:"exclude"`
+}
+
+type RateLimitRule struct {
+	Requests int           `koanf:"requests" validate:"gt=0"`
+	Window   time.Duration `koanf:"window" validate:"gt=0"`
+}
+
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Backend:  "memory",
+		Requests: 100,
+		Window:   time.Minute,
+		Exclude:  []string{"/healthz", "/readyz", "/livez", "/metrics"},
+	}
+}
+
+func DefaultBodyCaptureConfig() BodyCaptureConfig {
+	return BodyCaptureConfig{
+		Header:       "X-Debug-Capture",
+		MaxBytes:     4 << 10,
+		ContentTypes: []string{"application/json", "application/problem+json", "application/x-www-form-urlencoded", "text/*"},
+	}
+}
+
+func DefaultMaintenanceConfig() MaintenanceConfig {
+	return MaintenanceConfig{
+		Key:          "maintenance",
+		PollInterval: 5 * time.Second,
+		RetryAfter:   5 * time.Minute,
+		Exclude:      []string{"/livez", "/readyz", "/healthz", "/metrics", "/admin/"},
+	}
+}
+
+func DefaultWebSocketConfig() WebSocketConfig {
+	return WebSocketConfig{
+		PingInterval:    30 * time.Second,
+		PongTimeout:     10 * time.Second,
+		WriteTimeout:    10 * time.Second,
+		MaxMessageBytes: 64 << 10,
+		SendQueue:       64,
+		Rate:            10,
+		Burst:           20,
+		Bridge:          true,
+	}
+}
+
+func DefaultIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		Enabled:      true,
+		TTL:          24 * time.Hour,
+		LockTTL:      time.Minute,
+		Methods:      []string{"POST", "PATCH"},
+		MaxBodyBytes: 1 << 20,
+	}
+}
+
+func DefaultStaticConfig() StaticConfig {
+	return StaticConfig{
+		SPA:               true,
+		ImmutablePrefixes: []string{"/assets/"},
+		CacheMaxAge:       365 * 24 * time.Hour,
+		Exclude:           []string{"/api/", "/admin/", "/debug/"},
+	}
+}
+
+func DefaultAccessLogConfig() AccessLogConfig {
+	return AccessLogConfig{
+		Enabled: true,
+		Level:   "info",
+		Exclude: []string{"/healthz", "/readyz", "/livez", "/metrics"},
+	}
+}
+
+// HTTP2Config: HTTP/2 of the main listener, negotiated with ALPN under TLS
+// without TLS only with h2c, for proxies which speak HTTP/2 to the backend (envoy, grpc aware load balancers)
+type HTTP2Config struct {
+	Enabled bool `koanf:"enabled"`
+	H2C     bool `koanf:"h2c"`
+	// streams a client may have open on one connection, 0 is the net/http default (250)
+	MaxConcurrentStreams int `koanf:"max_concurrent_streams" validate:"min=0"`
+	// a connection without frames for PingInterval is pinged, no answer within PingTimeout closes it
+	// read / write timeouts are per stream with HTTP/2, the pings find dead peers of idle connections
+	// PingInterval is the read idle timeout of the connection
+	PingInterval time.Duration `koanf:"ping_interval" validate:"min_duration=0s"`
+	PingTimeout  time.Duration `koanf:"ping_timeout" validate:"min_duration=0s"`
+	// an h2 connection without open streams is closed after it, server.idle_timeout when 0
+	// it carries every request of a client (or proxy), so it lives longer than an HTTP/1.1 keep-alive
+	IdleTimeout time.Duration `koanf:"idle_timeout" validate:"min_duration=0s"`
+	// write deadline of one h2 stream, 0 for none: server.write_timeout only holds for HTTP/1.1,
+	// on a multiplexed connection it would cut off long streams (SSE, downloads) of a healthy peer
+	WriteTimeout time.Duration `koanf:"write_timeout" validate:"min_duration=0s"`
+}
+
+// TLSConfig: lets the server terminate TLS itself when it is not behind a load balancer
+// either CertFile + KeyFile or Autocert has to be set when enabled
+type TLSConfig struct {
+	Enabled  bool           `koanf:"enabled"`
+	CertFile string         `koanf:"cert_file"`
+	KeyFile  string         `koanf:"key_file"`
+	Autocert AutocertConfig `koanf:"autocert"`
+}
+
+// AutocertConfig: fetches and renews certificates from an ACME CA (Let's Encrypt)
+type AutocertConfig struct {
+	Enabled  bool     `koanf:"enabled"`
+	Hosts    []string `koanf:"hosts" validate:"dive,hostname_rfc1123"`
+	CacheDir string   `koanf:"cache_dir"`
+	Email    string   `koanf:"email"`
+}
+
+func (c *TLSConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Autocert.Enabled {
+		if c.CertFile != "" || c.KeyFile != "" {
+			return fmt.Errorf("tls cert/key files and autocert can not be used together")
+		}
+		if len(c.Autocert.Hosts) == 0 {
+			return fmt.Errorf("autocert requires at least one host in the whitelist")
+		}
+		if c.Autocert.CacheDir == "" {
+			return fmt.Errorf("autocert requires a cache dir")
+		}
+		return nil
+	}
+
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("tls requires both cert file and key file")
+	}
+
+	return nil
+}
+
+// RedisConfig: standalone (address), sentinel (master_name + addresses of the sentinels)
+// or cluster (addresses of some of the nodes)
 type RedisConfig struct {
-	Address string `koanf:"address" validation:"required"`
+	Mode       string         `koanf:"mode" validate:"oneof=standalone sentinel cluster"`
+	Address    string         `koanf:"address" validate:"required_if=Mode standalone,omitempty,redis_addr"`
+	Addresses  []string       `koanf:"addresses" validate:"required_unless=Mode standalone,dive,hostname_port"`
+	MasterName string         `koanf:"master_name" validate:"required_if=Mode sentinel"`
+	TLS        RedisTLSConfig `koanf:"tls"`
+	// ACL user / password (AUTH), override the ones of a "redis://" address
+	Username string `koanf:"username"`
+	Password string `koanf:"password"`
+	// AUTH of the sentinels themselves, when they are protected separately
+	SentinelPassword string `koanf:"sentinel_password"`
+	// database index, cluster mode only has 0
+	DB int `koanf:"db" validate:"min=0"`
+	// connections per node, go-redis default (10 per CPU) when 0
+	PoolSize     int           `koanf:"pool_size" validate:"min=0"`
+	MinIdleConns int           `koanf:"min_idle_conns" validate:"min=0"`
+	PoolTimeout  time.Duration `koanf:"pool_timeout" validate:"min_duration=0s"`
+	// go-redis defaults (5s dial, 3s read / write) when 0
+	DialTimeout  time.Duration `koanf:"dial_timeout" validate:"min_duration=0s"`
+	ReadTimeout  time.Duration `koanf:"read_timeout" validate:"min_duration=0s"`
+	WriteTimeout time.Duration `koanf:"write_timeout" validate:"min_duration=0s"`
+	// retry of the first connection at startup
+	ConnectRetry ConnectRetryConfig `koanf:"connect_retry"`
+}
+
+// RedisTLSConfig: TLS to the redis nodes, managed redis offerings usually require it
+type RedisTLSConfig struct {
+	Enabled    bool   `koanf:"enabled"`
+	ServerName string `koanf:"server_name"` // when the certificate doesn't name the address, e.g. behind a load balancer
+	// PEM bundle of the CA which signed the redis certificates, the system roots when empty
+	CAFile string `koanf:"ca_file" validate:"omitempty,file"`
+	// no certificate verification at all, local development against self signed certificates only
+	InsecureSkipVerify bool `koanf:"insecure_skip_verify"`
+}
+
+// Validate: settings which only work together with others
+func (c *RedisConfig) Validate() error {
+	if c.Mode == "cluster" && c.DB != 0 {
+		return fmt.Errorf("redis cluster only supports db 0")
+	}
+	if c.MinIdleConns > 0 && c.PoolSize > 0 && c.MinIdleConns > c.PoolSize {
+		return fmt.Errorf("redis min_idle_conns can not be larger than pool_size")
+	}
+
+	tlsEnabled := c.TLS.Enabled || (c.Mode == "standalone" && strings.HasPrefix(c.Address, "rediss://"))
+	if !tlsEnabled && (c.TLS.CAFile != "" || c.TLS.InsecureSkipVerify || c.TLS.ServerName != "") {
+		return fmt.Errorf("redis tls settings require tls.enabled or a rediss:// address")
+	}
+	if c.TLS.InsecureSkipVerify && c.TLS.CAFile != "" {
+		return fmt.Errorf("redis tls ca_file has no effect with insecure_skip_verify")
+	}
+	return nil
 }
 
 type DatabaseConfig struct {
-	Host            string `koanf:"host" validation:"required"`
-	Port            int    `koanf:"port" validation:"required"`
-	User            string `koanf:"user" validation:"required"`
-	Password        string `koanf:"password" validation:"required"`
-	Name            string `koanf:"name" validation:"required"`
-	SSLMode         string `koanf:"ssl_mode" validation:"required"`
-	MaxOpenConns    int    `koanf:"max_open_conns" validation:"required"`
-	MaxIdleConns    int    `koanf:"max_idle_conns" validation:"required"`
-	ConnMaxLifetime int    `koanf:"conn_max_lifetime" validation:"required"`
-	ConnMaxIdletime int    `koanf:"conn_max_idletime" validation:"required"`
+	// postgres (default), mysql or sqlite, see internal/database/driver.go
+	Driver string `koanf:"driver" validate:"oneof=postgres mysql sqlite"`
+	// database file of the sqlite driver, ":memory:" for a throwaway database
+	Path            string `koanf:"path" validate:"required_if=Driver sqlite"`
+	Host            string `koanf:"host" validate:"required_unless=Driver sqlite,omitempty,resolvable_host"`
+	Port            int    `koanf:"port" validate:"required_unless=Driver sqlite,omitempty,port"`
+	User            string `koanf:"user" validate:"required_unless=Driver sqlite"`
+	Password        string `koanf:"password" validate:"required_unless=Driver sqlite"`
+	Name            string `koanf:"name" validate:"required_unless=Driver sqlite"`
+	SSLMode         string `koanf:"ssl_mode" validate:"required_unless=Driver sqlite,omitempty,oneof=disable allow prefer require verify-ca verify-full"`
+	MaxOpenConns    int    `koanf:"max_open_conns" validate:"required"`
+	MaxIdleConns    int    `koanf:"max_idle_conns" validate:"required,ltefield=MaxOpenConns"` // cap of idle connections, mysql / sqlite (pgxpool has none)
+	ConnMaxLifetime int    `koanf:"conn_max_lifetime" validate:"required"`                    // seconds
+	ConnMaxIdletime int    `koanf:"conn_max_idletime" validate:"required"`                    // seconds
+	// floor of the postgres pool: connections kept open even when idle, opened again when they expire
+	MinConns int `koanf:"min_conns" validate:"min=0,ltefield=MaxOpenConns"`
+	// seconds between checks of idle connections, pgx default (1 minute) when 0
+	HealthCheckPeriod int `koanf:"health_check_period" validate:"min=0"`
+	// default deadline of db.Query / db.Exec / db.QueryRow, no timeout when 0
+	QueryTimeout time.Duration `koanf:"query_timeout" validate:"min_duration=0s"`
+	// also runs SET LOCAL statement_timeout in WithTx, so postgres stops the statement itself
+	SetStatementTimeout bool `koanf:"set_statement_timeout"`
+	// shown in pg_stat_activity, defaults to the service name
+	ApplicationName string `koanf:"application_name"`
+	SearchPath      string `koanf:"search_path"`
+	ConnectTimeout  int    `koanf:"connect_timeout" validate:"min=0"` // seconds, no timeout when 0
+	// any other libpq / pgx connection parameter, e.g. {"statement_cache_capacity": "256"}
+	ExtraParams map[string]string `koanf:"extra_params"`
+	// optional, read replicas share user, password, name and ssl mode with the primary
+	Replicas ReplicaConfigs `koanf:"replicas" validate:"dive"`
+	// postgres channels database.Listener subscribes to (LISTEN/NOTIFY)
+	ListenChannels []string `koanf:"listen_channels"`
+	// how Reader() picks a replica: round_robin (default) or least_connections
+	ReplicaStrategy string `koanf:"replica_strategy" validate:"omitempty,oneof=round_robin least_connections"`
+	// migrations are read from this directory instead of the ones embedded in the binary
+	MigrationsDir string `koanf:"migrations_dir" validate:"omitempty,dir"`
+	// how long an instance waits for another one to finish migrating, 5 minutes when 0
+	MigrationLockTimeout time.Duration `koanf:"migration_lock_timeout" validate:"min_duration=0s"`
+	// don't wait: start without migrating while another instance holds the migration lock
+	MigrationLockSkip bool `koanf:"migration_lock_skip"`
+	// runs the seeders of primary.env after the migrations, see internal/database/seeds
+	SeedOnStartup bool `koanf:"seed_on_startup"`
+	// don't fail on applied migrations whose file changed since, e.g. while squashing migrations
+	SkipMigrationChecksums bool `koanf:"skip_migration_checksums"`
+	// PgBouncer compatibility (transaction pooling): simple query protocol, no prepared statement cache
+	// LISTEN/NOTIFY needs a session, point database.Listener at postgres directly in that setup
+	SimpleProtocol bool `koanf:"simple_protocol"`
+	// how long Drain waits for running queries at shutdown
+	DrainTimeout time.Duration `koanf:"drain_timeout" validate:"min_duration=0s"`
+	// retry of the first connection at startup
+	ConnectRetry ConnectRetryConfig `koanf:"connect_retry"`
+	// query tracers: newrelic (nrpgx5), otel (otelpgx), both; empty picks the ones whose backend is configured
+	Tracers []string `koanf:"tracers" validate:"dive,oneof=newrelic otel"`
+	// schema per tenant, see database.WithTenantTx
+	Tenancy TenancyConfig `koanf:"tenancy"`
+	// db backup / db restore commands
+	Backup BackupConfig `koanf:"backup"`
+	// purge of soft deleted rows, see repository.Purger
+	SoftDelete SoftDeleteConfig `koanf:"soft_delete"`
+}
+
+// SoftDeleteConfig: soft deleted rows of purge_tables are removed retention after their deletion
+type SoftDeleteConfig struct {
+	// tables with a deleted_at column, no purge job when empty
+	PurgeTables   []string      `koanf:"purge_tables"`
+	Retention     time.Duration `koanf:"retention" validate:"gt=0"`
+	PurgeInterval time.Duration `koanf:"purge_interval" validate:"gt=0"`
+	BatchSize     int           `koanf:"batch_size" validate:"gt=0"`
+}
+
+func DefaultSoftDeleteConfig() SoftDeleteConfig {
+	return SoftDeleteConfig{
+		Retention:     30 * 24 * time.Hour,
+		PurgeInterval: time.Hour,
+		BatchSize:     1000,
+	}
+}
+
+// BackupConfig: pg_dump archives of the database, see internal/database/backup
+type BackupConfig struct {
+	// directory of the backup files, the working directory when empty
+	Dir string `koanf:"dir"`
+	// pg_dump compression level, 0 writes the archive uncompressed
+	Compression int `koanf:"compression" validate:"min=0,max=9"`
+	// optional, backups are uploaded when a bucket is set
+	S3 BackupS3Config `koanf:"s3"`
+}
+
+// BackupS3Config: credentials come from the AWS default chain (env, shared config, instance role)
+type BackupS3Config struct {
+	Bucket string `koanf:"bucket"`
+	Prefix string `koanf:"prefix"` // key prefix, e.g. "backups/"
+	Region string `koanf:"region"`
+	// S3 compatible storage (MinIO, R2, ...), AWS when empty
+	Endpoint     string `koanf:"endpoint" validate:"omitempty,url"`
+	UsePathStyle bool   `koanf:"use_path_style"`
+}
+
+func DefaultBackupConfig() BackupConfig {
+	return BackupConfig{
+		Compression: 6,
+	}
+}
+
+// TenancyConfig: tenants live in <schema_prefix><tenant id> schemas
+type TenancyConfig struct {
+	Enabled      bool   `koanf:"enabled"`
+	SchemaPrefix string `koanf:"schema_prefix" validate:"omitempty,max=15"`
+	// request header carrying the tenant id, X-Tenant-ID by default
+	Header string `koanf:"header"`
+	// tenants whose schemas are created by MigrateTenants, existing prefixed schemas are migrated as well
+	Tenants []string `koanf:"tenants"`
+}
+
+// ConnectRetryConfig: exponential backoff between connection attempts, jitter is the randomized fraction of each wait
+type ConnectRetryConfig struct {
+	MaxAttempts    int           `koanf:"max_attempts" validate:"min=1"`
+	InitialBackoff time.Duration `koanf:"initial_backoff" validate:"min_duration=0s"`
+	MaxBackoff     time.Duration `koanf:"max_backoff" validate:"gtefield=InitialBackoff"`
+	Jitter         float64       `koanf:"jitter" validate:"min=0,max=1"`
+}
+
+func DefaultConnectRetryConfig() ConnectRetryConfig {
+	return ConnectRetryConfig{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     15 * time.Second,
+		Jitter:         0.2,
+	}
+}
+
+// ReplicaConfig: host and port of a single read replica
+type ReplicaConfig struct {
+	Host string `koanf:"host" validate:"required,resolvable_host"`
+	Port int    `koanf:"port" validate:"required,port"`
+}
+
+// ReplicaConfigs: list of read replicas
+// from a config file it is a list of {host, port} objects, from env it is "host1:5432,host2:5432"
+type ReplicaConfigs []ReplicaConfig
+
+// UnmarshalText: parses the comma separated "host:port" form used in env variables
+func (r *ReplicaConfigs) UnmarshalText(text []byte) error {
+	replicas := ReplicaConfigs{}
+
+	for _, entry := range strings.Split(string(text), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		host, portStr, err := net.SplitHostPort(entry)
+		if err != nil {
+			return fmt.Errorf("invalid replica address %q: %w", entry, err)
+		}
+
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return fmt.Errorf("invalid replica port %q: %w", entry, err)
+		}
+
+		replicas = append(replicas, ReplicaConfig{Host: host, Port: port})
+	}
+
+	*r = replicas
+	return nil
 }
 
 type AuthConfig struct {
-	SecretKey string `koanf:"secret_key" validation:"required"`
+	SecretKey string `koanf:"secret_key" validate:"required"`
+}
+
+// DefaultAuditConfig: audit trail is written to its own rotating file unless configured otherwise
+func DefaultAuditConfig() AuditConfig {
+	return AuditConfig{
+		Enabled: true,
+		Sinks:   []string{"file"},
+		File: LogFileConfig{
+			Enabled:    true,
+			Path:       "logs/audit.log",
+			MaxSizeMB:  100,
+			MaxAgeDays: 365,
+			MaxBackups: 0,
+			Compress:   true,
+		},
+	}
 }
 
 // LoadConfig loads the configuration from environment variables using koanf
-func LoadConfig() (mainConfig *Config, err error) {
+// the prefix is resolved when the provider loads, after LoadDotenv, APP_ENV_PREFIX may come from a .env file
+func LoadConfig() (*Config, error) {
+	return LoadConfigWith(ProviderFunc(func(k *koanf.Koanf) error {
+		return EnvProvider(EnvPrefix()).Load(k)
+	}))
+}
+
+// LoadConfigWith: loads the configuration from the given providers, in the given order
+// a key set by a later provider overrides the same key from an earlier one
+func LoadConfigWith(providers ...Provider) (mainConfig *Config, err error) {
 	logger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger()
 
-	// loading env variables using koanf
+	// dotenv files only fill env variables which are not set already
+	files, err := LoadDotenv()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("could not load dotenv files")
+	}
+	logger.Debug().Strs("files", files).Msg("loaded dotenv files")
+
 	k := koanf.New(".")
 
-	err = k.Load(env.Provider("BOILERPLATE_", ".", func(s string) string {
-		return strings.ToLower(strings.TrimPrefix(s, "BOILERPLATE_"))
-	}), nil)
-	// err != nil -> checks if error exists
-	if err != nil {
-		logger.Fatal().Err(err).Msg("could not load initial env variables")
+	for _, provider := range providers {
+		err = provider.Load(k)
+		// err != nil -> checks if error exists
+		if err != nil {
+			logger.Fatal().Err(err).Msg("could not load config provider")
+		}
 	}
 
-	mainConfig = &Config{}
+	// start from default observability config, env variables only override what they set
+	// in config struct we set Observability as pointer type so unmarshal fills the defaults in place
+	mainConfig = &Config{
+		Server: ServerConfig{
+			AccessLog:       DefaultAccessLogConfig(),
+			RateLimit:       DefaultRateLimitConfig(),
+			BodyLimit:       BodyLimitConfig{MaxBytes: 1 << 20},
+			ETag:            ETagConfig{Enabled: true, MaxBytes: 1 << 20},
+			Static:          DefaultStaticConfig(),
+			HTTP2:           HTTP2Config{Enabled: true, PingInterval: 30 * time.Second, PingTimeout: 15 * time.Second, IdleTimeout: 5 * time.Minute},
+			Idempotency:     DefaultIdempotencyConfig(),
+			SSE:             SSEConfig{Heartbeat: 15 * time.Second, QueueSize: 64, Retry: 3 * time.Second},
+			WebSocket:       DefaultWebSocketConfig(),
+			Maintenance:     DefaultMaintenanceConfig(),
+			BodyCapture:     DefaultBodyCaptureConfig(),
+			Negotiation:     NegotiationConfig{Formats: []string{"json"}},
+			ResponseCache:   ResponseCacheConfig{MaxBodyBytes: 1 << 20},
+			SecurityHeaders: SecurityHeadersConfig{HSTSMaxAge: 365 * 24 * time.Hour},
+			TrustedProxies:  TrustedProxyConfig{Headers: []string{"X-Forwarded-For", "X-Real-IP", "Forwarded"}},
+		},
+		Observability: DefaultObservabilityConfig(),
+		Audit:         DefaultAuditConfig(),
+		Outbox:        DefaultOutboxConfig(),
+		Cache:         DefaultCacheConfig(),
+		Lock:          DefaultLockConfig(),
+		Session:       DefaultSessionConfig(),
+		PubSub:        DefaultPubSubConfig(),
+		Streams:       DefaultStreamsConfig(),
+		Redis:         RedisConfig{ConnectRetry: DefaultConnectRetryConfig()},
+		Database: DatabaseConfig{
+			ConnectRetry: DefaultConnectRetryConfig(),
+			Backup:       DefaultBackupConfig(),
+			SoftDelete:   DefaultSoftDeleteConfig(),
+		},
+	}
 
-	err = k.Unmarshal("", mainConfig)
+	// "a,b,c" from env is split into []string fields (cors origins, audit sinks, ...)
+	err = k.UnmarshalWithConf("", mainConfig, koanf.UnmarshalConf{
+		DecoderConfig: &mapstructure.DecoderConfig{
+			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				mapstructure.StringToTimeDurationHookFunc(),
+				mapstructure.StringToSliceHookFunc(","),
+				mapstructure.TextUnmarshallerHookFunc(),
+			),
+			Result:           mainConfig,
+			WeaklyTypedInput: true,
+			TagName:          "koanf",
+		},
+	})
 	if err != nil {
 		logger.Fatal().Err(err).Msg("could not unmarshal mainconfig")
 	}
 
-	validate := validator.New()
+	// fill some of the fields
+	mainConfig.Observability.ServiceName = "go-boilerplate"
+	mainConfig.Observability.Environment = mainConfig.Primary.Env
 
-	err = validate.Struct(mainConfig)
-	if err != nil {
-		logger.Fatal().Err(err).Msg("could not validate the struct")
+	// exporter "newrelic" is from before observability.provider, it meant the provider New Relic
+	if mainConfig.Observability.Logging.Exporter == "newrelic" {
+		if mainConfig.Observability.IsDatadog() {
+			logger.Fatal().Msg("observability.logging.exporter newrelic needs observability.provider newrelic, use provider")
+		}
+		logger.Warn().Msg("observability.logging.exporter newrelic is deprecated, use provider")
+		mainConfig.Observability.Logging.Exporter = "provider"
 	}
 
-	// set default observability config if not provided
-	// in config struct we set Observability as pointer type to check whether it is nil or not
-	if mainConfig.Observability == nil {
-		mainConfig.Observability = DefaultObservabilityConfig()
+	if mainConfig.Server.ShutdownTimeout == 0 {
+		mainConfig.Server.ShutdownTimeout = 30 * time.Second
+	}
+	if mainConfig.Server.DrainTimeout == 0 {
+		mainConfig.Server.DrainTimeout = mainConfig.Server.ShutdownTimeout
 	}
 
-	// fill some of the fields
-	mainConfig.Observability.ServiceName = "go-boilerplate"
-	mainConfig.Observability.Environment = mainConfig.Primary.Env
+	if mainConfig.Server.SecurityHeaders.Profile == "" {
+		switch {
+		case mainConfig.Primary.Env != "production":
+			mainConfig.Server.SecurityHeaders.Profile = "off"
+		case mainConfig.Server.Static.Enabled:
+			mainConfig.Server.SecurityHeaders.Profile = "html"
+		default:
+			mainConfig.Server.SecurityHeaders.Profile = "api"
+		}
+	}
+
+	if mainConfig.Server.RequestTimeout.Default == 0 {
+		mainConfig.Server.RequestTimeout.Default = time.Duration(mainConfig.Server.WriteTimeout) * time.Second
+	}
+
+	if mainConfig.Redis.Mode == "" {
+		mainConfig.Redis.Mode = "standalone"
+	}
+
+	if mainConfig.Database.Driver == "" {
+		mainConfig.Database.Driver = "postgres"
+	}
+
+	if mainConfig.Database.ReplicaStrategy == "" {
+		mainConfig.Database.ReplicaStrategy = "round_robin"
+	}
+
+	if mainConfig.Database.DrainTimeout == 0 {
+		mainConfig.Database.DrainTimeout = 10 * time.Second
+	}
+
+	if mainConfig.Database.MigrationLockTimeout == 0 {
+		mainConfig.Database.MigrationLockTimeout = 5 * time.Minute
+	}
+
+	if mainConfig.Database.Tenancy.SchemaPrefix == "" {
+		mainConfig.Database.Tenancy.SchemaPrefix = "tenant_"
+	}
+	if mainConfig.Database.Tenancy.Header == "" {
+		mainConfig.Database.Tenancy.Header = "X-Tenant-ID"
+	}
+
+	if mainConfig.Database.ApplicationName == "" {
+		mainConfig.Database.ApplicationName = mainConfig.Observability.ServiceName
+	}
+
+	if mainConfig.Cache.Namespace == "" {
+		mainConfig.Cache.Namespace = mainConfig.Observability.ServiceName
+	}
+
+	validate, err := newValidator()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("could not register config validation rules")
+	}
+
+	err = validate.Struct(mainConfig)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("could not validate the struct")
+	}
 
 	// automatic pointer dereferencing for method calls
 	err = mainConfig.Observability.Validate()
@@ -103,5 +1074,35 @@ func LoadConfig() (mainConfig *Config, err error) {
 		logger.Fatal().Err(err).Msg("invalid observability config")
 	}
 
+	err = mainConfig.Server.Validate()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid server config")
+	}
+
+	err = mainConfig.Server.TLS.Validate()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid server tls config")
+	}
+
+	err = mainConfig.Redis.Validate()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid redis config")
+	}
+
+	err = mainConfig.Outbox.Validate()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid outbox config")
+	}
+
+	err = mainConfig.Crypto.Validate()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid crypto config")
+	}
+
+	err = mainConfig.Session.Validate()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid session config")
+	}
+
 	return
 }
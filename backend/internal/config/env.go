@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// @dev env prefix: every env variable read by the config starts with it ("BOILERPLATE_DATABASE.HOST")
+// @dev forks can change it without editing this file:
+// @dev   build time -> go build -ldflags "-X github.com/anuragShingare30/go-boilerplate/internal/config.DefaultEnvPrefix=MYAPP_"
+// @dev   run time   -> APP_ENV_PREFIX=MYAPP_
+
+// DefaultEnvPrefix: prefix used when APP_ENV_PREFIX is not set, var (not const) so ldflags can override it
+var DefaultEnvPrefix = "BOILERPLATE_"
+
+// defaultEnvironment: used to pick dotenv files when no environment is set anywhere
+const defaultEnvironment = "local"
+
+// EnvPrefix: returns the env prefix in use, APP_ENV_PREFIX wins over DefaultEnvPrefix
+func EnvPrefix() string {
+	if prefix := os.Getenv("APP_ENV_PREFIX"); prefix != "" {
+		return prefix
+	}
+	return DefaultEnvPrefix
+}
+
+// Environment: returns the environment name used to pick dotenv files
+// APP_ENV -> <prefix>PRIMARY.ENV from process env -> <prefix>PRIMARY.ENV from .env -> "local"
+func Environment() string {
+	if env := os.Getenv("APP_ENV"); env != "" {
+		return env
+	}
+
+	key := EnvPrefix() + "PRIMARY.ENV"
+	if env := os.Getenv(key); env != "" {
+		return env
+	}
+
+	if values, err := godotenv.Read(".env"); err == nil && values[key] != "" {
+		return values[key]
+	}
+
+	return defaultEnvironment
+}
+
+// DotenvFiles: dotenv files for the environment, highest priority first
+// .env.local is skipped in test so tests are not affected by a developer's local overrides
+func DotenvFiles(environment string) []string {
+	files := []string{".env." + environment + ".local"}
+	if environment != "test" {
+		files = append(files, ".env.local")
+	}
+	return append(files, ".env."+environment, ".env")
+}
+
+// LoadDotenv: loads the existing dotenv files of the current environment into the process env
+// godotenv never overrides a variable which is already set, so process env wins over every file
+// and a file listed earlier in DotenvFiles wins over a later one; returns the files which were loaded
+func LoadDotenv() ([]string, error) {
+	var loaded []string
+
+	for _, file := range DotenvFiles(Environment()) {
+		err := godotenv.Load(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return loaded, fmt.Errorf("loading %s: %w", file, err)
+		}
+		loaded = append(loaded, file)
+	}
+
+	return loaded, nil
+}
+
+// ReloadLogLevel: returns the logging level from env, "" when not set
+// dotenv files are read again (without touching the process env), so editing them and sending SIGHUP is enough
+// <prefix>LOGGING_LEVEL wins over <prefix>OBSERVABILITY.LOGGING.LEVEL
+func ReloadLogLevel() string {
+	prefix := EnvPrefix()
+	keys := []string{prefix + "LOGGING_LEVEL", prefix + "OBSERVABILITY.LOGGING.LEVEL"}
+
+	// missing files are fine, then only the process env is used
+	var fileValues []map[string]string
+	for _, file := range DotenvFiles(Environment()) {
+		if values, err := godotenv.Read(file); err == nil {
+			fileValues = append(fileValues, values)
+		}
+	}
+
+	for _, key := range keys {
+		for _, values := range fileValues {
+			if level := values[key]; level != "" {
+				return level
+			}
+		}
+		if level := os.Getenv(key); level != "" {
+			return level
+		}
+	}
+
+	return ""
+}
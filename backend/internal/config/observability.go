@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
 	"time"
 )
 
@@ -11,52 +13,222 @@ import (
 type ObservabilityConfig struct {
 	ServiceName  string             `koanf:"service_name" validate:"required"`
 	Environment  string             `koanf:"environment" validate:"required"`
+	Provider     string             `koanf:"provider" validate:"required,oneof=newrelic datadog"` // APM / log forwarding vendor
 	Logging      LoggingConfig      `koanf:"logging" validate:"required"`
 	NewRelic     NewRelicConfig     `koanf:"new_relic" validate:"required"`
+	Datadog      DatadogConfig      `koanf:"datadog"`
 	HealthChecks HealthChecksConfig `koanf:"health_checks" validate:"required"`
+	Tracing      TracingConfig      `koanf:"tracing"`
+}
+
+// TracingConfig: OpenTelemetry traces over OTLP/HTTP, off while otlp.endpoint is empty
+type TracingConfig struct {
+	OTLP        OTLPConfig `koanf:"otlp"`                                // endpoint e.g. http://localhost:4318/v1/traces
+	SampleRatio float64    `koanf:"sample_ratio" validate:"min=0,max=1"` // share of root traces kept, sampled parents are always followed
 }
 
 type LoggingConfig struct {
-	Level              string        `koanf:"level" validate:"required"`
-	Format             string        `koanf:"format" validate:"required"`
-	SlowQueryThreshold time.Duration `koanf:"slow_query_threshold"`
+	Level              string        `koanf:"level" validate:"required,oneof=debug info warn error"`
+	Format             string        `koanf:"format" validate:"required,oneof=json console logstash ecs"`
+	SlowQueryThreshold time.Duration `koanf:"slow_query_threshold" validate:"min_duration=0s"`
+	// where logs are shipped besides stdout: provider (New Relic or Datadog), otlp, both or none
+	// the old value newrelic is still read as provider, LoadConfigWith warns about it
+	Exporter string            `koanf:"exporter" validate:"required,oneof=provider otlp both none"`
+	OTLP     OTLPConfig        `koanf:"otlp"`
+	File     LogFileConfig     `koanf:"file"`
+	Sampling LogSamplingConfig `koanf:"sampling"`
+	Dedup    LogDedupConfig    `koanf:"dedup"`
+	// per module levels, e.g. {"database": "debug", "http": "warn"}, other modules use Level
+	Levels    map[string]string `koanf:"levels" validate:"dive,oneof=debug info warn error"`
+	Redaction RedactionConfig   `koanf:"redaction"`
+	Syslog    SyslogConfig      `koanf:"syslog"`
+	GELF      GELFConfig        `koanf:"gelf"`
+	Console   ConsoleConfig     `koanf:"console"`
+	// sinks every event is written to, e.g. [stdout, file, newrelic]; derived from the settings above when empty
+	Writers []string `koanf:"writers" validate:"dive,oneof=stdout console file syslog gelf otlp newrelic datadog"`
+}
+
+// ConsoleConfig: human-readable output of the console writer, used for local development
+type ConsoleConfig struct {
+	Caller     bool   `koanf:"caller"`   // file:line of the log call
+	NoColor    bool   `koanf:"no_color"` // the NO_COLOR env variable disables colors as well
+	TimeFormat string `koanf:"time_format"`
+	// order of the fixed parts, parts left out are not printed
+	PartsOrder []string `koanf:"parts_order" validate:"dive,oneof=time level caller message"`
+	// fields printed first in this order, the remaining ones follow sorted by name
+	FieldsOrder   []string `koanf:"fields_order"`
+	FieldsExclude []string `koanf:"fields_exclude"`
+}
+
+// GELFConfig: additional Graylog (GELF 1.1) output
+type GELFConfig struct {
+	Enabled  bool   `koanf:"enabled"`
+	Network  string `koanf:"network" validate:"omitempty,oneof=udp tcp"`
+	Address  string `koanf:"address"`  // host:port of the GELF input
+	Compress bool   `koanf:"compress"` // gzip UDP messages, TCP is never compressed
+}
+
+// SyslogConfig: additional RFC5424 syslog output, local or remote
+type SyslogConfig struct {
+	Enabled  bool   `koanf:"enabled"`
+	Network  string `koanf:"network" validate:"omitempty,oneof=udp tcp unix unixgram"`
+	Address  string `koanf:"address"`  // host:port, or socket path for unix networks
+	Facility string `koanf:"facility"` // kern, user, daemon, local0 ... local7
+	AppName  string `koanf:"app_name"` // defaults to the service name
+}
+
+// RedactionConfig: scrubs secrets and PII from log events before they reach stdout, files or New Relic
+type RedactionConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// field names are matched case-insensitively as substrings, "password" also hides "db_password"
+	Keys []string `koanf:"keys"`
+	// regular expressions replaced inside the message and every string value
+	Patterns    []string `koanf:"patterns"`
+	Replacement string   `koanf:"replacement"`
+}
+
+// LogSamplingConfig: drops part of the high volume events so a busy service doesn't overwhelm log forwarding
+// each level keeps 1 in N events, 0 or 1 keeps every event; error and above are never sampled
+type LogSamplingConfig struct {
+	Enabled bool   `koanf:"enabled"`
+	Trace   uint32 `koanf:"trace"`
+	Debug   uint32 `koanf:"debug"`
+	Info    uint32 `koanf:"info"`
+	Warn    uint32 `koanf:"warn"`
+	// optional, the first Burst events of a level in every Period are kept before 1 in N applies
+	Burst  uint32        `koanf:"burst"`
+	Period time.Duration `koanf:"period" validate:"min_duration=0s"`
+}
+
+// LogDedupConfig: a message logged more than Threshold times per Interval is collapsed into one "repeated N times" entry
+// protects ingestion quotas when a flapping dependency makes the service log the same error thousands of times
+type LogDedupConfig struct {
+	Enabled   bool          `koanf:"enabled"`
+	Threshold int           `koanf:"threshold" validate:"min=0"` // identical events passed through per interval
+	Interval  time.Duration `koanf:"interval" validate:"min_duration=0s"`
+}
+
+// LogFileConfig: rotating log file written next to stdout, for VMs / bare metal without a log shipper
+type LogFileConfig struct {
+	Enabled    bool   `koanf:"enabled"`
+	Path       string `koanf:"path"`
+	MaxSizeMB  int    `koanf:"max_size_mb" validate:"gte=0"`  // rotate after this size
+	MaxAgeDays int    `koanf:"max_age_days" validate:"gte=0"` // 0 keeps old files forever
+	MaxBackups int    `koanf:"max_backups" validate:"gte=0"`  // 0 keeps all old files
+	Compress   bool   `koanf:"compress"`                      // gzip rotated files
+}
+
+// OTLPConfig: OpenTelemetry collector which receives the logs over OTLP/HTTP
+// works with any collector backend (Grafana Loki, Elastic, Honeycomb, ...)
+type OTLPConfig struct {
+	Endpoint string            `koanf:"endpoint"` // full URL, e.g. http://localhost:4318/v1/logs
+	Insecure bool              `koanf:"insecure"`
+	Headers  map[string]string `koanf:"headers"` // e.g. auth headers required by hosted collectors
 }
 
 type NewRelicConfig struct {
-	LicenseKey                string `koanf:"license_key" validate:"required"`
+	LicenseKey                string `koanf:"license_key"` // empty license key disables New Relic
 	AppLogForwardingEnabled   bool   `koanf:"app_log_forwarding_enabled"`
 	DistributedTracingEnabled bool   `koanf:"distributed_tracing_enabled"`
 	DebugLogging              bool   `koanf:"debug_logging"`
+	// appends NR-LINKING metadata to the stdout JSON lines, for setups where an agent tails stdout instead
+	LocalDecoratingEnabled bool `koanf:"local_decorating_enabled"`
+}
+
+// DatadogConfig: used when provider is datadog, logs go to the HTTP intake of the site
+type DatadogConfig struct {
+	APIKey string   `koanf:"api_key"` // empty api key disables Datadog forwarding
+	Site   string   `koanf:"site"`    // datadoghq.com, datadoghq.eu, us3.datadoghq.com, ...
+	Source string   `koanf:"source"`
+	Tags   []string `koanf:"tags"` // e.g. team:platform, sent as ddtags
 }
 
 type HealthChecksConfig struct {
 	Enabled  bool          `koanf:"enabled"`
-	Interval time.Duration `koanf:"interval" validate:"min=1s"`
-	Timeout  time.Duration `koanf:"timeout" validate:"min=1s"`
+	Interval time.Duration `koanf:"interval" validate:"min_duration=1s,max_duration=1h"`
+	Timeout  time.Duration `koanf:"timeout" validate:"min_duration=1s,max_duration=1m"`
 	Checks   []string      `koanf:"checks"`
 }
 
-
-func DefaultObservabilityConfig() *ObservabilityConfig{
+func DefaultObservabilityConfig() *ObservabilityConfig {
 	return &ObservabilityConfig{
 		ServiceName: "boilerplate",
 		Environment: "development",
+		Provider:    "newrelic",
 		Logging: LoggingConfig{
-			Level: "info",
-			Format: "json",
+			Level:              "info",
+			Format:             "json",
 			SlowQueryThreshold: 100 * time.Millisecond,
+			Exporter:           "provider",
+			Redaction: RedactionConfig{
+				Enabled: true,
+				Keys:    []string{"password", "passwd", "secret", "token", "authorization", "api_key", "apikey", "cookie", "license_key"},
+				Patterns: []string{
+					`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`, // email addresses
+					`(?i)bearer\s+[a-z0-9\-._~+/]+=*`,                  // bearer tokens
+				},
+				Replacement: "[REDACTED]",
+			},
+			Syslog: SyslogConfig{
+				Enabled:  false,
+				Network:  "udp",
+				Address:  "localhost:514",
+				Facility: "local0",
+			},
+			GELF: GELFConfig{
+				Enabled:  false,
+				Network:  "udp",
+				Address:  "localhost:12201",
+				Compress: true,
+			},
+			Sampling: LogSamplingConfig{
+				Enabled: false,
+				Trace:   100,
+				Debug:   10,
+				Info:    1,
+				Warn:    1,
+			},
+			Console: ConsoleConfig{
+				Caller:     true,
+				NoColor:    false,
+				TimeFormat: "2006-01-02 15:04:05",
+				PartsOrder: []string{"time", "level", "caller", "message"},
+				// same on every line of a local process, only noise in the terminal
+				FieldsExclude: []string{"service", "environment", "version", "commit", "host", "instance_id"},
+			},
+			Dedup: LogDedupConfig{
+				Enabled:   false,
+				Threshold: 10,
+				Interval:  time.Second,
+			},
+			File: LogFileConfig{
+				Enabled:    false,
+				Path:       "logs/app.log",
+				MaxSizeMB:  100,
+				MaxAgeDays: 28,
+				MaxBackups: 5,
+				Compress:   true,
+			},
 		},
 		NewRelic: NewRelicConfig{
-			LicenseKey: "",
-			AppLogForwardingEnabled: true,
+			LicenseKey:                "",
+			AppLogForwardingEnabled:   true,
 			DistributedTracingEnabled: true,
-			DebugLogging: false,  // Disabled by default to avoid mixed log formats
+			DebugLogging:              false, // Disabled by default to avoid mixed log formats
+		},
+		Datadog: DatadogConfig{
+			APIKey: "",
+			Site:   "datadoghq.com",
+			Source: "go",
 		},
 		HealthChecks: HealthChecksConfig{
-			Enabled: true,
-			Interval: 100 * time.Millisecond,
-			Timeout: 100 * time.Millisecond,
-			Checks: []string{"db", "redis"},
+			Enabled:  true,
+			Interval: 30 * time.Second,
+			Timeout:  5 * time.Second,
+			Checks:   []string{"db", "redis"},
+		},
+		Tracing: TracingConfig{
+			SampleRatio: 1,
 		},
 	}
 }
@@ -70,17 +242,55 @@ func (c *ObservabilityConfig) Validate() error {
 		return fmt.Errorf("SlowQueryThreshold should non-negative")
 	}
 
+	for _, pattern := range c.Logging.Redaction.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid log redaction pattern %q: %w", pattern, err)
+		}
+	}
+
+	if c.Logging.Sampling.Burst > 0 && c.Logging.Sampling.Period <= 0 {
+		return fmt.Errorf("log sampling period is required when burst is set")
+	}
+
+	if c.Logging.Dedup.Enabled && c.Logging.Dedup.Interval <= 0 {
+		return fmt.Errorf("log dedup interval is required when dedup is enabled")
+	}
+
+	if c.HasLogWriter("syslog") && c.Logging.Syslog.Address == "" {
+		return fmt.Errorf("syslog address is required when syslog output is enabled")
+	}
+
+	if c.HasLogWriter("gelf") && c.Logging.GELF.Address == "" {
+		return fmt.Errorf("gelf address is required when gelf output is enabled")
+	}
+
+	if c.HasLogWriter("file") && c.Logging.File.Path == "" {
+		return fmt.Errorf("log file path is required when file logging is enabled")
+	}
+
+	if c.HasLogWriter("otlp") && c.Logging.OTLP.Endpoint == "" {
+		return fmt.Errorf("otlp endpoint is required when logs are exported over otlp")
+	}
+
+	if c.HasLogWriter("newrelic") && c.IsDatadog() {
+		return fmt.Errorf("log writer newrelic needs provider newrelic")
+	}
+
+	if c.HasLogWriter("datadog") && !c.IsDatadog() {
+		return fmt.Errorf("log writer datadog needs provider datadog")
+	}
+
 	return nil
 }
 
 func (c *ObservabilityConfig) GetLogLevel() string {
 	switch c.Environment {
 	case "production":
-		if c.Logging.Level == ""{
+		if c.Logging.Level == "" {
 			return "info"
 		}
 	case "development":
-		if c.Logging.Level == ""{
+		if c.Logging.Level == "" {
 			return "info"
 		}
 	}
@@ -88,10 +298,77 @@ func (c *ObservabilityConfig) GetLogLevel() string {
 	return c.Logging.Level
 }
 
-
 func (c *ObservabilityConfig) IsProduction() bool {
-	if c.Environment == "production"{
+	if c.Environment == "production" {
 		return true
 	}
 	return false
-}
\ No newline at end of file
+}
+
+// ProviderLogsEnabled: logs are forwarded to the provider, New Relic or Datadog (needs its key as well)
+func (c *ObservabilityConfig) ProviderLogsEnabled() bool {
+	return c.Logging.Exporter == "provider" || c.Logging.Exporter == "both"
+}
+
+// IsDatadog: Datadog is the APM / log forwarding provider instead of New Relic
+func (c *ObservabilityConfig) IsDatadog() bool {
+	return c.Provider == "datadog"
+}
+
+// OTLPLogsEnabled: logs are exported to an OpenTelemetry collector
+func (c *ObservabilityConfig) OTLPLogsEnabled() bool {
+	return c.Logging.Exporter == "otlp" || c.Logging.Exporter == "both"
+}
+
+// OTLPTracingEnabled: traces are exported to an OpenTelemetry collector
+func (c *ObservabilityConfig) OTLPTracingEnabled() bool {
+	return c.Tracing.OTLP.Endpoint != ""
+}
+
+// LogWriters: sinks every log event is written to
+// without an explicit logging.writers list it is derived from format, exporter and the enabled flag of each output
+func (c *ObservabilityConfig) LogWriters() []string {
+	if len(c.Logging.Writers) > 0 {
+		return c.Logging.Writers
+	}
+
+	var writers []string
+	switch {
+	case c.Logging.Format == "logstash" || c.Logging.Format == "ecs":
+		// explicit field layout, used in every environment
+		writers = append(writers, "stdout")
+	case c.IsProduction() && c.Logging.Format == "json":
+		writers = append(writers, "stdout")
+	default:
+		writers = append(writers, "console")
+	}
+
+	if c.Logging.File.Enabled {
+		writers = append(writers, "file")
+	}
+	if c.Logging.Syslog.Enabled {
+		writers = append(writers, "syslog")
+	}
+	if c.Logging.GELF.Enabled {
+		writers = append(writers, "gelf")
+	}
+	if c.OTLPLogsEnabled() {
+		writers = append(writers, "otlp")
+	}
+
+	// provider forwarding only in production, local logs stay local
+	if c.IsProduction() && c.ProviderLogsEnabled() {
+		if c.IsDatadog() {
+			writers = append(writers, "datadog")
+		} else {
+			writers = append(writers, "newrelic")
+		}
+	}
+
+	return writers
+}
+
+// HasLogWriter: name is one of LogWriters
+func (c *ObservabilityConfig) HasLogWriter(name string) bool {
+	return slices.Contains(c.LogWriters(), name)
+}
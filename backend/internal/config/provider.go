@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/env"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+)
+
+// @dev config providers: every source of config values (env, files, S3, HTTP endpoint, embedded defaults)
+// @dev is a Provider, LoadConfigWith loads them in order into one koanf instance, later ones win
+
+// Provider: loads config values into the koanf instance
+type Provider interface {
+	Load(k *koanf.Koanf) error
+}
+
+// ProviderFunc: lets a plain function be used as a Provider
+type ProviderFunc func(k *koanf.Koanf) error
+
+// Load implements Provider
+func (f ProviderFunc) Load(k *koanf.Koanf) error {
+	return f(k)
+}
+
+// EnvProvider: loads env variables starting with prefix, "PREFIX_DATABASE.HOST" -> "database.host"
+func EnvProvider(prefix string) Provider {
+	return ProviderFunc(func(k *koanf.Koanf) error {
+		err := k.Load(env.Provider(prefix, ".", func(s string) string {
+			return strings.ToLower(strings.TrimPrefix(s, prefix))
+		}), nil)
+		if err != nil {
+			return fmt.Errorf("loading env variables: %w", err)
+		}
+		return nil
+	})
+}
+
+// FileProvider: loads a .yaml/.yml or .json config file, keys use the same names as the koanf tags
+func FileProvider(path string) Provider {
+	return ProviderFunc(func(k *koanf.Koanf) error {
+		var parser koanf.Parser
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			parser = yaml.Parser()
+		case ".json":
+			parser = json.Parser()
+		default:
+			return fmt.Errorf("unsupported config file type %q", path)
+		}
+
+		if err := k.Load(file.Provider(path), parser); err != nil {
+			return fmt.Errorf("loading config file %s: %w", path, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// @dev custom validation rules used in the `validate` tags of the config structs
+// @dev go-playground/validator only knows generic rules, these are specific to our config
+
+// hostLookupTimeout: max time spent resolving a hostname while validating config
+const hostLookupTimeout = 3 * time.Second
+
+// newValidator: returns a validator with all custom config rules registered
+func newValidator() (*validator.Validate, error) {
+	validate := validator.New()
+
+	rules := map[string]validator.Func{
+		"port":            validatePort,
+		"resolvable_host": validateResolvableHost,
+		"redis_addr":      validateRedisAddr,
+		"min_duration":    validateMinDuration,
+		"max_duration":    validateMaxDuration,
+	}
+
+	for tag, fn := range rules {
+		if err := validate.RegisterValidation(tag, fn); err != nil {
+			return nil, fmt.Errorf("registering %q validation: %w", tag, err)
+		}
+	}
+
+	return validate, nil
+}
+
+// validatePort: accepts int or string fields holding a port between 1 and 65535
+func validatePort(fl validator.FieldLevel) bool {
+	field := fl.Field()
+
+	var port int64
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		port = field.Int()
+	case reflect.String:
+		parsed, err := strconv.ParseInt(field.String(), 10, 64)
+		if err != nil {
+			return false
+		}
+		port = parsed
+	default:
+		return false
+	}
+
+	return port >= 1 && port <= 65535
+}
+
+// validateResolvableHost: accepts an IP address or a hostname which resolves through DNS
+func validateResolvableHost(fl validator.FieldLevel) bool {
+	host := fl.Field().String()
+	if host == "" {
+		return false
+	}
+
+	if net.ParseIP(host) != nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hostLookupTimeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	return err == nil && len(addrs) > 0
+}
+
+// validateRedisAddr: accepts "host:port" or a "redis://" / "rediss://" URL
+func validateRedisAddr(fl validator.FieldLevel) bool {
+	addr := fl.Field().String()
+
+	if u, err := url.Parse(addr); err == nil && (u.Scheme == "redis" || u.Scheme == "rediss") {
+		return u.Host != ""
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+
+	p, err := strconv.Atoi(port)
+	return err == nil && p >= 1 && p <= 65535
+}
+
+// validateMinDuration: usage `validate:"min_duration=1s"`
+func validateMinDuration(fl validator.FieldLevel) bool {
+	value, bound, ok := durationAndParam(fl)
+	return ok && value >= bound
+}
+
+// validateMaxDuration: usage `validate:"max_duration=1m"`
+func validateMaxDuration(fl validator.FieldLevel) bool {
+	value, bound, ok := durationAndParam(fl)
+	return ok && value <= bound
+}
+
+// durationAndParam: reads the time.Duration field and parses the tag param as a duration
+func durationAndParam(fl validator.FieldLevel) (time.Duration, time.Duration, bool) {
+	if fl.Field().Kind() != reflect.Int64 {
+		return 0, 0, false
+	}
+
+	bound, err := time.ParseDuration(fl.Param())
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return time.Duration(fl.Field().Int()), bound, true
+}
@@ -0,0 +1,195 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+/**
+@dev field encryption: column values encrypted by the service, the database (and its backups) only sees ciphertext
+
+cipher, err := crypto.New(cfg.Crypto)                 → keys from crypto.keys
+cipher, err := crypto.NewWithKMS(ctx, cfg.Crypto)     → crypto.kms_keys decrypted with AWS KMS as well
+crypto.SetDefault(cipher)                             → used by EncryptedString / EncryptedBytes / EncryptedJSON (types.go)
+
+ciphertext = version (1) | algorithm (1) | key id length (1) | key id | nonce | sealed data
+    → the header is authenticated, changing the key id or algorithm fails the decryption
+    → values name their key, rotation = add a new key, point crypto.key_id at it, old values still decrypt
+    → a random nonce per value, the same plaintext never gives the same ciphertext (no lookups by value)
+*/
+
+const formatVersion byte = 1
+
+// algorithm ids in the ciphertext header
+const (
+	algorithmAESGCM            byte = 1
+	algorithmXChaCha20Poly1305 byte = 2
+)
+
+// KeySize: 32 bytes, AES-256 and XChaCha20 both
+const KeySize = 32
+
+var (
+	// ErrNoCipher: an encrypted type was used before SetDefault
+	ErrNoCipher = errors.New("crypto: no default cipher, call crypto.SetDefault")
+	// ErrDecrypt: wrong key, corrupted or tampered ciphertext
+	ErrDecrypt = errors.New("crypto: decryption failed")
+)
+
+// Cipher: AEAD encryption with the configured keys
+type Cipher struct {
+	keyID     string
+	algorithm byte
+	// aeads by algorithm and key id, every key works with both algorithms
+	aeads map[byte]map[string]cipher.AEAD
+}
+
+// New: cipher of the plain keys of cfg, crypto.kms_keys need NewWithKMS
+func New(cfg config.CryptoConfig) (*Cipher, error) {
+	if len(cfg.KMSKeys) > 0 {
+		return nil, errors.New("crypto: kms_keys are configured, use NewWithKMS")
+	}
+
+	keys, err := decodeKeys(cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+	return newCipher(cfg.Algorithm, cfg.KeyID, keys)
+}
+
+// decodeKeys: base64 keys of the config by id
+func decodeKeys(encoded map[string]string) (map[string][]byte, error) {
+	keys := make(map[string][]byte, len(encoded))
+	for id, value := range encoded {
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %q is not valid base64: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
+
+func newCipher(algorithm, keyID string, keys map[string][]byte) (*Cipher, error) {
+	if _, ok := keys[keyID]; !ok {
+		return nil, fmt.Errorf("crypto: key %q is not configured", keyID)
+	}
+	if len(keyID) > 255 {
+		return nil, fmt.Errorf("crypto: key id %q is longer than 255 bytes", keyID)
+	}
+
+	c := &Cipher{
+		keyID: keyID,
+		aeads: map[byte]map[string]cipher.AEAD{
+			algorithmAESGCM:            {},
+			algorithmXChaCha20Poly1305: {},
+		},
+	}
+	switch algorithm {
+	case "", "aes-gcm":
+		c.algorithm = algorithmAESGCM
+	case "xchacha20-poly1305":
+		c.algorithm = algorithmXChaCha20Poly1305
+	default:
+		return nil, fmt.Errorf("crypto: unknown algorithm %q", algorithm)
+	}
+
+	for id, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("crypto: key %q has %d bytes, want %d", id, len(key), KeySize)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %q: %w", id, err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %q: %w", id, err)
+		}
+		c.aeads[algorithmAESGCM][id] = gcm
+
+		xchacha, err := chacha20poly1305.NewX(key)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %q: %w", id, err)
+		}
+		c.aeads[algorithmXChaCha20Poly1305][id] = xchacha
+	}
+	return c, nil
+}
+
+// Encrypt: seals plaintext with the current key, associatedData (e.g. the row id) must be the same to decrypt
+func (c *Cipher) Encrypt(plaintext, associatedData []byte) ([]byte, error) {
+	aead := c.aeads[c.algorithm][c.keyID]
+
+	header := make([]byte, 0, 3+len(c.keyID))
+	header = append(header, formatVersion, c.algorithm, byte(len(c.keyID)))
+	header = append(header, c.keyID...)
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("crypto: failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(header)+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, additionalData(header, associatedData)), nil
+}
+
+// Decrypt: opens a value of Encrypt with the key and algorithm named in its header
+func (c *Cipher) Decrypt(ciphertext, associatedData []byte) ([]byte, error) {
+	if len(ciphertext) < 3 || ciphertext[0] != formatVersion {
+		return nil, fmt.Errorf("%w: unknown format", ErrDecrypt)
+	}
+	algorithm, idLength := ciphertext[1], int(ciphertext[2])
+	if len(ciphertext) < 3+idLength {
+		return nil, fmt.Errorf("%w: truncated header", ErrDecrypt)
+	}
+	header, rest := ciphertext[:3+idLength], ciphertext[3+idLength:]
+	keyID := string(header[3:])
+
+	aead, ok := c.aeads[algorithm][keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key %q or algorithm %d", ErrDecrypt, keyID, algorithm)
+	}
+	if len(rest) < aead.NonceSize()+aead.Overhead() {
+		return nil, fmt.Errorf("%w: truncated ciphertext", ErrDecrypt)
+	}
+
+	nonce, sealed := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, additionalData(header, associatedData))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// KeyID: key new values are encrypted with, e.g. to find values still encrypted with an old one
+func (c *Cipher) KeyID() string {
+	return c.keyID
+}
+
+func additionalData(header, associatedData []byte) []byte {
+	return append(append(make([]byte, 0, len(header)+len(associatedData)), header...), associatedData...)
+}
+
+// defaultCipher: used by the encrypted column types, nil until SetDefault is called
+var defaultCipher *Cipher
+
+// SetDefault: makes c the cipher of the encrypted column types, call it once at startup
+func SetDefault(c *Cipher) {
+	defaultCipher = c
+}
+
+// Default: the cipher set with SetDefault, nil without
+func Default() *Cipher {
+	return defaultCipher
+}
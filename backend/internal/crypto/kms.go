@@ -0,0 +1,60 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"maps"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// @dev KMS: envelope encryption, crypto.kms_keys hold data keys encrypted by a KMS key (aws kms generate-data-key)
+// @dev they are decrypted once at startup, the plain data keys only live in memory; credentials from the AWS default chain
+
+// NewWithKMS: cipher of crypto.keys and the KMS decrypted crypto.kms_keys
+func NewWithKMS(ctx context.Context, cfg config.CryptoConfig) (*Cipher, error) {
+	keys, err := decodeKeys(cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.KMSKeys) > 0 {
+		decrypted, err := decryptKMSKeys(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(keys, decrypted)
+	}
+
+	return newCipher(cfg.Algorithm, cfg.KeyID, keys)
+}
+
+func decryptKMSKeys(ctx context.Context, cfg config.CryptoConfig) (map[string][]byte, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.KMSRegion != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.KMSRegion))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: failed to load aws config: %w", err)
+	}
+	client := kms.NewFromConfig(awsCfg)
+
+	keys := make(map[string][]byte, len(cfg.KMSKeys))
+	for id, encoded := range cfg.KMSKeys {
+		blob, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: kms key %q is not valid base64: %w", id, err)
+		}
+		// the ciphertext blob names its KMS key, no key id needed for symmetric keys
+		out, err := client.Decrypt(ctx, &kms.DecryptInput{CiphertextBlob: blob})
+		if err != nil {
+			return nil, fmt.Errorf("crypto: failed to decrypt kms key %q: %w", id, err)
+		}
+		keys[id] = out.Plaintext
+	}
+	return keys, nil
+}
@@ -0,0 +1,116 @@
+package crypto
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+/**
+@dev encrypted column types: bytea columns, encrypted in Value (insert / update) and decrypted in Scan
+@dev pgx uses driver.Valuer / sql.Scanner, so they work with sqlc, the repository helpers and plain queries
+
+type User struct {
+    ID    uuid.UUID                   `db:"id"`
+    Email crypto.EncryptedString      `db:"email_encrypted"`
+    Notes crypto.EncryptedJSON[Notes] `db:"notes_encrypted"`
+}
+
+NULL scans into the zero value, the columns can't be searched or indexed by value (random nonce)
+no associated data: a value copied to another row still decrypts, use Cipher.Encrypt with the row id for that
+*/
+
+// EncryptedString: string stored encrypted
+type EncryptedString string
+
+// Value implements driver.Valuer
+func (s EncryptedString) Value() (driver.Value, error) {
+	return encryptValue([]byte(s))
+}
+
+// Scan implements sql.Scanner
+func (s *EncryptedString) Scan(src any) error {
+	plaintext, err := decryptValue(src)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// EncryptedBytes: bytes stored encrypted
+type EncryptedBytes []byte
+
+// Value implements driver.Valuer
+func (b EncryptedBytes) Value() (driver.Value, error) {
+	return encryptValue(b)
+}
+
+// Scan implements sql.Scanner
+func (b *EncryptedBytes) Scan(src any) error {
+	plaintext, err := decryptValue(src)
+	if err != nil {
+		return err
+	}
+	*b = plaintext
+	return nil
+}
+
+// EncryptedJSON: any JSON marshalable value stored encrypted
+type EncryptedJSON[T any] struct {
+	V T
+}
+
+// Value implements driver.Valuer
+func (j EncryptedJSON[T]) Value() (driver.Value, error) {
+	plaintext, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: failed to marshal value: %w", err)
+	}
+	return encryptValue(plaintext)
+}
+
+// Scan implements sql.Scanner
+func (j *EncryptedJSON[T]) Scan(src any) error {
+	plaintext, err := decryptValue(src)
+	if err != nil {
+		return err
+	}
+	var v T
+	if len(plaintext) > 0 {
+		if err := json.Unmarshal(plaintext, &v); err != nil {
+			return fmt.Errorf("crypto: failed to unmarshal value: %w", err)
+		}
+	}
+	j.V = v
+	return nil
+}
+
+func encryptValue(plaintext []byte) (driver.Value, error) {
+	c := Default()
+	if c == nil {
+		return nil, ErrNoCipher
+	}
+	return c.Encrypt(plaintext, nil)
+}
+
+// decryptValue: nil for NULL
+func decryptValue(src any) ([]byte, error) {
+	var ciphertext []byte
+	switch v := src.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		ciphertext = v
+	case string:
+		ciphertext = []byte(v)
+	default:
+		return nil, fmt.Errorf("crypto: cannot scan %T into an encrypted value", src)
+	}
+
+	c := Default()
+	if c == nil {
+		return nil, ErrNoCipher
+	}
+	return c.Decrypt(ciphertext, nil)
+}
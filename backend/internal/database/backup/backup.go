@@ -0,0 +1,275 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev backup / restore through pg_dump and pg_restore, for small deployments without managed backups
+
+backup.Backup(ctx, cfg, logger, backup.Options{...})
+    → pg_dump --format=custom of database.name into <database.backup.dir>/<name>-<timestamp>.dump
+    → database.backup.s3.bucket set and Upload: the file is uploaded to s3://bucket/<prefix><file name>
+backup.Restore(ctx, cfg, logger, backup.RestoreOptions{Source: "s3://bucket/key" or a path})
+    → s3 sources are downloaded to a temp file first
+    → pg_restore into database.name, -clean drops the existing objects first
+
+the password is handed over in a temporary pgpass file (0600), it never shows up in the process list or the environment
+the pg_dump / pg_restore binaries must be on PATH, their major version at least the one of the server
+*/
+
+// Options: of Backup
+type Options struct {
+	// file path of the archive, <dir>/<db name>-<timestamp>.dump when empty
+	Output string
+	// 0-9, 0 writes the archive uncompressed
+	Compression int
+	// upload the archive to database.backup.s3 after the dump
+	Upload bool
+}
+
+// RestoreOptions: of Restore
+type RestoreOptions struct {
+	// local path or s3://bucket/key
+	Source string
+	// drop the objects of the archive before recreating them
+	Clean bool
+	// parallel pg_restore jobs, 1 when 0
+	Jobs int
+}
+
+// Backup: see the flow above, returns where the archive ended up (path, or s3 URL when uploaded)
+func Backup(ctx context.Context, cfg *config.Config, logger *zerolog.Logger, opts Options) (string, error) {
+	if opts.Compression < 0 || opts.Compression > 9 {
+		return "", fmt.Errorf("compression must be 0-9, got %d", opts.Compression)
+	}
+	output := opts.Output
+	if output == "" {
+		name := fmt.Sprintf("%s-%s.dump", cfg.Database.Name, time.Now().UTC().Format("20060102T150405Z"))
+		output = filepath.Join(cfg.Database.Backup.Dir, name)
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	logger.Info().Str("database", cfg.Database.Name).Str("output", output).Msg("starting database backup")
+	started := time.Now()
+
+	stopProgress := every(progressInterval, func() {
+		logger.Info().Str("output", output).Int64("bytes", fileSize(output)).Msg("backup in progress")
+	})
+	err := runPgTool(ctx, logger, &cfg.Database, "pg_dump",
+		"--format=custom",
+		"--compress="+strconv.Itoa(opts.Compression),
+		"--verbose",
+		"--file="+output,
+	)
+	stopProgress()
+	if err != nil {
+		// a partial archive can't be restored, don't leave it around looking like a backup
+		_ = os.Remove(output)
+		return "", err
+	}
+
+	size := fileSize(output)
+	logger.Info().
+		Str("output", output).
+		Int64("bytes", size).
+		Dur("duration", time.Since(started)).
+		Msg("database backup written")
+
+	if !opts.Upload {
+		return output, nil
+	}
+	if cfg.Database.Backup.S3.Bucket == "" {
+		return output, errors.New("upload requested but database.backup.s3.bucket is not set")
+	}
+
+	location, err := upload(ctx, cfg.Database.Backup.S3, logger, output)
+	if err != nil {
+		return output, err
+	}
+	return location, nil
+}
+
+// Restore: see the flow above
+func Restore(ctx context.Context, cfg *config.Config, logger *zerolog.Logger, opts RestoreOptions) error {
+	if opts.Source == "" {
+		return errors.New("restore source is required")
+	}
+
+	path := opts.Source
+	if bucket, key, ok := parseS3URL(opts.Source); ok {
+		downloaded, err := download(ctx, cfg.Database.Backup.S3, logger, bucket, key)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(downloaded)
+		path = downloaded
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("backup not found: %w", err)
+	}
+
+	logger.Info().
+		Str("database", cfg.Database.Name).
+		Str("source", opts.Source).
+		Bool("clean", opts.Clean).
+		Msg("starting database restore")
+	started := time.Now()
+
+	args := []string{"--verbose", "--no-owner", "--jobs=" + strconv.Itoa(max(opts.Jobs, 1))}
+	if opts.Clean {
+		args = append(args, "--clean", "--if-exists")
+	}
+	if err := runPgTool(ctx, logger, &cfg.Database, "pg_restore", append(args, path)...); err != nil {
+		return err
+	}
+
+	logger.Info().Dur("duration", time.Since(started)).Msg("database restored")
+	return nil
+}
+
+// runPgTool: runs pg_dump / pg_restore against the configured database, its verbose output is logged line by line
+func runPgTool(ctx context.Context, logger *zerolog.Logger, cfg *config.DatabaseConfig, tool string, args ...string) error {
+	passFile, err := writePassFile(cfg)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(passFile)
+
+	connArgs := []string{
+		"--host=" + cfg.Host,
+		"--port=" + strconv.Itoa(cfg.Port),
+		"--username=" + cfg.User,
+		"--dbname=" + cfg.Name,
+		"--no-password", // fail instead of prompting when the pgpass file doesn't match
+	}
+	// connection flags first, the archive path of pg_restore stays the last argument
+	all := append(connArgs, args...)
+
+	cmd := exec.CommandContext(ctx, tool, all...)
+	cmd.Env = append(os.Environ(),
+		"PGPASSFILE="+passFile,
+		"PGSSLMODE="+cfg.SSLMode,
+	)
+	if cfg.ApplicationName != "" {
+		cmd.Env = append(cmd.Env, "PGAPPNAME="+cfg.ApplicationName)
+	}
+	if cfg.ConnectTimeout > 0 {
+		cmd.Env = append(cmd.Env, "PGCONNECT_TIMEOUT="+strconv.Itoa(cfg.ConnectTimeout))
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to read %s output: %w", tool, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", tool, err)
+	}
+
+	lastError := logToolOutput(logger, tool, stderr)
+
+	if err := cmd.Wait(); err != nil {
+		if lastError != "" {
+			return fmt.Errorf("%s failed: %w: %s", tool, err, lastError)
+		}
+		return fmt.Errorf("%s failed: %w", tool, err)
+	}
+	return nil
+}
+
+// logToolOutput: logs the --verbose lines, returns the last error line for the returned error
+func logToolOutput(logger *zerolog.Logger, tool string, output io.Reader) string {
+	var lastError string
+
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		line := strings.TrimPrefix(scanner.Text(), tool+": ")
+		switch {
+		case strings.HasPrefix(line, "error: "):
+			lastError = strings.TrimPrefix(line, "error: ")
+			logger.Error().Str("tool", tool).Msg(lastError)
+		case strings.HasPrefix(line, "warning: "):
+			logger.Warn().Str("tool", tool).Msg(strings.TrimPrefix(line, "warning: "))
+		default:
+			logger.Info().Str("tool", tool).Msg(line)
+		}
+	}
+	return lastError
+}
+
+// writePassFile: pgpass file with the password of cfg, only readable by the current user
+func writePassFile(cfg *config.DatabaseConfig) (string, error) {
+	file, err := os.CreateTemp("", "pgpass-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create pgpass file: %w", err)
+	}
+	// CreateTemp already uses 0600, libpq ignores the file when it is readable by others
+	line := strings.Join([]string{
+		escapePass(cfg.Host),
+		strconv.Itoa(cfg.Port),
+		escapePass(cfg.Name),
+		escapePass(cfg.User),
+		escapePass(cfg.Password),
+	}, ":") + "\n"
+
+	if _, err := file.WriteString(line); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write pgpass file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write pgpass file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// escapePass: ":" and "\" are escaped with a backslash in pgpass fields
+func escapePass(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `:`, `\:`).Replace(value)
+}
+
+// every: calls fn every interval in the background until the returned func is called
+func every(interval time.Duration, fn func()) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fn()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// progressInterval: how often a running dump / upload logs its size
+const progressInterval = 5 * time.Second
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
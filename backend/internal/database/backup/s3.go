@@ -0,0 +1,143 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/rs/zerolog"
+)
+
+// @dev s3: single PutObject / GetObject, archives of small deployments stay well below the 5GB object limit of PutObject
+
+func newS3Client(ctx context.Context, cfg config.BackupS3Config) (*s3.Client, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws config: %w", err)
+	}
+
+	return s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+	}), nil
+}
+
+// upload: file to s3://bucket/<prefix><file name>, returns the s3 URL
+func upload(ctx context.Context, cfg config.BackupS3Config, logger *zerolog.Logger, file string) (string, error) {
+	client, err := newS3Client(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer f.Close()
+
+	key := cfg.Prefix + filepath.Base(file)
+	location := "s3://" + path.Join(cfg.Bucket, key)
+	size := fileSize(file)
+	logger.Info().Str("location", location).Int64("bytes", size).Msg("uploading database backup")
+
+	body := &progressFile{File: f}
+	stop := every(progressInterval, func() {
+		logger.Info().Int64("bytes", body.read.Load()).Int64("total", size).Msg("upload in progress")
+	})
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(cfg.Bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+	})
+	stop()
+	if err != nil {
+		return "", fmt.Errorf("failed to upload backup to %s: %w", location, err)
+	}
+
+	logger.Info().Str("location", location).Msg("database backup uploaded")
+	return location, nil
+}
+
+// download: s3 object into a temp file, the caller removes it
+func download(ctx context.Context, cfg config.BackupS3Config, logger *zerolog.Logger, bucket, key string) (string, error) {
+	client, err := newS3Client(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	location := "s3://" + path.Join(bucket, key)
+	logger.Info().Str("location", location).Msg("downloading database backup")
+
+	object, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to download backup %s: %w", location, err)
+	}
+	defer object.Body.Close()
+
+	f, err := os.CreateTemp("", "restore-*.dump")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	written, err := io.Copy(f, object.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to download backup %s: %w", location, err)
+	}
+
+	logger.Info().Str("location", location).Int64("bytes", written).Msg("database backup downloaded")
+	return f.Name(), nil
+}
+
+// parseS3URL: bucket and key of s3://bucket/key
+func parseS3URL(source string) (bucket, key string, ok bool) {
+	rest, found := strings.CutPrefix(source, "s3://")
+	if !found {
+		return "", "", false
+	}
+	bucket, key, _ = strings.Cut(rest, "/")
+	return bucket, key, bucket != "" && key != ""
+}
+
+// progressFile: counts the bytes read, stays seekable so the SDK can sign and retry the upload
+type progressFile struct {
+	*os.File
+	read atomic.Int64
+}
+
+func (f *progressFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	f.read.Add(int64(n))
+	return n, err
+}
+
+func (f *progressFile) Seek(offset int64, whence int) (int64, error) {
+	position, err := f.File.Seek(offset, whence)
+	if err == nil {
+		f.read.Store(position)
+	}
+	return position, err
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+/**
+@dev bulk insert: COPY instead of INSERT, tens of thousands of rows in a fraction of the time
+
+db.BulkInsert(ctx, "users", []string{"email", "name"}, rows)
+    → one transaction, the import is all or nothing
+        → rows are copied in chunks of bulkInsertChunkSize, each chunk a datastore segment "COPY <table>"
+        → progress logged per chunk at DEBUG, the summary at INFO
+*/
+
+const bulkInsertChunkSize = 5000
+
+// BulkInsert: copies rows (values in the order of columns) into table, returns the number of rows copied
+func (db *Database) BulkInsert(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	started := time.Now()
+	identifier := pgx.Identifier(strings.Split(table, "."))
+	txn := newrelic.FromContext(ctx)
+
+	var copied int64
+	err := db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		// the whole fn runs again when WithTx retries
+		copied = 0
+
+		for start := 0; start < len(rows); start += bulkInsertChunkSize {
+			chunk := rows[start:min(start+bulkInsertChunkSize, len(rows))]
+
+			segment := newrelic.DatastoreSegment{
+				StartTime:  txn.StartSegmentNow(),
+				Product:    newrelic.DatastorePostgres,
+				Collection: table,
+				Operation:  "COPY",
+			}
+			n, err := tx.CopyFrom(ctx, identifier, columns, pgx.CopyFromRows(chunk))
+			segment.End()
+			if err != nil {
+				return fmt.Errorf("failed to copy rows %d-%d into %s: %w", start, start+len(chunk), table, err)
+			}
+
+			copied += n
+			db.log.Debug().
+				Str("table", table).
+				Int64("copied", copied).
+				Int("total", len(rows)).
+				Msg("bulk insert progress")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	db.log.Info().
+		Str("table", table).
+		Int64("rows", copied).
+		Dur("duration", time.Since(started)).
+		Msg("bulk insert finished")
+	return copied, nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+/**
+@dev pool drain: shutdown order is http server → Drain → Close, so requests still running get to finish their queries
+
+db.Drain(ctx)
+    → new Exec / Query / QueryRow / WithTx calls fail with ErrDraining (db.Pool used directly is not guarded)
+    → waits until pool.Stat().AcquiredConns() is 0, up to database.drain_timeout
+    → deadline hit: logs the queries which are still running, Close interrupts them
+*/
+
+const drainPollInterval = 50 * time.Millisecond
+
+// ErrDraining: the database is shutting down and takes no new work
+var ErrDraining = errors.New("database is draining")
+
+// inflightTracer: queries currently running on the primary, reported when the drain deadline is hit
+type inflightTracer struct {
+	nextID  atomic.Uint64
+	queries sync.Map // id → inflightQuery
+}
+
+type inflightQuery struct {
+	sql     string
+	started time.Time
+}
+
+type inflightKey struct{}
+
+// TraceQueryStart implements pgx tracer interface
+func (t *inflightTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	id := t.nextID.Add(1)
+	t.queries.Store(id, inflightQuery{sql: data.SQL, started: time.Now()})
+	return context.WithValue(ctx, inflightKey{}, id)
+}
+
+// TraceQueryEnd implements pgx tracer interface
+func (t *inflightTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	if id, ok := ctx.Value(inflightKey{}).(uint64); ok {
+		t.queries.Delete(id)
+	}
+}
+
+// withInflightTracer: adds the in-flight tracer to the tracers of the pool
+func withInflightTracer(tracer pgx.QueryTracer, inflight *inflightTracer) pgx.QueryTracer {
+	switch existing := tracer.(type) {
+	case nil:
+		return inflight
+	case *multiTracer:
+		existing.tracers = append(existing.tracers, inflight)
+		return existing
+	default:
+		return &multiTracer{tracers: []any{existing, inflight}}
+	}
+}
+
+// Drain: stops new work and waits for acquired connections to be released, see the flow above
+func (db *Database) Drain(ctx context.Context) error {
+	db.draining.Store(true)
+
+	ctx, cancel := context.WithTimeout(ctx, db.drainTimeout)
+	defer cancel()
+
+	started := time.Now()
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		acquired := db.Pool.Stat().AcquiredConns()
+		if acquired == 0 {
+			db.log.Info().Dur("waited", time.Since(started)).Msg("database pool drained")
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			db.logInterrupted(acquired)
+			return ctx.Err()
+		}
+	}
+}
+
+// logInterrupted: what Close is about to cut off
+func (db *Database) logInterrupted(acquired int32) {
+	db.log.Warn().
+		Int32("acquired_conns", acquired).
+		Dur("drain_timeout", db.drainTimeout).
+		Msg("database drain deadline reached, closing with connections in use")
+
+	if db.inflight == nil {
+		return
+	}
+	db.inflight.queries.Range(func(_, value any) bool {
+		query := value.(inflightQuery)
+		sql := query.sql
+		if len(sql) > maxSlowQuerySQLLength {
+			sql = sql[:maxSlowQuerySQLLength] + "..."
+		}
+		db.log.Warn().
+			Str("sql", sql).
+			Dur("running", time.Since(query.started)).
+			Msg("interrupting query")
+		return true
+	})
+}
+
+// checkDraining: ErrDraining once Drain started
+func (db *Database) checkDraining() error {
+	if db.draining.Load() {
+		return ErrDraining
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev database drivers: postgres (pgx) is what the service is built on, mysql and sqlite are for projects not on postgres
+
+database.driver = postgres (default) | mysql | sqlite
+
+conn, err := database.Open(ctx, cfg, &log, loggerService)
+    → postgres: *Database, everything of this package (replicas, tenants, listener, outbox, audit, ...)
+    → mysql / sqlite: *SQLDatabase, database/sql pool with query hooks, migrations and health check
+database.Migrate(ctx, &log, cfg) migrates with the configured driver, so the migrate command works for all of them
+
+the server wiring in cmd/go-boilerplate needs postgres, projects on another driver replace it with their own
+*/
+
+// Driver: a database backend
+type Driver interface {
+	// Name: database.driver value selecting the driver
+	Name() string
+	// Connect: opens the pool, retried with database.connect_retry until the database answers
+	Connect(ctx context.Context, cfg *config.Config, logger *zerolog.Logger, loggerService *loggerConfig.LoggerService) (Conn, error)
+	// Migrate: applies every pending migration
+	Migrate(ctx context.Context, cfg *config.Config, logger *zerolog.Logger) error
+}
+
+// Conn: an open database of any driver
+type Conn interface {
+	HealthCheck(ctx context.Context) (Health, error)
+	Close() error
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]Driver)
+)
+
+func init() {
+	RegisterDriver(postgresDriver{})
+	RegisterDriver(newSQLDriver(mysqlDialect))
+	RegisterDriver(newSQLDriver(sqliteDialect))
+}
+
+// RegisterDriver: makes driver selectable with database.driver, replaces a driver of the same name
+func RegisterDriver(driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[driver.Name()] = driver
+}
+
+// DriverFor: registered driver of name, postgres when name is empty
+func DriverFor(name string) (Driver, error) {
+	if name == "" {
+		name = postgresDriverName
+	}
+
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	driver, ok := drivers[name]
+	if !ok {
+		names := make([]string, 0, len(drivers))
+		for registered := range drivers {
+			names = append(names, registered)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown database driver %q, registered: %v", name, names)
+	}
+	return driver, nil
+}
+
+// Open: connects with the driver of database.driver
+func Open(ctx context.Context, cfg *config.Config, logger *zerolog.Logger, loggerService *loggerConfig.LoggerService) (Conn, error) {
+	driver, err := DriverFor(cfg.Database.Driver)
+	if err != nil {
+		return nil, err
+	}
+	return driver.Connect(ctx, cfg, logger, loggerService)
+}
+
+const postgresDriverName = "postgres"
+
+// postgresDriver: the pgx implementation, New / migrations of this package
+type postgresDriver struct{}
+
+func (postgresDriver) Name() string {
+	return postgresDriverName
+}
+
+func (postgresDriver) Connect(_ context.Context, cfg *config.Config, logger *zerolog.Logger, loggerService *loggerConfig.LoggerService) (Conn, error) {
+	return New(cfg, logger, loggerService)
+}
+
+func (postgresDriver) Migrate(ctx context.Context, cfg *config.Config, logger *zerolog.Logger) error {
+	return migratePostgres(ctx, logger, cfg)
+}
@@ -0,0 +1,12 @@
+-- example table, same shape as the users table of the postgres migrations (needs MySQL 8.0.13+ for the UUID default)
+CREATE TABLE IF NOT EXISTS users (
+    id         CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    email      VARCHAR(255) NOT NULL UNIQUE,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6)
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,12 @@
+-- example table, same shape as the users table of the postgres migrations
+CREATE TABLE IF NOT EXISTS users (
+    id         TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+    email      TEXT NOT NULL UNIQUE,
+    name       TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+/**
+@dev deep health check: more than the ping at startup, for the readiness endpoint and the health checks
+
+db.HealthCheck(ctx)
+    → primary: round trip of SELECT 1 on an acquired connection, server version from the connection
+    → pool utilization of the primary: acquired / max connections
+    → every replica: reachable?, replication lag = now() - last replayed transaction
+    → error only when the primary can't be queried, unhealthy replicas are reported in Replicas
+*/
+
+// replicationLagQuery: 0 on a server which is not in recovery (replica promoted, or configured wrong)
+// @dev the lag grows while the primary has no writes, nothing new to replay doesn't mean the replica is behind
+const replicationLagQuery = `
+SELECT CASE WHEN pg_is_in_recovery()
+    THEN COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)::float8
+    ELSE 0 END`
+
+// Health: result of HealthCheck
+type Health struct {
+	Latency       time.Duration // round trip of SELECT 1 on the primary
+	ServerVersion string
+	Pool          PoolHealth
+	Replicas      []ReplicaHealth // empty without read replicas
+}
+
+// PoolHealth: connection usage of a pool
+type PoolHealth struct {
+	AcquiredConns int32
+	IdleConns     int32
+	TotalConns    int32
+	MaxConns      int32
+	Utilization   float64 // acquired / max, 1 means requests wait for connections
+}
+
+// ReplicaHealth: state of one read replica
+type ReplicaHealth struct {
+	Host    string
+	Healthy bool
+	Lag     time.Duration
+	Pool    PoolHealth
+	Error   string // empty when healthy
+}
+
+// HealthCheck: see the flow above, ErrDraining once the database is shutting down
+func (db *Database) HealthCheck(ctx context.Context) (Health, error) {
+	if err := db.checkDraining(); err != nil {
+		return Health{}, err
+	}
+
+	health := Health{Pool: poolHealth(db.Pool)}
+
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return health, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	started := time.Now()
+	var one int
+	if err := conn.QueryRow(ctx, "SELECT 1").Scan(&one); err != nil {
+		return health, fmt.Errorf("failed to query primary: %w", err)
+	}
+	health.Latency = time.Since(started)
+	// reported by the server at connect, no extra round trip
+	health.ServerVersion = conn.Conn().PgConn().ParameterStatus("server_version")
+
+	if db.replicas != nil {
+		health.Replicas = db.replicas.health(ctx)
+	}
+
+	return health, nil
+}
+
+// health: lag and pool usage of every replica, each with its own timeout so one slow replica doesn't hide the others
+func (s *replicaSet) health(ctx context.Context) []ReplicaHealth {
+	replicas := make([]ReplicaHealth, 0, len(s.replicas))
+
+	for _, r := range s.replicas {
+		result := ReplicaHealth{Host: r.host, Pool: poolHealth(r.pool)}
+
+		checkCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		var lagSeconds float64
+		err := r.pool.QueryRow(checkCtx, replicationLagQuery).Scan(&lagSeconds)
+		cancel()
+
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Healthy = true
+			result.Lag = time.Duration(lagSeconds * float64(time.Second))
+		}
+		replicas = append(replicas, result)
+	}
+
+	return replicas
+}
+
+func poolHealth(pool *pgxpool.Pool) PoolHealth {
+	stat := pool.Stat()
+	return newPoolHealth(stat.AcquiredConns(), stat.IdleConns(), stat.TotalConns(), stat.MaxConns())
+}
+
+func newPoolHealth(acquired, idle, total, maxConns int32) PoolHealth {
+	health := PoolHealth{
+		AcquiredConns: acquired,
+		IdleConns:     idle,
+		TotalConns:    total,
+		MaxConns:      maxConns,
+	}
+	if maxConns > 0 {
+		health.Utilization = float64(acquired) / float64(maxConns)
+	}
+	return health
+}
@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev LISTEN/NOTIFY: lightweight pub/sub through postgres, e.g. cache invalidation between replicas of the service
+
+listener := database.NewListener(cfg, &log)
+listener.Handle("user_changed", func(ctx context.Context, n *pgconn.Notification) { ... })
+listener.Start(ctx)
+    → dedicated connection (not from the pool, LISTEN is bound to the session)
+        → LISTEN on database.listen_channels and every channel with a handler
+            → handlers of the channel are called one after the other with each notification
+    → broken connection: reconnect with backoff, notifications sent meanwhile are lost
+*/
+
+const (
+	listenerMinBackoff = time.Second
+	listenerMaxBackoff = 30 * time.Second
+)
+
+// NotificationHandler: called with every notification of the channel it is registered for
+// handlers run on the listener goroutine, slow work should be handed off
+type NotificationHandler func(ctx context.Context, notification *pgconn.Notification)
+
+// Listener: subscribes to postgres channels and dispatches notifications to handlers
+type Listener struct {
+	dsn string
+	log *zerolog.Logger
+
+	mu       sync.RWMutex
+	channels []string
+	handlers map[string][]NotificationHandler
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewListener: listener on database.listen_channels, more channels are added with Handle
+func NewListener(cfg *config.Config, logger *zerolog.Logger) *Listener {
+	l := &Listener{
+		dsn:      DSN(&cfg.Database),
+		log:      logger,
+		handlers: make(map[string][]NotificationHandler),
+	}
+	for _, channel := range cfg.Database.ListenChannels {
+		l.addChannel(channel)
+	}
+	return l
+}
+
+// Handle: registers handler for channel, must be called before Start
+func (l *Listener) Handle(channel string, handler NotificationHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.addChannelLocked(channel)
+	l.handlers[channel] = append(l.handlers[channel], handler)
+}
+
+func (l *Listener) addChannel(channel string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.addChannelLocked(channel)
+}
+
+func (l *Listener) addChannelLocked(channel string) {
+	for _, existing := range l.channels {
+		if existing == channel {
+			return
+		}
+	}
+	l.channels = append(l.channels, channel)
+}
+
+// Active: there is a channel to listen on, from database.listen_channels or a Handle call
+func (l *Listener) Active() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.channels) > 0
+}
+
+// Start: listens in the background until ctx is done or Close is called
+func (l *Listener) Start(ctx context.Context) {
+	ctx, l.cancel = context.WithCancel(ctx)
+	l.done = make(chan struct{})
+
+	go func() {
+		defer close(l.done)
+		l.run(ctx)
+	}()
+}
+
+// Close: stops listening and waits for the running handler to return
+func (l *Listener) Close() {
+	if l.cancel == nil {
+		return
+	}
+	l.cancel()
+	<-l.done
+}
+
+// run: connect → listen → dispatch, reconnects with exponential backoff
+func (l *Listener) run(ctx context.Context) {
+	backoff := listenerMinBackoff
+
+	for {
+		connected, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			// the connection worked for a while, start over with a short backoff
+			backoff = listenerMinBackoff
+		}
+
+		l.log.Warn().Err(err).Dur("retry_in", backoff).Msg("postgres listener disconnected")
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, listenerMaxBackoff)
+	}
+}
+
+// listen: one connection lifetime, connected reports whether LISTEN succeeded
+func (l *Listener) listen(ctx context.Context) (connected bool, err error) {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect listener: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	l.mu.RLock()
+	channels := append([]string(nil), l.channels...)
+	l.mu.RUnlock()
+
+	for _, channel := range channels {
+		// channel names are identifiers, they can't be bind parameters
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return false, fmt.Errorf("failed to listen on %s: %w", channel, err)
+		}
+	}
+	l.log.Info().Strs("channels", channels).Msg("postgres listener started")
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		l.dispatch(ctx, notification)
+	}
+}
+
+// dispatch: calls the handlers of the channel, a panicking handler doesn't stop the listener
+func (l *Listener) dispatch(ctx context.Context, notification *pgconn.Notification) {
+	l.mu.RLock()
+	handlers := l.handlers[notification.Channel]
+	l.mu.RUnlock()
+
+	if len(handlers) == 0 {
+		l.log.Debug().
+			Str("channel", notification.Channel).
+			Str("payload", notification.Payload).
+			Msg("postgres notification without handler")
+		return
+	}
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					l.log.Error().
+						Interface("panic", p).
+						Str("channel", notification.Channel).
+						Msg("postgres notification handler panicked")
+				}
+			}()
+			handler(ctx, notification)
+		}()
+	}
+}
+
+// Notify: sends payload on channel, listeners of every instance receive it once the transaction commits
+func (db *Database) Notify(ctx context.Context, channel, payload string) error {
+	if _, err := db.Exec(ctx, "SELECT pg_notify($1, $2)", channel, payload); err != nil {
+		return fmt.Errorf("failed to notify %s: %w", channel, err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// @dev pool metrics: pool exhaustion shows up as acquired = max and growing acquire duration / empty acquires
+// @dev Prometheus reads pool.Stat() on every scrape, New Relic gets the same values as custom metrics every poolMetricsInterval
+
+const poolMetricsInterval = 15 * time.Second
+
+var (
+	poolAcquiredConns = prometheus.NewDesc("db_pool_acquired_conns", "Connections currently in use.", []string{"pool"}, nil)
+	poolIdleConns     = prometheus.NewDesc("db_pool_idle_conns", "Idle connections in the pool.", []string{"pool"}, nil)
+	poolTotalConns    = prometheus.NewDesc("db_pool_total_conns", "Open connections, acquired + idle + being created.", []string{"pool"}, nil)
+	poolMaxConns      = prometheus.NewDesc("db_pool_max_conns", "Maximum size of the pool.", []string{"pool"}, nil)
+	poolAcquires      = prometheus.NewDesc("db_pool_acquires_total", "Successful connection acquires.", []string{"pool"}, nil)
+	poolAcquireTime   = prometheus.NewDesc("db_pool_acquire_duration_seconds_total", "Time spent waiting for connections.", []string{"pool"}, nil)
+	poolEmptyAcquires = prometheus.NewDesc("db_pool_empty_acquires_total", "Acquires which had to wait because no connection was idle.", []string{"pool"}, nil)
+	poolCanceled      = prometheus.NewDesc("db_pool_canceled_acquires_total", "Acquires canceled by their context.", []string{"pool"}, nil)
+)
+
+// poolStats: collector over the pools of every open Database, pools are labeled primary / replica host
+var poolStats = &poolCollector{pools: make(map[string]*pgxpool.Pool)}
+
+func init() {
+	prometheus.MustRegister(poolStats)
+}
+
+type poolCollector struct {
+	mu    sync.RWMutex
+	pools map[string]*pgxpool.Pool
+}
+
+func (c *poolCollector) add(name string, pool *pgxpool.Pool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pools[name] = pool
+}
+
+func (c *poolCollector) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pools, name)
+}
+
+// Describe implements prometheus.Collector
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		poolAcquiredConns, poolIdleConns, poolTotalConns, poolMaxConns,
+		poolAcquires, poolAcquireTime, poolEmptyAcquires, poolCanceled,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for name, pool := range c.pools {
+		stat := pool.Stat()
+		ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()), name)
+		ch <- prometheus.MustNewConstMetric(poolAcquireTime, prometheus.CounterValue, stat.AcquireDuration().Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()), name)
+		ch <- prometheus.MustNewConstMetric(poolCanceled, prometheus.CounterValue, float64(stat.CanceledAcquireCount()), name)
+	}
+}
+
+// recordNewRelic: pool stats as Custom/Database/Pool/<pool>/<metric>
+func (c *poolCollector) recordNewRelic(app *newrelic.Application) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for name, pool := range c.pools {
+		stat := pool.Stat()
+		prefix := "Custom/Database/Pool/" + name + "/"
+		app.RecordCustomMetric(prefix+"AcquiredConns", float64(stat.AcquiredConns()))
+		app.RecordCustomMetric(prefix+"IdleConns", float64(stat.IdleConns()))
+		app.RecordCustomMetric(prefix+"TotalConns", float64(stat.TotalConns()))
+		app.RecordCustomMetric(prefix+"MaxConns", float64(stat.MaxConns()))
+		app.RecordCustomMetric(prefix+"AcquireDurationMs", float64(stat.AcquireDuration().Milliseconds()))
+		app.RecordCustomMetric(prefix+"EmptyAcquireCount", float64(stat.EmptyAcquireCount()))
+		app.RecordCustomMetric(prefix+"CanceledAcquireCount", float64(stat.CanceledAcquireCount()))
+	}
+}
+
+// runNewRelicPoolMetrics: samples the pools until stop is closed
+func runNewRelicPoolMetrics(app *newrelic.Application, stop <-chan struct{}) {
+	ticker := time.NewTicker(poolMetricsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			poolStats.recordNewRelic(app)
+		case <-stop:
+			return
+		}
+	}
+}
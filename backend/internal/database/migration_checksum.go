@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5"
+	tern "github.com/jackc/tern/v2/migrate"
+)
+
+/**
+@dev migration checksums: an applied migration must not change, environments would silently diverge
+
+runMigrations (under the migration lock)
+    → verify: sha256 of every applied migration file == the one stored when it was applied, else fail startup
+    → migrate
+    → record: checksums of newly applied versions are stored, rolled back versions are removed
+
+versions applied before checksums existed are recorded on the next run (trust on first use)
+accepting an intentional change: DELETE FROM schema_migration_checksums WHERE version = N
+*/
+
+const migrationChecksumTable = "schema_migration_checksums"
+
+type migrationChecksum struct {
+	version  int32
+	name     string
+	checksum string
+}
+
+// fileChecksums: sha256 of every migration file, index = version - 1
+func fileChecksums(dir string) ([]migrationChecksum, error) {
+	subtree, err := migrationsFS(dir)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := tern.FindMigrations(subtree)
+	if err != nil {
+		return nil, fmt.Errorf("finding database migrations: %w", err)
+	}
+
+	checksums := make([]migrationChecksum, len(paths))
+	for i, path := range paths {
+		body, err := fs.ReadFile(subtree, path)
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", path, err)
+		}
+		sum := sha256.Sum256(body)
+		checksums[i] = migrationChecksum{version: int32(i + 1), name: path, checksum: hex.EncodeToString(sum[:])}
+	}
+	return checksums, nil
+}
+
+// verifyMigrationChecksums: fails when an applied migration file was modified after it was applied
+func verifyMigrationChecksums(ctx context.Context, conn *pgx.Conn, files []migrationChecksum, current int32) error {
+	if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+migrationChecksumTable+` (
+		version    INT4 PRIMARY KEY,
+		name       TEXT NOT NULL,
+		checksum   TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("creating migration checksum table: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, "SELECT version, name, checksum FROM "+migrationChecksumTable+" WHERE version <= $1 ORDER BY version", current)
+	if err != nil {
+		return fmt.Errorf("reading migration checksums: %w", err)
+	}
+	stored, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (migrationChecksum, error) {
+		var c migrationChecksum
+		err := row.Scan(&c.version, &c.name, &c.checksum)
+		return c, err
+	})
+	if err != nil {
+		return fmt.Errorf("reading migration checksums: %w", err)
+	}
+
+	for _, applied := range stored {
+		if int(applied.version) > len(files) {
+			// database ahead of the migration set, tern reports that with a clearer error
+			continue
+		}
+		file := files[applied.version-1]
+		if file.checksum != applied.checksum {
+			return fmt.Errorf(
+				"migration %d (%s) was modified after it was applied as %s: checksum %s, applied %s; revert the file and add a new migration instead",
+				applied.version, file.name, applied.name, file.checksum, applied.checksum,
+			)
+		}
+	}
+	return nil
+}
+
+// recordMigrationChecksums: stores the checksums of versions 1..current, removes the ones above current
+func recordMigrationChecksums(ctx context.Context, conn *pgx.Conn, files []migrationChecksum, current int32) error {
+	batch := &pgx.Batch{}
+	batch.Queue("DELETE FROM "+migrationChecksumTable+" WHERE version > $1", current)
+	for _, file := range files {
+		if file.version > current {
+			break
+		}
+		batch.Queue(
+			"INSERT INTO "+migrationChecksumTable+" (version, name, checksum) VALUES ($1, $2, $3) ON CONFLICT (version) DO NOTHING",
+			file.version, file.name, file.checksum,
+		)
+	}
+
+	if err := conn.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("recording migration checksums: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev migration lock: replicas booting at the same time would all run Migrate
+
+runMigrations → pg_try_advisory_lock(migrationLockID), polled every migrationLockPoll
+    → acquired: migrate, the lock is released with the connection
+    → held by another session: log who holds it (pid, application, client address) and
+        → database.migration_lock_skip: don't migrate, the holder applies the migrations
+        → otherwise keep waiting up to database.migration_lock_timeout, then fail startup
+
+tern takes its own lock inside MigrateTo, but it blocks without a timeout and without telling who holds it
+*/
+
+// migrationLockID: advisory lock key of the migrations, fits into 32 bits so it shows up as pg_locks.objid
+const migrationLockID int64 = 482910553
+
+const (
+	migrationLockPoll        = 2 * time.Second
+	migrationLockLogInterval = 30 * time.Second
+)
+
+// errMigrationLockHeld: another session is migrating and database.migration_lock_skip is set
+var errMigrationLockHeld = errors.New("migration lock held by another session")
+
+// lockHolder: session holding the migration lock
+type lockHolder struct {
+	pid             int32
+	applicationName string
+	clientAddr      string
+	since           time.Time
+}
+
+// acquireMigrationLock: waits for the migration lock, release with releaseMigrationLock
+func acquireMigrationLock(ctx context.Context, conn *pgx.Conn, logger *zerolog.Logger, timeout time.Duration, skip bool) error {
+	deadline := time.Now().Add(timeout)
+	var lastLog time.Time
+
+	for {
+		var acquired bool
+		if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockID).Scan(&acquired); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if acquired {
+			if !lastLog.IsZero() {
+				logger.Info().Msg("acquired migration lock")
+			}
+			return nil
+		}
+
+		if skip || time.Since(lastLog) >= migrationLockLogInterval {
+			event := logger.Info()
+			if skip {
+				event = logger.Warn()
+			}
+			logMigrationLockHolder(ctx, conn, event)
+			lastLog = time.Now()
+		}
+		if skip {
+			return errMigrationLockHeld
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for the migration lock", timeout)
+		}
+
+		select {
+		case <-time.After(migrationLockPoll):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// releaseMigrationLock: the lock is session level, release it before the connection is reused
+func releaseMigrationLock(ctx context.Context, conn *pgx.Conn) error {
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to release migration lock: %w", err)
+	}
+	return nil
+}
+
+// logMigrationLockHolder: who is migrating, best effort, reading pg_stat_activity of other roles may be restricted
+func logMigrationLockHolder(ctx context.Context, conn *pgx.Conn, event *zerolog.Event) {
+	holder, err := migrationLockHolder(ctx, conn)
+	if err != nil {
+		event.Err(err).Msg("migration lock held by another session, waiting")
+		return
+	}
+
+	event.
+		Int32("holder_pid", holder.pid).
+		Str("holder_application", holder.applicationName).
+		Str("holder_client_addr", holder.clientAddr).
+		Time("holder_since", holder.since).
+		Msg("migration lock held by another session, waiting")
+}
+
+func migrationLockHolder(ctx context.Context, conn *pgx.Conn) (lockHolder, error) {
+	var holder lockHolder
+	err := conn.QueryRow(ctx, `
+		SELECT a.pid, COALESCE(a.application_name, ''), COALESCE(host(a.client_addr), ''), COALESCE(a.xact_start, a.backend_start)
+		FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted AND l.classid = 0 AND l.objid = $1 AND l.objsubid = 1
+		LIMIT 1`, migrationLockID,
+	).Scan(&holder.pid, &holder.applicationName, &holder.clientAddr, &holder.since)
+	if err != nil {
+		return lockHolder{}, fmt.Errorf("failed to look up migration lock holder: %w", err)
+	}
+	return holder, nil
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// @dev new migrations: the next sequence number is taken from the files in dir, tern refuses gaps and duplicates
+// @dev the file has to live in internal/database/migrations to be picked up by the embed glob
+
+// MigrationsSourceDir: the embedded migrations, relative to backend/
+const MigrationsSourceDir = "internal/database/migrations"
+
+const migrationTemplate = `-- Write your migrate up statements here
+
+---- create above / drop below ----
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
+`
+
+var (
+	migrationNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]*$`)
+	migrationFilePattern = regexp.MustCompile(`^(\d+)_.+\.sql$`)
+)
+
+// versionColumnTemplate: version column of repository.UpdateWithVersion (optimistic locking)
+// existing rows start at version 1
+const versionColumnTemplate = `-- optimistic locking, see repository.UpdateWithVersion
+ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+---- create above / drop below ----
+
+ALTER TABLE %[1]s DROP COLUMN IF EXISTS version;
+`
+
+// softDeleteTemplate: deleted_at column of repository.SoftDeleteRepository, the index serves the purge job
+const softDeleteTemplate = `-- soft delete, see repository.SoftDeleteRepository
+ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- unique columns should only be unique among live rows, e.g.
+-- CREATE UNIQUE INDEX ... ON %[1]s (email) WHERE deleted_at IS NULL;
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS %[3]s;
+ALTER TABLE %[1]s DROP COLUMN IF EXISTS deleted_at;
+`
+
+// NewMigrationFile: creates dir/NNN_name.sql with the up/down separator, returns its path
+func NewMigrationFile(dir, name string) (string, error) {
+	return newMigrationFile(dir, name, migrationTemplate)
+}
+
+// NewVersionColumnMigration: creates dir/NNN_add_version_to_<table>.sql adding the version column to table
+func NewVersionColumnMigration(dir, table string) (string, error) {
+	if table == "" {
+		return "", fmt.Errorf("table name is required")
+	}
+	contents := fmt.Sprintf(versionColumnTemplate, pgx.Identifier(strings.Split(table, ".")).Sanitize())
+	return newMigrationFile(dir, "add_version_to_"+strings.ReplaceAll(table, ".", "_"), contents)
+}
+
+// NewSoftDeleteMigration: creates dir/NNN_add_soft_delete_to_<table>.sql adding deleted_at to table
+func NewSoftDeleteMigration(dir, table string) (string, error) {
+	if table == "" {
+		return "", fmt.Errorf("table name is required")
+	}
+	parts := strings.Split(table, ".")
+	index := "idx_" + parts[len(parts)-1] + "_deleted_at"
+	// CREATE INDEX takes no schema, the index lives in the schema of the table; DROP INDEX needs it
+	schemaIndex := append(append([]string(nil), parts[:len(parts)-1]...), index)
+
+	contents := fmt.Sprintf(softDeleteTemplate,
+		pgx.Identifier(parts).Sanitize(),
+		pgx.Identifier{index}.Sanitize(),
+		pgx.Identifier(schemaIndex).Sanitize(),
+	)
+	return newMigrationFile(dir, "add_soft_delete_to_"+strings.ReplaceAll(table, ".", "_"), contents)
+}
+
+func newMigrationFile(dir, name, contents string) (string, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, ".sql"))
+	name = strings.NewReplacer("-", "_", " ", "_").Replace(name)
+	if !migrationNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid migration name %q, use lowercase letters, digits and underscores", name)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("reading migrations directory: %w", err)
+	}
+
+	var last int
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		sequence, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		last = max(last, sequence)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%03d_%s.sql", last+1, name))
+	// O_EXCL: never overwrite, e.g. when two branches picked the same number
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("creating migration file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(contents); err != nil {
+		return "", fmt.Errorf("writing migration file: %w", err)
+	}
+	return path, nil
+}
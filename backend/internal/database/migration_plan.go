@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/jackc/pgx/v5"
+)
+
+// @dev dry run: the migrations MigrateTo would run with their SQL (templates evaluated), nothing is executed
+// @dev the migrator is built without a connection, so not even the version table is created
+
+// LatestVersion: PlanMigrations target of Migrate
+const LatestVersion int32 = -1
+
+// PlannedMigration: one step of a plan, Version is the schema version after the step
+type PlannedMigration struct {
+	Version   int32  `json:"version"`
+	Name      string `json:"name"`
+	Direction string `json:"direction"` // up / down
+	SQL       string `json:"sql"`
+}
+
+// PlanMigrations: steps from the current version to version (LatestVersion for every pending migration)
+func PlanMigrations(ctx context.Context, cfg *config.Config, version int32) ([]PlannedMigration, error) {
+	conn, err := pgx.Connect(ctx, DSN(&cfg.Database))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(ctx)
+
+	current, err := currentSchemaVersion(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	// nil conn: tern only loads and parses the migrations
+	m, err := newMigrator(ctx, nil, cfg.Database.MigrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := int32(len(m.Migrations))
+	if version == LatestVersion {
+		version = latest
+	}
+	if version < 0 || version > latest {
+		return nil, fmt.Errorf("migration version %d out of range, available 0 to %d", version, latest)
+	}
+	if current > latest {
+		return nil, fmt.Errorf("database is at version %d, only %d migrations available", current, latest)
+	}
+
+	plan := []PlannedMigration{}
+	for current < version {
+		migration := m.Migrations[current]
+		plan = append(plan, PlannedMigration{Version: migration.Sequence, Name: migration.Name, Direction: "up", SQL: migration.UpSQL})
+		current++
+	}
+	for current > version {
+		migration := m.Migrations[current-1]
+		if migration.DownSQL == "" && migration.DownFunc == nil {
+			return nil, fmt.Errorf("irreversible migration %d %s, it has no down section", migration.Sequence, migration.Name)
+		}
+		plan = append(plan, PlannedMigration{Version: migration.Sequence - 1, Name: migration.Name, Direction: "down", SQL: migration.DownSQL})
+		current--
+	}
+	return plan, nil
+}
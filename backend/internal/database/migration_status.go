@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	tern "github.com/jackc/tern/v2/migrate"
+)
+
+// @dev migration status: read only, no tern migrator (it takes an advisory lock and creates the version table)
+// @dev so it's cheap enough for the build info endpoint and doesn't wait behind a running migration
+
+// pgUndefinedTable: schema_version doesn't exist yet, nothing was migrated
+const pgUndefinedTable = "42P01"
+
+// MigrationInfo: one migration file
+type MigrationInfo struct {
+	Version int32  `json:"version"`
+	Name    string `json:"name"`
+}
+
+// SchemaStatus: applied vs available migrations
+// Current > Latest means the database was migrated by a newer binary
+type SchemaStatus struct {
+	Current int32           `json:"current"`
+	Latest  int32           `json:"latest"`
+	Pending []MigrationInfo `json:"pending"`
+}
+
+// UpToDate: every available migration is applied
+func (s SchemaStatus) UpToDate() bool {
+	return s.Current == s.Latest
+}
+
+// MigrationStatus: status over a dedicated connection, for the migrate status command
+func MigrationStatus(ctx context.Context, cfg *config.Config) (SchemaStatus, error) {
+	conn, err := pgx.Connect(ctx, DSN(&cfg.Database))
+	if err != nil {
+		return SchemaStatus{}, err
+	}
+	defer conn.Close(ctx)
+
+	return migrationStatus(ctx, conn, cfg.Database.MigrationsDir)
+}
+
+// MigrationStatus: status over the pool of the running service
+func (db *Database) MigrationStatus(ctx context.Context) (SchemaStatus, error) {
+	return migrationStatus(ctx, db, db.migrationsDir)
+}
+
+// rowQuerier: *pgx.Conn or *Database
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func migrationStatus(ctx context.Context, q rowQuerier, dir string) (SchemaStatus, error) {
+	available, err := availableMigrations(dir)
+	if err != nil {
+		return SchemaStatus{}, err
+	}
+
+	current, err := currentSchemaVersion(ctx, q)
+	if err != nil {
+		return SchemaStatus{}, err
+	}
+
+	status := SchemaStatus{
+		Current: current,
+		Latest:  int32(len(available)),
+		Pending: []MigrationInfo{},
+	}
+	if current < status.Latest {
+		status.Pending = available[current:]
+	}
+	return status, nil
+}
+
+// currentSchemaVersion: applied version, 0 before the first migration
+func currentSchemaVersion(ctx context.Context, q rowQuerier) (int32, error) {
+	var current int32
+	err := q.QueryRow(ctx, "SELECT version FROM "+schemaVersionTable).Scan(&current)
+
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == pgUndefinedTable:
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("retrieving current database migration version: %w", err)
+	}
+	return current, nil
+}
+
+// availableMigrations: migrations in order, version = position like in tern
+func availableMigrations(dir string) ([]MigrationInfo, error) {
+	subtree, err := migrationsFS(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := tern.FindMigrations(subtree)
+	if err != nil {
+		return nil, fmt.Errorf("finding database migrations: %w", err)
+	}
+
+	available := make([]MigrationInfo, len(paths))
+	for i, p := range paths {
+		available[i] = MigrationInfo{Version: int32(i + 1), Name: path.Base(p)}
+	}
+	return available, nil
+}
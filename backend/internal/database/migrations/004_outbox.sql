@@ -0,0 +1,21 @@
+-- transactional outbox: events are written in the transaction of the business change, the relay publishes them
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              BIGSERIAL PRIMARY KEY,
+    topic           TEXT NOT NULL,
+    key             TEXT NOT NULL DEFAULT '',
+    payload         JSONB NOT NULL,
+    headers         JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT NOT NULL DEFAULT '',
+    published_at    TIMESTAMPTZ
+);
+
+-- the relay only ever reads pending rows
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (next_attempt_at, id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events (published_at) WHERE published_at IS NOT NULL;
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS outbox_events;
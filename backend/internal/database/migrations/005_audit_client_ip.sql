@@ -0,0 +1,7 @@
+-- client ip of the request an audit record was emitted in, resolved through server.trusted_proxies
+-- adding a column with a constant default doesn't rewrite the table or fire the append-only trigger
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS client_ip TEXT NOT NULL DEFAULT '';
+
+---- create above / drop below ----
+
+ALTER TABLE audit_events DROP COLUMN IF EXISTS client_ip;
@@ -0,0 +1,7 @@
+-- pgcrypto (crypt, digest, hmac, ...) for the migrations after this one
+-- gen_random_uuid of 003_users.sql is built into postgres since 13 and doesn't need it
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+---- create above / drop below ----
+
+DROP EXTENSION IF EXISTS pgcrypto;
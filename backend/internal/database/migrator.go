@@ -3,68 +3,238 @@ package database
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
-	"net"
-	"net/url"
-	"strconv"
+	"os"
+	"strings"
 
 	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/retry"
 
 	"github.com/jackc/pgx/v5"
 	tern "github.com/jackc/tern/v2/migrate"
 	"github.com/rs/zerolog"
 )
 
+/**
+@dev migrations: internal/database/migrations/NNN_name.sql, embedded into the binary
+
+Migrate(ctx, logger, cfg)           → forward to the latest version, run on every startup
+MigrateTo(ctx, logger, cfg, 2)      → forward or back to version 2, down sections run newest first
+Rollback(ctx, logger, cfg, 1)       → back by 1 migration
+
+migration source, first match wins:
+    database.migrations_dir     → files on disk, e.g. hotfix migrations mounted by operators
+    SetMigrations(fsys)         → migration set of an app using the boilerplate as a library
+    embedded migrations/*.sql
+
+reverting a bad deployment: roll back with the new binary (it has the down sections of its migrations),
+then deploy the previous binary, its startup Migrate finds the schema up to date
+a migration without "---- create above / drop below ----" is irreversible, rolling back past it fails
+applied migrations are verified against their checksums, see migration_checksum.go
+*/
+
 //go:embed migrations/*.sql
 var migrations embed.FS
 
+// customMigrations: set with SetMigrations, replaces the embedded migrations
+var customMigrations fs.FS
+
+// SetMigrations: replaces the embedded migrations with fsys (NNN_name.sql files at its root)
+// must be called before Migrate / database.New
+func SetMigrations(fsys fs.FS) {
+	customMigrations = fsys
+}
+
+// schemaVersionTable: table of tern holding the current version
+const schemaVersionTable = "schema_version"
+
+// Migrate: applies every pending migration, with the driver of database.driver
 func Migrate(ctx context.Context, logger *zerolog.Logger, cfg *config.Config) error {
-	hostPort := net.JoinHostPort(cfg.Database.Host, strconv.Itoa(cfg.Database.Port))
-
-	// URL-encode the password
-	encodedPassword := url.QueryEscape(cfg.Database.Password)
-	dsn := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=%s",
-		cfg.Database.User,
-		encodedPassword,
-		hostPort,
-		cfg.Database.Name,
-		cfg.Database.SSLMode,
-	)
-
-	// we will not create new pools, just connect with db
-	conn, err := pgx.Connect(ctx, dsn)
+	if cfg.Database.Driver != "" && cfg.Database.Driver != postgresDriverName {
+		driver, err := DriverFor(cfg.Database.Driver)
+		if err != nil {
+			return err
+		}
+		return driver.Migrate(ctx, cfg, logger)
+	}
+	return migratePostgres(ctx, logger, cfg)
+}
+
+// migratePostgres: Migrate with tern
+func migratePostgres(ctx context.Context, logger *zerolog.Logger, cfg *config.Config) error {
+	return runMigrations(ctx, logger, cfg, func(m *tern.Migrator, _ int32) (int32, error) {
+		return int32(len(m.Migrations)), nil
+	})
+}
+
+// MigrateTo: migrates up or down to version, 0 reverts every migration
+func MigrateTo(ctx context.Context, logger *zerolog.Logger, cfg *config.Config, version int32) error {
+	return runMigrations(ctx, logger, cfg, func(m *tern.Migrator, _ int32) (int32, error) {
+		if version < 0 || version > int32(len(m.Migrations)) {
+			return 0, fmt.Errorf("migration version %d out of range, available 0 to %d", version, len(m.Migrations))
+		}
+		return version, nil
+	})
+}
+
+// Rollback: reverts the last steps migrations
+func Rollback(ctx context.Context, logger *zerolog.Logger, cfg *config.Config, steps int) error {
+	return runMigrations(ctx, logger, cfg, func(_ *tern.Migrator, current int32) (int32, error) {
+		if steps < 1 {
+			return 0, fmt.Errorf("rollback steps must be at least 1, got %d", steps)
+		}
+		if int32(steps) > current {
+			return 0, fmt.Errorf("can't roll back %d migrations, database is at version %d", steps, current)
+		}
+		return current - int32(steps), nil
+	})
+}
+
+// runMigrations: connect → load migrations → migrate to the version chosen by target
+func runMigrations(ctx context.Context, logger *zerolog.Logger, cfg *config.Config, target func(m *tern.Migrator, current int32) (int32, error)) error {
+	if cfg.Database.Driver != "" && cfg.Database.Driver != postgresDriverName {
+		return fmt.Errorf("%s driver only supports forward migrations (Migrate)", cfg.Database.Driver)
+	}
+
+	dsn := DSN(&cfg.Database)
+
+	// we will not create new pools, just connect with db, retried while postgres is starting
+	var conn *pgx.Conn
+	err := retry.Connect(ctx, cfg.Database.ConnectRetry, logger, "connecting to database for migrations", func(ctx context.Context) (err error) {
+		conn, err = pgx.Connect(ctx, dsn)
+		return permanentConnectError(err)
+	})
 	if err != nil {
 		return err
 	}
 	defer conn.Close(ctx)
 
-	// init tern migrator
-	m, err := tern.NewMigrator(ctx, conn, "schema_version")
+	// one instance migrates, the others wait for it (or skip, see migration_lock.go)
+	err = acquireMigrationLock(ctx, conn, logger, cfg.Database.MigrationLockTimeout, cfg.Database.MigrationLockSkip)
+	if errors.Is(err, errMigrationLockHeld) {
+		logger.Warn().Msg("skipping database migrations, another instance is running them")
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("constructing database migrator: %w", err)
+		return err
 	}
-	// real all files from migrations dir
-	subtree, err := fs.Sub(migrations, "migrations")
+	defer func() {
+		if err := releaseMigrationLock(context.WithoutCancel(ctx), conn); err != nil {
+			logger.Warn().Err(err).Msg("failed to release migration lock")
+		}
+	}()
+
+	m, err := newMigrator(ctx, conn, cfg.Database.MigrationsDir)
 	if err != nil {
-		return fmt.Errorf("retrieving database migrations subtree: %w", err)
+		return err
 	}
-	// load migrations
-	if err := m.LoadMigrations(subtree); err != nil {
-		return fmt.Errorf("loading database migrations: %w", err)
+	m.OnStart = func(sequence int32, name, direction, _ string) {
+		logger.Info().
+			Int32("version", sequence).
+			Str("name", name).
+			Str("direction", direction).
+			Msg("running database migration")
 	}
+
 	from, err := m.GetCurrentVersion(ctx)
 	if err != nil {
-		return fmt.Errorf("retreiving current database migration version")
+		return fmt.Errorf("retrieving current database migration version: %w", err)
 	}
-	if err := m.Migrate(ctx); err != nil {
+	to, err := target(m, from)
+	if err != nil {
 		return err
 	}
-	// checks for upgraded versions
-	if from == int32(len(m.Migrations)) {
-		logger.Info().Msgf("database schema up to date, version %d", len(m.Migrations))
-	} else {
-		logger.Info().Msgf("migrated database schema, from %d to %d", from, len(m.Migrations))
+
+	var checksums []migrationChecksum
+	if !cfg.Database.SkipMigrationChecksums {
+		if checksums, err = fileChecksums(cfg.Database.MigrationsDir); err != nil {
+			return err
+		}
+		if err := verifyMigrationChecksums(ctx, conn, checksums, from); err != nil {
+			return err
+		}
+	}
+
+	migrateErr := m.MigrateTo(ctx, to)
+
+	if checksums != nil {
+		// also after a failed run, the migrations before the failing one are applied
+		current, err := m.GetCurrentVersion(ctx)
+		if err == nil {
+			err = recordMigrationChecksums(ctx, conn, checksums, current)
+		}
+		if err != nil {
+			return errors.Join(migrateErr, err)
+		}
+	}
+	if migrateErr != nil {
+		return migrateErr
+	}
+
+	// checks for changed versions
+	switch {
+	case from == to:
+		logger.Info().Msgf("database schema up to date, version %d", to)
+	case from < to:
+		logger.Info().Msgf("migrated database schema, from %d to %d", from, to)
+	default:
+		logger.Warn().Msgf("rolled back database schema, from %d to %d", from, to)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// newMigrator: tern migrator with the migrations loaded, see migrationsFS
+func newMigrator(ctx context.Context, conn *pgx.Conn, dir string) (*tern.Migrator, error) {
+	// init tern migrator
+	m, err := tern.NewMigrator(ctx, conn, schemaVersionTable)
+	if err != nil {
+		return nil, fmt.Errorf("constructing database migrator: %w", err)
+	}
+	// real all files from migrations dir
+	subtree, err := migrationsFS(dir)
+	if err != nil {
+		return nil, err
+	}
+	// load migrations
+	if err := m.LoadMigrations(noopUpFS{subtree}); err != nil {
+		return nil, fmt.Errorf("loading database migrations: %w", err)
+	}
+	return m, nil
+}
+
+// noopUpFS: the migration files for tern, an up section holding only comments (001_setup.sql) runs as a no-op
+// tern refuses to load such a file, the file itself stays untouched so its checksum doesn't change
+type noopUpFS struct{ fs.FS }
+
+func (f noopUpFS) ReadFile(name string) ([]byte, error) {
+	body, err := fs.ReadFile(f.FS, name)
+	if err != nil || !strings.HasSuffix(name, ".sql") {
+		return body, err
+	}
+
+	up, _, _ := strings.Cut(string(body), "---- create above / drop below ----")
+	for line := range strings.SplitSeq(up, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return body, nil
+		}
+	}
+	return append([]byte("SELECT 1;\n"), body...), nil
+}
+
+// migrationsFS: the migration files, NNN_name.sql at the root
+func migrationsFS(dir string) (fs.FS, error) {
+	if dir != "" {
+		return os.DirFS(dir), nil
+	}
+	if customMigrations != nil {
+		return customMigrations, nil
+	}
+
+	subtree, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("retrieving database migrations subtree: %w", err)
+	}
+	return subtree, nil
+}
@@ -0,0 +1,223 @@
+package pagination
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+/**
+@dev keyset pagination: WHERE (created_at, id) < (last seen) instead of OFFSET, constant cost on every page
+
+var usersByNewest = pagination.Keyset[User, UserKey]{
+    Columns: []string{"created_at", "id"},    → stable: the last column must be unique
+    Desc:    true,
+    Key:     func(u User) UserKey { return UserKey{u.CreatedAt, u.ID} },
+}
+page, err := usersByNewest.Fetch(ctx, db, "SELECT * FROM users WHERE deleted_at IS NULL", nil, pagination.Params{Limit: 20, After: token})
+    → SELECT * FROM (<query>) AS page WHERE (created_at, id) < ($1, $2) ORDER BY created_at DESC, id DESC LIMIT 21
+    → the extra row tells whether there is a next page, it isn't returned
+    → page.Next / page.Prev: opaque cursors (base64url JSON of the key), pass them back as After / Before
+
+the key struct is decoded with its concrete types, so time.Time / uuid.UUID survive the round trip
+*/
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ErrInvalidCursor: cursor token that wasn't produced by Encode (or of another key type)
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Querier: *database.Database or pgx.Tx
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// Params: page request, at most one of After / Before
+type Params struct {
+	Limit  int
+	After  string // cursor of the last item of the previous page
+	Before string // cursor of the first item of the next page, when paging back
+}
+
+// Page: one page of items with the cursors of its neighbours, empty when there is none
+type Page[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next_cursor,omitempty"`
+	Prev  string `json:"prev_cursor,omitempty"`
+}
+
+// Keyset: ordering of a paginated query, K holds the values of Columns for one row
+type Keyset[T any, K any] struct {
+	Columns []string
+	Desc    bool
+	Key     func(item T) K
+}
+
+// Encode: opaque cursor of key
+func Encode[K any](key K) (string, error) {
+	body, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("encoding cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(body), nil
+}
+
+// Decode: key of a cursor made by Encode
+func Decode[K any](token string) (K, error) {
+	var key K
+	body, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return key, ErrInvalidCursor
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(body)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&key); err != nil {
+		return key, ErrInvalidCursor
+	}
+	return key, nil
+}
+
+// Fetch: one page of query, args are the bind parameters of query ($1..$n)
+func (k Keyset[T, K]) Fetch(ctx context.Context, db Querier, query string, args []any, params Params) (Page[T], error) {
+	if len(k.Columns) == 0 || k.Key == nil {
+		return Page[T]{}, errors.New("keyset needs columns and a key func")
+	}
+	if params.After != "" && params.Before != "" {
+		return Page[T]{}, errors.New("after and before cursors can't be combined")
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	// paging back: reversed order from the cursor, the rows are flipped again below
+	backward := params.Before != ""
+	token := params.After
+	if backward {
+		token = params.Before
+	}
+
+	var cursorValues []any
+	if token != "" {
+		key, err := Decode[K](token)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		if cursorValues, err = keyValues(key, len(k.Columns)); err != nil {
+			return Page[T]{}, err
+		}
+	}
+
+	sql, sqlArgs := k.pageSQL(query, args, cursorValues, backward, limit)
+	rows, err := db.Query(ctx, sql, sqlArgs...)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	items, err := pgx.CollectRows(rows, pgx.RowToStructByName[T])
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	more := len(items) > limit
+	if more {
+		items = items[:limit]
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+	if items == nil {
+		items = []T{}
+	}
+
+	page := Page[T]{Items: items}
+	if len(items) == 0 {
+		return page, nil
+	}
+
+	// forward: more rows → next page, a cursor → previous page; backward the other way around
+	hasNext, hasPrev := more, token != ""
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+	if hasNext {
+		if page.Next, err = Encode(k.Key(items[len(items)-1])); err != nil {
+			return Page[T]{}, err
+		}
+	}
+	if hasPrev {
+		if page.Prev, err = Encode(k.Key(items[0])); err != nil {
+			return Page[T]{}, err
+		}
+	}
+	return page, nil
+}
+
+// pageSQL: query wrapped with the keyset condition, order and limit + 1
+func (k Keyset[T, K]) pageSQL(query string, args, cursor []any, backward bool, limit int) (string, []any) {
+	desc := k.Desc != backward
+
+	columns := make([]string, len(k.Columns))
+	for i, column := range k.Columns {
+		columns[i] = pgx.Identifier{column}.Sanitize()
+	}
+	direction := " ASC"
+	operator := ">"
+	if desc {
+		direction = " DESC"
+		operator = "<"
+	}
+
+	var sql strings.Builder
+	sql.WriteString("SELECT * FROM (")
+	sql.WriteString(query)
+	sql.WriteString(") AS page")
+
+	allArgs := slices.Clone(args)
+	if cursor != nil {
+		placeholders := make([]string, len(cursor))
+		for i, value := range cursor {
+			allArgs = append(allArgs, value)
+			placeholders[i] = fmt.Sprintf("$%d", len(allArgs))
+		}
+		// row comparison, matches the index on (columns...) in one range scan
+		fmt.Fprintf(&sql, " WHERE (%s) %s (%s)", strings.Join(columns, ", "), operator, strings.Join(placeholders, ", "))
+	}
+
+	sql.WriteString(" ORDER BY ")
+	sql.WriteString(strings.Join(columns, direction+", ") + direction)
+	fmt.Fprintf(&sql, " LIMIT %d", limit+1)
+
+	return sql.String(), allArgs
+}
+
+// keyValues: exported fields of a key struct in declaration order, one per keyset column
+// a single column keyset can use the plain value (e.g. int64) as key
+func keyValues(key any, columns int) ([]any, error) {
+	v := reflect.ValueOf(key)
+	if v.Kind() != reflect.Struct || columns == 1 && v.NumField() != 1 {
+		return []any{key}, nil
+	}
+
+	var values []any
+	for i := range v.NumField() {
+		if v.Type().Field(i).IsExported() {
+			values = append(values, v.Field(i).Interface())
+		}
+	}
+	if len(values) != columns {
+		return nil, fmt.Errorf("cursor key has %d fields, keyset has %d columns", len(values), columns)
+	}
+	return values, nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// @dev query helpers: same as the pgxpool methods with database.query_timeout as deadline
+// @dev a runaway query gets cancelled instead of pinning a pool connection, a shorter deadline of ctx still wins
+
+// Exec: pool Exec on the primary with the default query timeout
+func (db *Database) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if err := db.checkDraining(); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	return db.Pool.Exec(ctx, sql, args...)
+}
+
+// Query: pool Query on the primary with the default query timeout, the deadline ends with rows.Close
+func (db *Database) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if err := db.checkDraining(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := db.withQueryTimeout(ctx)
+
+	rows, err := db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &timeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+// QueryRow: pool QueryRow on the primary with the default query timeout, the deadline ends with Scan
+// Scan is mandatory as with pgx: an unscanned row holds its connection and query context until the timeout fires
+func (db *Database) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if err := db.checkDraining(); err != nil {
+		return errRow{err: err}
+	}
+	ctx, cancel := db.withQueryTimeout(ctx)
+
+	return &timeoutRow{row: db.Pool.QueryRow(ctx, sql, args...), cancel: cancel}
+}
+
+// withQueryTimeout: ctx with the default query timeout, unchanged when none is configured
+func (db *Database) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, db.queryTimeout)
+}
+
+// timeoutRows: cancels the query context once the rows are closed
+// pgx.ForEachRow / CollectRows close the rows, so the deadline doesn't outlive the query
+type timeoutRows struct {
+	pgx.Rows
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRows) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+// timeoutRow: cancels the query context after Scan, pgx.Row is read exactly once
+// without Scan nothing ends the query, the connection goes back to the pool when the deadline cancels it
+type timeoutRow struct {
+	row    pgx.Row
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRow) Scan(dest ...any) error {
+	defer r.cancel()
+	return r.row.Scan(dest...)
+}
+
+// errRow: pgx.Row failing with err, for QueryRow calls refused before a query ran
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error {
+	return r.err
+}
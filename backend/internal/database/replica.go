@@ -0,0 +1,200 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+// @dev read replicas: extra postgres servers which receive a copy of the primary's data
+// @dev read heavy queries can be served by replicas, writes always go to the primary
+
+/**
+@dev read/write splitting
+
+db.Writer() → primary pool, for writes and reads which must see them (read-your-writes)
+db.Reader() → healthy replica picked by database.replica_strategy
+    → round_robin: next replica in turn
+    → least_connections: replica with the fewest acquired connections
+    → no healthy replica (or none configured): primary
+
+replicas are pinged every replicaHealthInterval, a failing one is skipped until it answers again
+*/
+
+const (
+	replicaHealthInterval = 10 * time.Second
+	replicaPingTimeout    = 2 * time.Second
+)
+
+// ReplicaDSNs: builds one connection string per configured read replica
+// user, password, db name, ssl mode and connection params are same as primary, only host and port differ
+func ReplicaDSNs(cfg *config.Config) []string {
+	dsns := make([]string, 0, len(cfg.Database.Replicas))
+
+	for _, replica := range cfg.Database.Replicas {
+		dsns = append(dsns, DSNForHost(&cfg.Database, replica.Host, replica.Port))
+	}
+
+	return dsns
+}
+
+// replica: pool of one read replica with its last health check result
+type replica struct {
+	pool    *pgxpool.Pool
+	name    string // pool label of the metrics
+	host    string
+	healthy atomic.Bool
+}
+
+// replicaSet: pools of all read replicas and the health checker keeping their state
+type replicaSet struct {
+	replicas []*replica
+	strategy string
+	next     atomic.Uint64 // round robin position
+	log      *zerolog.Logger
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newReplicaSet(cfg *config.Config, logger *zerolog.Logger, loggerService *loggerConfig.LoggerService) (*replicaSet, error) {
+	set := &replicaSet{
+		strategy: cfg.Database.ReplicaStrategy,
+		log:      logger,
+		stop:     make(chan struct{}),
+	}
+
+	for i, dsn := range ReplicaDSNs(cfg) {
+		poolConfig, err := newPoolConfig(dsn, cfg, logger, loggerService)
+		if err != nil {
+			set.closePools()
+			return nil, err
+		}
+
+		// pgxpool connects lazily, an unreachable replica doesn't fail startup, it's only marked unhealthy
+		pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+		if err != nil {
+			set.closePools()
+			return nil, fmt.Errorf("failed to create replica pool: %w", err)
+		}
+
+		r := &replica{
+			pool: pool,
+			name: fmt.Sprintf("replica_%s_%d", cfg.Database.Replicas[i].Host, cfg.Database.Replicas[i].Port),
+			host: cfg.Database.Replicas[i].Host,
+		}
+		// assumed healthy, so the first check only logs the replicas which are down
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+		poolStats.add(r.name, pool)
+	}
+
+	set.check()
+
+	set.wg.Add(1)
+	go set.run()
+
+	return set, nil
+}
+
+// run: health checks until Close
+func (s *replicaSet) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(replicaHealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.check()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// check: pings every replica and logs state changes
+func (s *replicaSet) check() {
+	for _, r := range s.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaPingTimeout)
+		err := r.pool.Ping(ctx)
+		cancel()
+
+		healthy := err == nil
+		if previous := r.healthy.Swap(healthy); previous != healthy {
+			if healthy {
+				s.log.Info().Str("replica", r.host).Msg("read replica is healthy")
+			} else {
+				s.log.Warn().Err(err).Str("replica", r.host).Msg("read replica is unhealthy, reads fall back to other replicas or the primary")
+			}
+		}
+	}
+}
+
+// pick: a healthy replica according to the strategy, nil when all are down
+func (s *replicaSet) pick() *pgxpool.Pool {
+	if s.strategy == "least_connections" {
+		var best *replica
+		for _, r := range s.replicas {
+			if !r.healthy.Load() {
+				continue
+			}
+			if best == nil || r.pool.Stat().AcquiredConns() < best.pool.Stat().AcquiredConns() {
+				best = r
+			}
+		}
+		if best == nil {
+			return nil
+		}
+		return best.pool
+	}
+
+	// round robin, starting after the replica used last time
+	start := s.next.Add(1)
+	for i := range s.replicas {
+		r := s.replicas[(start+uint64(i))%uint64(len(s.replicas))]
+		if r.healthy.Load() {
+			return r.pool
+		}
+	}
+	return nil
+}
+
+// Close: stops the health checks and closes every replica pool
+func (s *replicaSet) Close() {
+	close(s.stop)
+	s.wg.Wait()
+	s.closePools()
+}
+
+func (s *replicaSet) closePools() {
+	for _, r := range s.replicas {
+		poolStats.remove(r.name)
+		r.pool.Close()
+	}
+}
+
+// Writer: primary pool, every write and every read which has to see the latest writes goes here
+func (db *Database) Writer() *pgxpool.Pool {
+	return db.Pool
+}
+
+// Reader: pool of a healthy read replica, the primary when there is none
+// replicas lag behind the primary, don't read through it what the same request just wrote
+func (db *Database) Reader() *pgxpool.Pool {
+	if db.replicas == nil {
+		return db.Pool
+	}
+	if pool := db.replicas.pick(); pool != nil {
+		return pool
+	}
+	return db.Pool
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/jackc/pgx/v5"
+)
+
+/**
+@dev row-level security: postgres enforces which rows a request can see, a missing WHERE in a query can't leak data
+
+db.WithRLS(ctx, database.RLSClaims{UserID: "42", TenantID: "acme"}, func(tx pgx.Tx) error { ... })
+    → SET LOCAL app.current_user_id = '42', app.tenant_id = 'acme'   (set_config(..., true), ends with the transaction)
+    → policies read them back:
+
+        ALTER TABLE documents ENABLE ROW LEVEL SECURITY;
+        CREATE POLICY tenant_isolation ON documents
+            USING (tenant_id = current_setting('app.tenant_id', true));
+
+current_setting(..., true) returns NULL outside WithRLS, so policies match nothing instead of everything
+the application role must not own the tables (or use FORCE ROW LEVEL SECURITY), owners bypass policies
+*/
+
+// settingPattern: custom settings need a dotted name, postgres rejects undotted unknown ones
+var settingPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*\.[a-z_][a-z0-9_]*$`)
+
+// RLSClaims: identity of the request, TenantID falls back to the tenant in ctx (ContextWithTenant)
+type RLSClaims struct {
+	UserID   string
+	TenantID string
+	// additional settings, e.g. {"app.role": "admin"}
+	Extra map[string]string
+}
+
+// WithRLS: WithTx with the claims as transaction local settings for RLS policies
+func (db *Database) WithRLS(ctx context.Context, claims RLSClaims, fn func(tx pgx.Tx) error) error {
+	if claims.TenantID == "" {
+		claims.TenantID, _ = TenantFromContext(ctx)
+	}
+
+	settings := map[string]string{
+		"app.current_user_id": claims.UserID,
+		"app.tenant_id":       claims.TenantID,
+	}
+	for name, value := range claims.Extra {
+		if !settingPattern.MatchString(name) {
+			return fmt.Errorf("invalid rls setting name %q, use <prefix>.<name>", name)
+		}
+		settings[name] = value
+	}
+
+	return db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		for name, value := range settings {
+			// empty claims stay unset, current_setting(..., true) is NULL for them
+			if value == "" {
+				continue
+			}
+			if _, err := tx.Exec(ctx, "SELECT set_config($1, $2, true)", name, value); err != nil {
+				return fmt.Errorf("failed to set %s: %w", name, err)
+			}
+		}
+		return fn(tx)
+	})
+}
@@ -0,0 +1,167 @@
+package seeds
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev seeds: baseline data for local setups, tests and demo environments, not schema changes (those are migrations)
+
+SQL seeder: internal/database/seeds/sql/<name>.sql, first line lists the environments
+    -- environments: local, staging
+Go seeder:  seeds.Register(seeds.Seeder{Name: "demo_orders", Environments: []string{"local"}, Run: func(ctx, tx) error { ... }})
+
+seeds.Run(ctx, db, env, logger)      → "go-boilerplate seed" or database.seed_on_startup
+    → seeders of env in name order, each in its own transaction
+        → name recorded in seed_runs in the same transaction, a seeder runs once per database
+    → seeders without environments never run, production has to be listed explicitly
+*/
+
+//go:embed sql
+var sqlSeeds embed.FS
+
+const environmentsPrefix = "-- environments:"
+
+// Seeder: named unit of seed data
+type Seeder struct {
+	Name         string
+	Environments []string
+	Run          func(ctx context.Context, tx pgx.Tx) error
+}
+
+var (
+	mu       sync.Mutex
+	registry = map[string]Seeder{}
+)
+
+// Register: adds a Go seeder, typically from an init func, panics on duplicate names
+func Register(seeder Seeder) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := registry[seeder.Name]; exists {
+		panic(fmt.Sprintf("seeds: seeder %q registered twice", seeder.Name))
+	}
+	registry[seeder.Name] = seeder
+}
+
+// Run: applies the seeders of env which didn't run yet, returns how many ran
+func Run(ctx context.Context, db *database.Database, env string, logger *zerolog.Logger) (int, error) {
+	seeders, err := forEnvironment(env)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := db.Exec(ctx, `CREATE TABLE IF NOT EXISTS seed_runs (
+		name       TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return 0, fmt.Errorf("failed to create seed_runs table: %w", err)
+	}
+
+	applied := 0
+	for _, seeder := range seeders {
+		ran := false
+		err := db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+			// the row lock of the insert also keeps two instances from seeding at once
+			tag, err := tx.Exec(ctx, "INSERT INTO seed_runs (name) VALUES ($1) ON CONFLICT (name) DO NOTHING", seeder.Name)
+			if err != nil {
+				return err
+			}
+			if tag.RowsAffected() == 0 {
+				return nil
+			}
+
+			ran = true
+			return seeder.Run(ctx, tx)
+		})
+		if err != nil {
+			return applied, fmt.Errorf("seeder %s: %w", seeder.Name, err)
+		}
+
+		if ran {
+			applied++
+			logger.Info().Str("seeder", seeder.Name).Str("env", env).Msg("applied seed data")
+		} else {
+			logger.Debug().Str("seeder", seeder.Name).Msg("seed data already applied")
+		}
+	}
+	return applied, nil
+}
+
+// forEnvironment: SQL and Go seeders of env, sorted by name
+func forEnvironment(env string) ([]Seeder, error) {
+	all, err := sqlSeeders()
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	for _, seeder := range registry {
+		if slices.ContainsFunc(all, func(s Seeder) bool { return s.Name == seeder.Name }) {
+			mu.Unlock()
+			return nil, fmt.Errorf("seeder %q exists as SQL file and Go seeder", seeder.Name)
+		}
+		all = append(all, seeder)
+	}
+	mu.Unlock()
+
+	var seeders []Seeder
+	for _, seeder := range all {
+		if slices.Contains(seeder.Environments, env) {
+			seeders = append(seeders, seeder)
+		}
+	}
+	slices.SortFunc(seeders, func(a, b Seeder) int { return strings.Compare(a.Name, b.Name) })
+	return seeders, nil
+}
+
+// sqlSeeders: embedded sql/*.sql files, the name is the file name without .sql
+func sqlSeeders() ([]Seeder, error) {
+	files, err := fs.Glob(sqlSeeds, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	seeders := make([]Seeder, 0, len(files))
+	for _, file := range files {
+		body, err := sqlSeeds.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading seed %s: %w", file, err)
+		}
+
+		sql := string(body)
+		firstLine, _, _ := strings.Cut(sql, "\n")
+		if !strings.HasPrefix(firstLine, environmentsPrefix) {
+			return nil, fmt.Errorf("seed %s: first line must be %q", file, environmentsPrefix+" local, ...")
+		}
+
+		var environments []string
+		for _, env := range strings.Split(strings.TrimPrefix(firstLine, environmentsPrefix), ",") {
+			if env = strings.TrimSpace(env); env != "" {
+				environments = append(environments, env)
+			}
+		}
+
+		seeders = append(seeders, Seeder{
+			Name:         strings.TrimSuffix(path.Base(file), ".sql"),
+			Environments: environments,
+			Run: func(ctx context.Context, tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, sql)
+				return err
+			},
+		})
+	}
+	return seeders, nil
+}
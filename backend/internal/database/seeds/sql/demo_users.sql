@@ -0,0 +1,6 @@
+-- environments: local, test, staging
+-- a few users to click through the API with
+INSERT INTO users (email, name) VALUES
+    ('alice@example.com', 'Alice Example'),
+    ('bob@example.com', 'Bob Example')
+ON CONFLICT (email) DO NOTHING;
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/rs/zerolog"
+)
+
+// @dev slow query log: queries slower than observability.logging.slow_query_threshold are logged as WARN
+// @dev and recorded as SlowQuery custom events in New Relic, in every environment
+
+// maxSlowQuerySQLLength: long generated queries are cut, the start is enough to find them
+const maxSlowQuerySQLLength = 2048
+
+// slowQueryTracer: pgx tracer measuring every query
+type slowQueryTracer struct {
+	threshold time.Duration
+	log       *zerolog.Logger
+	nrApp     *newrelic.Application // nil without New Relic
+}
+
+type slowQueryKey struct{}
+
+type slowQueryStart struct {
+	start time.Time
+	sql   string
+	args  int
+}
+
+func newSlowQueryTracer(threshold time.Duration, logger *zerolog.Logger, nrApp *newrelic.Application) *slowQueryTracer {
+	return &slowQueryTracer{threshold: threshold, log: logger, nrApp: nrApp}
+}
+
+// TraceQueryStart implements pgx tracer interface
+func (t *slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, slowQueryKey{}, slowQueryStart{
+		start: time.Now(),
+		sql:   data.SQL,
+		args:  len(data.Args),
+	})
+}
+
+// TraceQueryEnd implements pgx tracer interface
+func (t *slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	started, ok := ctx.Value(slowQueryKey{}).(slowQueryStart)
+	if !ok {
+		return
+	}
+
+	duration := time.Since(started.start)
+	if duration < t.threshold {
+		return
+	}
+
+	sql := started.sql
+	if len(sql) > maxSlowQuerySQLLength {
+		sql = sql[:maxSlowQuerySQLLength] + "..."
+	}
+
+	// request scoped logger when there is one, so the line carries request_id / trace.id
+	logger := t.log
+	if ctxLogger := zerolog.Ctx(ctx); ctxLogger != zerolog.DefaultContextLogger && ctxLogger.GetLevel() != zerolog.Disabled {
+		logger = ctxLogger
+	}
+
+	event := logger.Warn().
+		Str("sql", sql).
+		Int("args", started.args).
+		Dur("duration", duration).
+		Dur("threshold", t.threshold)
+	if data.Err != nil {
+		event = loggerConfig.ErrorFields(event, data.Err)
+	}
+	event.Msg("slow query")
+
+	if t.nrApp != nil {
+		// args values are not sent, they may contain personal data
+		t.nrApp.RecordCustomEvent("SlowQuery", map[string]any{
+			"sql":        sql,
+			"args":       started.args,
+			"durationMs": duration.Milliseconds(),
+			"failed":     data.Err != nil,
+		})
+	}
+}
@@ -0,0 +1,384 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/anuragShingare30/go-boilerplate/internal/retry"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/rs/zerolog"
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver, pure Go (no cgo)
+)
+
+/**
+@dev mysql / sqlite: database/sql drivers behind the Driver interface
+
+db := conn.(*database.SQLDatabase)
+db.Exec / db.Query / db.QueryRow / db.WithTx → "?" placeholders, query hooks around every call
+    → slow query log (observability.logging.slow_query_threshold), New Relic datastore segments
+    → db.AddQueryHook(hook) for more, e.g. OpenTelemetry spans
+database.Migrate → driver_migrations/<driver>/NNN_name.sql (or database.migrations_dir), forward only,
+    one transaction per migration (mysql commits DDL implicitly, a failed migration can be half applied)
+    no migration lock, run migrate from one place when several instances start together
+
+sqlite: database.path is the file, ":memory:" lives as long as the pool, migrate it with db.Migrate
+*/
+
+// sqlDialect: what differs between the database/sql drivers
+type sqlDialect struct {
+	name         string // database.driver
+	driverName   string // database/sql driver
+	product      newrelic.DatastoreProduct
+	versionQuery string
+	// dsn: forMigrations allows several statements per Exec, the migration files need it
+	dsn func(cfg *config.DatabaseConfig, forMigrations bool) string
+}
+
+var mysqlDialect = sqlDialect{
+	name:         "mysql",
+	driverName:   "mysql",
+	product:      newrelic.DatastoreMySQL,
+	versionQuery: "SELECT VERSION()",
+	dsn:          mysqlDSN,
+}
+
+var sqliteDialect = sqlDialect{
+	name:         "sqlite",
+	driverName:   "sqlite",
+	product:      newrelic.DatastoreProduct("SQLite"),
+	versionQuery: "SELECT sqlite_version()",
+	dsn:          sqliteDSN,
+}
+
+// mysqlDSN: user:password@tcp(host:port)/name?..., ssl_mode is mapped on the tls param
+func mysqlDSN(cfg *config.DatabaseConfig, forMigrations bool) string {
+	c := mysql.NewConfig()
+	c.User = cfg.User
+	c.Passwd = cfg.Password
+	c.Net = "tcp"
+	c.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	c.DBName = cfg.Name
+	c.ParseTime = true
+	// only the migration connection, stacked statements in app queries would make injections worse
+	c.MultiStatements = forMigrations
+	if cfg.ConnectTimeout > 0 {
+		c.Timeout = time.Duration(cfg.ConnectTimeout) * time.Second
+	}
+	if cfg.ApplicationName != "" {
+		c.ConnectionAttributes = "program_name:" + cfg.ApplicationName
+	}
+
+	switch cfg.SSLMode {
+	case "allow", "prefer":
+		c.TLSConfig = "preferred"
+	case "require":
+		c.TLSConfig = "skip-verify"
+	case "verify-ca", "verify-full":
+		c.TLSConfig = "true"
+	default:
+		c.TLSConfig = "false"
+	}
+
+	if len(cfg.ExtraParams) > 0 {
+		c.Params = make(map[string]string, len(cfg.ExtraParams))
+		for key, value := range cfg.ExtraParams {
+			c.Params[key] = value
+		}
+	}
+	return c.FormatDSN()
+}
+
+// sqliteDSN: file:<path>?_pragma=..., foreign keys on and a busy timeout instead of immediate SQLITE_BUSY errors
+func sqliteDSN(cfg *config.DatabaseConfig, _ bool) string {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	if cfg.Path != ":memory:" {
+		// readers don't block the writer
+		params.Add("_pragma", "journal_mode(WAL)")
+	}
+	for key, value := range cfg.ExtraParams {
+		params.Set(key, value)
+	}
+	return "file:" + cfg.Path + "?" + params.Encode()
+}
+
+// sqlDriver: Driver of a sqlDialect
+type sqlDriver struct {
+	dialect sqlDialect
+}
+
+func newSQLDriver(dialect sqlDialect) sqlDriver {
+	return sqlDriver{dialect: dialect}
+}
+
+func (d sqlDriver) Name() string {
+	return d.dialect.name
+}
+
+func (d sqlDriver) Connect(ctx context.Context, cfg *config.Config, logger *zerolog.Logger, loggerService *loggerConfig.LoggerService) (Conn, error) {
+	pool, err := d.open(ctx, cfg, logger, false)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info().Str("driver", d.dialect.name).Msg("connected to database!!!")
+	return &SQLDatabase{
+		DB:            pool,
+		dialect:       d.dialect,
+		log:           logger,
+		hooks:         sqlQueryHooks(cfg, d.dialect, logger, loggerService),
+		migrationsDir: cfg.Database.MigrationsDir,
+	}, nil
+}
+
+func (d sqlDriver) Migrate(ctx context.Context, cfg *config.Config, logger *zerolog.Logger) error {
+	pool, err := d.open(ctx, cfg, logger, true)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	return migrateSQL(ctx, pool, d.dialect, cfg.Database.MigrationsDir, logger)
+}
+
+// open: pool with the database.* pool settings, pinged until the database answers
+func (d sqlDriver) open(ctx context.Context, cfg *config.Config, logger *zerolog.Logger, forMigrations bool) (*sql.DB, error) {
+	pool, err := sql.Open(d.dialect.driverName, d.dialect.dsn(&cfg.Database, forMigrations))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", d.dialect.name, err)
+	}
+
+	pool.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	pool.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	pool.SetConnMaxLifetime(time.Duration(cfg.Database.ConnMaxLifetime) * time.Second)
+	pool.SetConnMaxIdleTime(time.Duration(cfg.Database.ConnMaxIdletime) * time.Second)
+	if d.dialect.name == sqliteDialect.name && cfg.Database.Path == ":memory:" {
+		// every connection would get its own empty in-memory database
+		pool.SetMaxOpenConns(1)
+		pool.SetConnMaxLifetime(0)
+		pool.SetConnMaxIdleTime(0)
+	}
+
+	err = retry.Connect(ctx, cfg.Database.ConnectRetry, logger, "connecting to "+d.dialect.name, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, DatabasePingTimeout*time.Second)
+		defer cancel()
+		return pool.PingContext(ctx)
+	})
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return pool, nil
+}
+
+// SQLDatabase: open mysql / sqlite database
+type SQLDatabase struct {
+	DB            *sql.DB
+	dialect       sqlDialect
+	log           *zerolog.Logger
+	hooks         []QueryHook
+	migrationsDir string
+}
+
+// Driver: database.driver of db
+func (db *SQLDatabase) Driver() string {
+	return db.dialect.name
+}
+
+// AddQueryHook: runs hook around every query after the configured ones, must be called before db is used
+func (db *SQLDatabase) AddQueryHook(hook QueryHook) {
+	db.hooks = append(db.hooks, hook)
+}
+
+// Exec: ExecContext with the query hooks
+func (db *SQLDatabase) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, end := db.trace(ctx, query, args)
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	end(err)
+	return result, err
+}
+
+// Query: QueryContext with the query hooks, the hooks end when the query returns, not when the rows are read
+func (db *SQLDatabase) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, end := db.trace(ctx, query, args)
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	end(err)
+	return rows, err
+}
+
+// QueryRow: QueryRowContext with the query hooks
+func (db *SQLDatabase) QueryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, end := db.trace(ctx, query, args)
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	end(row.Err())
+	return row
+}
+
+// WithTx: runs fn in a transaction, rollback on error or panic
+// statements on tx don't go through the query hooks
+func (db *SQLDatabase) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				err = errors.Join(err, fmt.Errorf("failed to rollback transaction: %w", rbErr))
+			}
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Migrate: pending migrations on this pool, the way to migrate a sqlite ":memory:" database
+func (db *SQLDatabase) Migrate(ctx context.Context) error {
+	return migrateSQL(ctx, db.DB, db.dialect, db.migrationsDir, db.log)
+}
+
+// HealthCheck: round trip of SELECT 1, server version and pool usage, replicas are a postgres feature
+func (db *SQLDatabase) HealthCheck(ctx context.Context) (Health, error) {
+	stats := db.DB.Stats()
+	health := Health{Pool: newPoolHealth(
+		int32(stats.InUse),
+		int32(stats.Idle),
+		int32(stats.OpenConnections),
+		int32(stats.MaxOpenConnections),
+	)}
+
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return health, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	started := time.Now()
+	var one int
+	if err := conn.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return health, fmt.Errorf("failed to query database: %w", err)
+	}
+	health.Latency = time.Since(started)
+
+	if err := conn.QueryRowContext(ctx, db.dialect.versionQuery).Scan(&health.ServerVersion); err != nil {
+		return health, fmt.Errorf("failed to read server version: %w", err)
+	}
+	return health, nil
+}
+
+// Close: closes the pool
+func (db *SQLDatabase) Close() error {
+	db.log.Info().Str("driver", db.dialect.name).Msg("closing database connection pool!!!")
+	return db.DB.Close()
+}
+
+// trace: QueryStart of every hook, the returned func ends them in reverse order
+func (db *SQLDatabase) trace(ctx context.Context, query string, args []any) (context.Context, func(err error)) {
+	for _, hook := range db.hooks {
+		ctx = hook.QueryStart(ctx, query, args)
+	}
+	return ctx, func(err error) {
+		for i := len(db.hooks) - 1; i >= 0; i-- {
+			db.hooks[i].QueryEnd(ctx, err)
+		}
+	}
+}
+
+// QueryHook: tracer of the database/sql drivers, the counterpart of pgx.QueryTracer
+type QueryHook interface {
+	QueryStart(ctx context.Context, query string, args []any) context.Context
+	QueryEnd(ctx context.Context, err error)
+}
+
+// sqlQueryHooks: same selection as the pgx tracers, database.tracers or the backends which are configured
+func sqlQueryHooks(cfg *config.Config, dialect sqlDialect, logger *zerolog.Logger, loggerService *loggerConfig.LoggerService) []QueryHook {
+	var hooks []QueryHook
+	var nrApp *newrelic.Application
+	if loggerService != nil {
+		nrApp = loggerService.GetApplication()
+	}
+
+	for _, name := range queryTracers(&cfg.Database, loggerService) {
+		switch name {
+		case "newrelic":
+			if nrApp == nil {
+				logger.Warn().Msg("newrelic query tracer selected but the New Relic agent is not running")
+				continue
+			}
+			hooks = append(hooks, &newRelicHook{product: dialect.product, database: cfg.Database.Name})
+		case "otel":
+			logger.Warn().Str("driver", dialect.name).Msg("otel query tracer is only built in for postgres, add one with AddQueryHook")
+		}
+	}
+
+	if threshold := cfg.Observability.Logging.SlowQueryThreshold; threshold > 0 {
+		hooks = append(hooks, slowQueryHook{tracer: newSlowQueryTracer(threshold, logger, nrApp)})
+	}
+	return hooks
+}
+
+// slowQueryHook: the slow query tracer of the pgx pool, it only reads SQL, args and error of the trace data
+type slowQueryHook struct {
+	tracer *slowQueryTracer
+}
+
+func (h slowQueryHook) QueryStart(ctx context.Context, query string, args []any) context.Context {
+	return h.tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: query, Args: args})
+}
+
+func (h slowQueryHook) QueryEnd(ctx context.Context, err error) {
+	h.tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: err})
+}
+
+// newRelicHook: datastore segment in the New Relic transaction of ctx, nothing without one
+type newRelicHook struct {
+	product  newrelic.DatastoreProduct
+	database string
+}
+
+type newRelicSegmentKey struct{}
+
+func (h *newRelicHook) QueryStart(ctx context.Context, query string, _ []any) context.Context {
+	txn := newrelic.FromContext(ctx)
+	if txn == nil {
+		return ctx
+	}
+
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	return context.WithValue(ctx, newRelicSegmentKey{}, &newrelic.DatastoreSegment{
+		StartTime:          txn.StartSegmentNow(),
+		Product:            h.product,
+		Operation:          strings.ToUpper(operation),
+		ParameterizedQuery: query,
+		DatabaseName:       h.database,
+	})
+}
+
+func (h *newRelicHook) QueryEnd(ctx context.Context, _ error) {
+	if segment, ok := ctx.Value(newRelicSegmentKey{}).(*newrelic.DatastoreSegment); ok {
+		segment.End()
+	}
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	tern "github.com/jackc/tern/v2/migrate"
+	"github.com/rs/zerolog"
+)
+
+// @dev migrations of the mysql / sqlite drivers: tern only parses the files, the SQL runs through database/sql
+// @dev the version is kept in a one row schema_version table, like tern does on postgres
+
+//go:embed driver_migrations
+var driverMigrations embed.FS
+
+// migrateSQL: every pending migration of dialect, one transaction each
+func migrateSQL(ctx context.Context, db *sql.DB, dialect sqlDialect, dir string, logger *zerolog.Logger) error {
+	fsys, err := sqlMigrationsFS(dir, dialect.name)
+	if err != nil {
+		return err
+	}
+
+	// nil conn: nothing is executed through tern
+	m, err := tern.NewMigrator(ctx, nil, schemaVersionTable)
+	if err != nil {
+		return fmt.Errorf("constructing database migrator: %w", err)
+	}
+	if err := m.LoadMigrations(fsys); err != nil {
+		return fmt.Errorf("loading database migrations: %w", err)
+	}
+
+	current, err := sqlSchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	latest := int32(len(m.Migrations))
+	if current > latest {
+		return fmt.Errorf("database is at version %d, only %d migrations available", current, latest)
+	}
+
+	for _, migration := range m.Migrations[current:] {
+		logger.Info().
+			Str("driver", dialect.name).
+			Int32("sequence", migration.Sequence).
+			Str("name", migration.Name).
+			Msg("running database migration")
+
+		err := applySQLMigration(ctx, db, migration)
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %w", migration.Sequence, migration.Name, err)
+		}
+	}
+
+	logger.Info().Str("driver", dialect.name).Int32("version", latest).Msg("database migrated")
+	return nil
+}
+
+func applySQLMigration(ctx context.Context, db *sql.DB, migration *tern.Migration) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, migration.UpSQL); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "UPDATE "+schemaVersionTable+" SET version = ?", migration.Sequence); err != nil {
+		return fmt.Errorf("failed to update schema version: %w", err)
+	}
+	return tx.Commit()
+}
+
+// sqlSchemaVersion: current version, the version table is created at version 0 on the first run
+func sqlSchemaVersion(ctx context.Context, db *sql.DB) (int32, error) {
+	if _, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+schemaVersionTable+" (version INTEGER NOT NULL)"); err != nil {
+		return 0, fmt.Errorf("failed to create version table: %w", err)
+	}
+
+	var version int32
+	err := db.QueryRowContext(ctx, "SELECT version FROM "+schemaVersionTable).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := db.ExecContext(ctx, "INSERT INTO "+schemaVersionTable+" (version) VALUES (0)"); err != nil {
+			return 0, fmt.Errorf("failed to initialize version table: %w", err)
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// sqlMigrationsFS: database.migrations_dir, otherwise the embedded migrations of the driver
+func sqlMigrationsFS(dir, driver string) (fs.FS, error) {
+	if dir != "" {
+		return os.DirFS(dir), nil
+	}
+	subtree, err := fs.Sub(driverMigrations, "driver_migrations/"+driver)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving %s migrations subtree: %w", driver, err)
+	}
+	return subtree, nil
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/jackc/pgx/v5"
+)
+
+/**
+@dev multi-tenancy: schema per tenant, database.tenancy
+
+request → middleware.Tenant (X-Tenant-ID header) → database.ContextWithTenant(ctx, "acme")
+    → db.WithTenantTx(ctx, opts, fn)
+        → SET LOCAL search_path = "tenant_acme", public   (set_config(..., true), ends with the transaction)
+        → unqualified tables resolve to the tenant schema first, shared tables stay in public
+
+the search_path is only switched inside the transaction, so pooled connections never leak a tenant to the next request
+tenant schemas are migrated with MigrateTenants (internal/database/tenant_migrations)
+*/
+
+// ErrNoTenant: tenant scoped access without a tenant in the context
+var ErrNoTenant = errors.New("no tenant in context")
+
+var (
+	// tenantPattern: tenant ids end up in schema names, keep them to plain identifiers
+	tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]{0,47}$`)
+	// schemaPattern: unquoted postgres identifier, tern doesn't quote the version table
+	schemaPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+)
+
+type tenantKey struct{}
+
+// ContextWithTenant: ctx carrying the tenant id
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext: the tenant id of ctx
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// ValidTenant: tenant can be used as part of a schema name
+func ValidTenant(tenant string) bool {
+	return tenantPattern.MatchString(tenant)
+}
+
+// TenantSchema: schema of tenant, schema prefix + tenant id
+func TenantSchema(prefix, tenant string) (string, error) {
+	if !ValidTenant(tenant) {
+		return "", fmt.Errorf("invalid tenant id %q", tenant)
+	}
+	schema := prefix + tenant
+	if !schemaPattern.MatchString(schema) {
+		return "", fmt.Errorf("invalid tenant schema %q, check database.tenancy.schema_prefix", schema)
+	}
+	return schema, nil
+}
+
+// WithTenantTx: WithTx with the search_path of the tenant in ctx
+func (db *Database) WithTenantTx(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	schema, err := TenantSchema(db.tenantSchemaPrefix, tenant)
+	if err != nil {
+		return err
+	}
+
+	return db.WithTx(ctx, opts, func(tx pgx.Tx) error {
+		// set_config instead of SET: the value can be a bind parameter, is_local = true scopes it to tx
+		searchPath := pgx.Identifier{schema}.Sanitize() + ", public"
+		if _, err := tx.Exec(ctx, "SELECT set_config('search_path', $1, true)", searchPath); err != nil {
+			return fmt.Errorf("failed to switch to tenant schema: %w", err)
+		}
+		return fn(tx)
+	})
+}
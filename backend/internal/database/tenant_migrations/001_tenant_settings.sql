@@ -0,0 +1,10 @@
+-- per tenant key/value settings, created in every tenant schema
+CREATE TABLE IF NOT EXISTS tenant_settings (
+    key        TEXT PRIMARY KEY,
+    value      JSONB NOT NULL DEFAULT '{}'::jsonb,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS tenant_settings;
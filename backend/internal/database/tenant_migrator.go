@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/retry"
+	"github.com/jackc/pgx/v5"
+	tern "github.com/jackc/tern/v2/migrate"
+	"github.com/rs/zerolog"
+)
+
+// @dev tenant migrations: internal/database/tenant_migrations/NNN_name.sql are applied to every tenant schema
+// @dev tenants = database.tenancy.tenants (schemas are created) + existing schemas with the tenant prefix
+// @dev each schema has its own <schema>.schema_version, the shared migration lock keeps instances from racing
+
+//go:embed tenant_migrations/*.sql
+var tenantMigrations embed.FS
+
+// MigrateTenants: applies the pending tenant migrations to every tenant schema
+func MigrateTenants(ctx context.Context, logger *zerolog.Logger, cfg *config.Config) error {
+	tenancy := cfg.Database.Tenancy
+
+	var conn *pgx.Conn
+	err := retry.Connect(ctx, cfg.Database.ConnectRetry, logger, "connecting to database for tenant migrations", func(ctx context.Context) (err error) {
+		conn, err = pgx.Connect(ctx, DSN(&cfg.Database))
+		return permanentConnectError(err)
+	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	err = acquireMigrationLock(ctx, conn, logger, cfg.Database.MigrationLockTimeout, cfg.Database.MigrationLockSkip)
+	if errors.Is(err, errMigrationLockHeld) {
+		logger.Warn().Msg("skipping tenant migrations, another instance is running them")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := releaseMigrationLock(context.WithoutCancel(ctx), conn); err != nil {
+			logger.Warn().Err(err).Msg("failed to release migration lock")
+		}
+	}()
+
+	schemas, err := tenantSchemas(ctx, conn, tenancy)
+	if err != nil {
+		return err
+	}
+
+	for _, schema := range schemas {
+		if _, err := conn.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+pgx.Identifier{schema}.Sanitize()); err != nil {
+			return fmt.Errorf("creating tenant schema %s: %w", schema, err)
+		}
+		if err := migrateTenantSchema(ctx, logger, cfg, schema); err != nil {
+			return fmt.Errorf("migrating tenant schema %s: %w", schema, err)
+		}
+	}
+
+	logger.Info().Int("tenants", len(schemas)).Msg("tenant schemas up to date")
+	return nil
+}
+
+// tenantSchemas: configured tenants + schemas already carrying the prefix, sorted
+func tenantSchemas(ctx context.Context, conn *pgx.Conn, tenancy config.TenancyConfig) ([]string, error) {
+	var schemas []string
+	for _, tenant := range tenancy.Tenants {
+		schema, err := TenantSchema(tenancy.SchemaPrefix, tenant)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+
+	rows, err := conn.Query(ctx, "SELECT nspname FROM pg_namespace WHERE starts_with(nspname, $1)", tenancy.SchemaPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("listing tenant schemas: %w", err)
+	}
+	existing, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("listing tenant schemas: %w", err)
+	}
+	for _, schema := range existing {
+		if _, err := TenantSchema(tenancy.SchemaPrefix, schema[len(tenancy.SchemaPrefix):]); err == nil {
+			schemas = append(schemas, schema)
+		}
+	}
+
+	slices.Sort(schemas)
+	return slices.Compact(schemas), nil
+}
+
+// migrateTenantSchema: tern run over a connection whose search_path is the tenant schema
+// the session default matters, tern runs "reset all" after every migration
+func migrateTenantSchema(ctx context.Context, logger *zerolog.Logger, cfg *config.Config, schema string) error {
+	dbCfg := cfg.Database
+	dbCfg.SearchPath = schema
+
+	conn, err := pgx.Connect(ctx, DSN(&dbCfg))
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	// schema is validated by TenantSchema, tern doesn't quote the version table
+	m, err := tern.NewMigrator(ctx, conn, schema+"."+schemaVersionTable)
+	if err != nil {
+		return fmt.Errorf("constructing tenant migrator: %w", err)
+	}
+	subtree, err := fs.Sub(tenantMigrations, "tenant_migrations")
+	if err != nil {
+		return fmt.Errorf("retrieving tenant migrations subtree: %w", err)
+	}
+	if err := m.LoadMigrations(subtree); err != nil {
+		return fmt.Errorf("loading tenant migrations: %w", err)
+	}
+	m.OnStart = func(sequence int32, name, direction, _ string) {
+		logger.Info().
+			Str("schema", schema).
+			Int32("version", sequence).
+			Str("name", name).
+			Str("direction", direction).
+			Msg("running tenant migration")
+	}
+
+	return m.Migrate(ctx)
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+/**
+@dev transactions: begin → fn → commit, rollback on error or panic
+
+db.WithTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error { ... })
+    → 40001 (serialization_failure) / 40P01 (deadlock_detected): the whole fn is run again
+      in a new transaction, up to txMaxAttempts times with exponential backoff + jitter
+    → the transaction is a "database.WithTx" segment of the New Relic transaction of ctx
+
+fn can run more than once, it must not have side effects outside of tx (http calls, emails, ...)
+*/
+
+const (
+	txMaxAttempts = 3
+	txBaseBackoff = 20 * time.Millisecond
+	txMaxBackoff  = 500 * time.Millisecond
+)
+
+// postgres error codes which mean the transaction can succeed when retried
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// WithTx: runs fn in a transaction on the primary, retried on serialization failures and deadlocks
+func (db *Database) WithTx(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	if err := db.checkDraining(); err != nil {
+		return err
+	}
+
+	segment := newrelic.FromContext(ctx).StartSegment("database.WithTx")
+	defer segment.End()
+
+	var err error
+	for attempt := 1; attempt <= txMaxAttempts; attempt++ {
+		err = db.runTx(ctx, opts, fn)
+		if err == nil || !isRetryableTxError(err) || attempt == txMaxAttempts {
+			segment.AddAttribute("attempts", attempt)
+			break
+		}
+
+		backoff := txBackoff(attempt)
+		db.log.Debug().
+			Err(err).
+			Int("attempt", attempt).
+			Dur("backoff", backoff).
+			Msg("retrying transaction")
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+
+	return err
+}
+
+// runTx: a single attempt of WithTx
+func (db *Database) runTx(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) (err error) {
+	tx, err := db.Pool.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			// rollback even when ctx is done, the connection goes back to the pool clean
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+				err = errors.Join(err, fmt.Errorf("failed to rollback transaction: %w", rbErr))
+			}
+		}
+	}()
+
+	if db.setStatementTimeout && db.queryTimeout > 0 {
+		// SET can't take bind parameters, the value is a formatted integer
+		if _, err = tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", db.queryTimeout.Milliseconds())); err != nil {
+			return fmt.Errorf("failed to set statement timeout: %w", err)
+		}
+	}
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isRetryableTxError: serialization failure or deadlock anywhere in the error chain
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected
+}
+
+// txBackoff: exponential backoff with full jitter, so retried transactions don't collide again
+func txBackoff(attempt int) time.Duration {
+	backoff := min(txBaseBackoff<<(attempt-1), txMaxBackoff)
+	return time.Duration(rand.Int64N(int64(backoff))) + time.Millisecond
+}
@@ -0,0 +1,161 @@
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/apierror"
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+)
+
+/**
+@dev conditional requests (RFC 9110 section 13): clients skip unchanged bodies and don't overwrite changes of others
+
+reads, every GET / HEAD with middleware.ETag: tag of the response body, If-None-Match → 304 without body
+handlers which know the version of a resource answer before building the response:
+if etag.NotModified(w, r, etag.Version(user.ID, user.Version), user.UpdatedAt) {
+	return   → 304 written, ETag and Last-Modified set for the 200 as well
+}
+
+writes, optimistic concurrency on PUT / PATCH:
+current := etag.Version(user.ID, user.Version)
+if err := etag.Precondition(r, current, user.UpdatedAt, true); err != nil {
+	apierror.Write(w, r, err)   → 428 without If-Match, 412 when the resource changed since the client read it
+	return
+}
+
+If-Match compares strongly, weak tags never match it: resources written with If-Match are tagged with Strong, JSON or Version
+*/
+
+// Strong: tag of the exact bytes of a representation
+func Strong(body []byte) string {
+	return `"` + hash(body) + `"`
+}
+
+// Weak: tag of a representation which is equivalent to others with the same tag, not byte for byte the same
+func Weak(body []byte) string {
+	return "W/" + Strong(body)
+}
+
+// Version: tag of the parts which identify one version of a resource, e.g. id and version column
+// strong, every version has a single representation
+func Version(parts ...any) string {
+	return `"` + hash(fmt.Appendln(nil, parts...)) + `"`
+}
+
+// JSON: strong tag of v as encoded by httpx.JSON
+func JSON(v any) (string, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("etag of %T: %w", v, err)
+	}
+	// json.Encoder of httpx.JSON ends the body with a newline
+	return Strong(append(body, '\n')), nil
+}
+
+// hash: first 128 bit of sha256, url safe
+func hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// NotModified: sets ETag / Last-Modified and writes 304 when the client has the current version
+// only for GET and HEAD, zero tag or time leave that validator out
+func NotModified(w http.ResponseWriter, r *http.Request, tag string, modified time.Time) bool {
+	if tag != "" {
+		w.Header().Set("ETag", tag)
+	}
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if !Fresh(r.Header, tag, modified) {
+		return false
+	}
+	WriteNotModified(w)
+	return true
+}
+
+// Fresh: If-None-Match (weak comparison) or, without it, If-Modified-Since says the client copy is current
+func Fresh(header http.Header, tag string, modified time.Time) bool {
+	if ifNoneMatch := header.Get("If-None-Match"); ifNoneMatch != "" {
+		return tag != "" && matches(ifNoneMatch, tag, false)
+	}
+	if ifModifiedSince := header.Get("If-Modified-Since"); ifModifiedSince != "" && !modified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		// Last-Modified has second precision, the comparison as well
+		return err == nil && !modified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// WriteNotModified: 304 with the validators and caching headers only, the content headers are dropped
+func WriteNotModified(w http.ResponseWriter) {
+	header := w.Header()
+	for _, name := range []string{"Content-Type", "Content-Length", "Content-Encoding", "Transfer-Encoding"} {
+		header.Del(name)
+	}
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// Precondition: If-Match / If-Unmodified-Since of a write against the current version of the resource
+// required: writes without a precondition are refused with 428, clients have to read before they write
+func Precondition(r *http.Request, current string, modified time.Time, required bool) error {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if current == "" || !matches(ifMatch, current, true) {
+			return PreconditionFailed()
+		}
+		return nil
+	}
+	if ifUnmodifiedSince := r.Header.Get("If-Unmodified-Since"); ifUnmodifiedSince != "" && !modified.IsZero() {
+		since, err := http.ParseTime(ifUnmodifiedSince)
+		if err != nil || modified.Truncate(time.Second).After(since) {
+			return PreconditionFailed()
+		}
+		return nil
+	}
+	if required {
+		return apierror.New(http.StatusPreconditionRequired, apierror.CodePreconditionRequired,
+			"If-Match header with the ETag of the resource is required")
+	}
+	return nil
+}
+
+// PreconditionFailed: 412, the resource changed since the client read it
+func PreconditionFailed() *apierror.Error {
+	err := apierror.New(http.StatusPreconditionFailed, apierror.CodePreconditionFailed,
+		"resource was changed by someone else, reload and retry")
+	err.Details = []httpx.FieldError{{Field: "If-Match", Rule: "etag", Message: "does not match the current version"}}
+	return err
+}
+
+// matches: tag is one of the comma separated list, "*" matches any current representation
+// strong comparison: both tags must be strong and equal, weak comparison ignores the W/ prefix
+func matches(list, tag string, strong bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if strong && strings.HasPrefix(tag, "W/") {
+		return false
+	}
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong {
+			if candidate == tag {
+				return true
+			}
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
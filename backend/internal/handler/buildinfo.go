@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/database"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/anuragShingare30/go-boilerplate/internal/router"
+	"github.com/rs/zerolog"
+)
+
+// @dev build info: which release runs and which schema version it sees, e.g. to check a rollout finished
+
+type BuildInfoHandler struct {
+	db     *database.Database
+	logger *zerolog.Logger
+}
+
+type buildInfoBody struct {
+	Version     string                 `json:"version"`
+	Commit      string                 `json:"commit"`
+	GoVersion   string                 `json:"go_version"`
+	InstanceID  string                 `json:"instance_id"`
+	Schema      *database.SchemaStatus `json:"schema,omitempty"`
+	SchemaError string                 `json:"schema_error,omitempty"`
+}
+
+// BuildInfoDoc: OpenAPI description of GET /version
+var BuildInfoDoc = router.Doc{
+	Summary:  "Build and schema version",
+	Tags:     []string{"meta"},
+	Response: buildInfoBody{},
+}
+
+func NewBuildInfoHandler(db *database.Database, logger *zerolog.Logger) *BuildInfoHandler {
+	return &BuildInfoHandler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Get: GET /version, build metadata and migration status
+func (h *BuildInfoHandler) Get(w http.ResponseWriter, r *http.Request) {
+	metadata := loggerConfig.ProcessMetadata()
+	body := buildInfoBody{
+		Version:    metadata.Version,
+		Commit:     metadata.Commit,
+		GoVersion:  runtime.Version(),
+		InstanceID: metadata.InstanceID,
+	}
+
+	// the build info is still useful while the database is down
+	status, err := h.db.MigrationStatus(r.Context())
+	if err != nil {
+		h.logger.Warn().Err(err).Msg("failed to read migration status")
+		body.SchemaError = "migration status unavailable"
+	} else {
+		body.Schema = &status
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
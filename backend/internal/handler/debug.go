@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/anuragShingare30/go-boilerplate/internal/router"
+)
+
+/**
+@dev debug endpoints for incidents, only registered with server.debug.enabled
+
+/admin/debug/... (admin token) or /debug/... on server.debug.address
+    pprof/                       → index of the profiles
+    pprof/profile?seconds=30     → CPU profile, go tool pprof http://.../pprof/profile
+    pprof/heap, goroutine, ...   → named profiles, ?debug=1 for text
+    pprof/trace?seconds=5        → execution trace, go tool trace
+    vars                         → expvar (cmdline, memstats and whatever the code publishes)
+    runtime                      → JSON snapshot of goroutines, memory and GC
+
+profiles longer than server.write_timeout / request_timeout are cut off on the main port, use the own listener for them
+*/
+
+type DebugHandler struct {
+	started time.Time
+}
+
+type runtimeBody struct {
+	GoVersion  string        `json:"go_version"`
+	Version    string        `json:"version"`
+	InstanceID string        `json:"instance_id"`
+	Uptime     string        `json:"uptime"`
+	NumCPU     int           `json:"num_cpu"`
+	GOMAXPROCS int           `json:"gomaxprocs"`
+	Goroutines int           `json:"goroutines"`
+	CgoCalls   int64         `json:"cgo_calls"`
+	Memory     runtimeMemory `json:"memory"`
+	GC         runtimeGC     `json:"gc"`
+}
+
+type runtimeMemory struct {
+	Alloc        uint64 `json:"alloc_bytes"`
+	TotalAlloc   uint64 `json:"total_alloc_bytes"`
+	Sys          uint64 `json:"sys_bytes"`
+	HeapInuse    uint64 `json:"heap_inuse_bytes"`
+	HeapIdle     uint64 `json:"heap_idle_bytes"`
+	HeapReleased uint64 `json:"heap_released_bytes"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse_bytes"`
+}
+
+type runtimeGC struct {
+	NumGC        uint32    `json:"num_gc"`
+	PauseTotalMs float64   `json:"pause_total_ms"`
+	LastPauseMs  float64   `json:"last_pause_ms"`
+	LastGC       time.Time `json:"last_gc"`
+	NextGCBytes  uint64    `json:"next_gc_bytes"`
+	CPUFraction  float64   `json:"cpu_fraction"`
+}
+
+func NewDebugHandler() *DebugHandler {
+	return &DebugHandler{started: time.Now()}
+}
+
+// Register: adds the debug routes to g, e.g. r.Group("/debug", debugHandler.Register)
+func (h *DebugHandler) Register(g *router.Router) {
+	// the index builds its links relative to the page, they work below any prefix
+	g.Get("/pprof/", pprof.Index)
+	g.Get("/pprof/cmdline", pprof.Cmdline)
+	g.Get("/pprof/profile", pprof.Profile)
+	g.Get("/pprof/symbol", pprof.Symbol)
+	g.Post("/pprof/symbol", pprof.Symbol)
+	g.Get("/pprof/trace", pprof.Trace)
+	// pprof.Index only finds named profiles below /debug/pprof/, here the name comes from the pattern
+	g.Get("/pprof/{name}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(router.Param(r, "name")).ServeHTTP(w, r)
+	})
+
+	g.Handle("GET /vars", expvar.Handler())
+	g.Get("/runtime", h.Runtime)
+}
+
+// Runtime: GET .../debug/runtime, goroutines, memory and GC of the process
+func (h *DebugHandler) Runtime(w http.ResponseWriter, _ *http.Request) {
+	// stops the world for a moment, fine for an endpoint called by hand
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	metadata := loggerConfig.ProcessMetadata()
+	body := runtimeBody{
+		GoVersion:  runtime.Version(),
+		Version:    metadata.Version,
+		InstanceID: metadata.InstanceID,
+		Uptime:     time.Since(h.started).Round(time.Second).String(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+		CgoCalls:   runtime.NumCgoCall(),
+		Memory: runtimeMemory{
+			Alloc:        mem.Alloc,
+			TotalAlloc:   mem.TotalAlloc,
+			Sys:          mem.Sys,
+			HeapInuse:    mem.HeapInuse,
+			HeapIdle:     mem.HeapIdle,
+			HeapReleased: mem.HeapReleased,
+			HeapObjects:  mem.HeapObjects,
+			StackInuse:   mem.StackInuse,
+		},
+		GC: runtimeGC{
+			NumGC:        mem.NumGC,
+			PauseTotalMs: float64(mem.PauseTotalNs) / float64(time.Millisecond),
+			NextGCBytes:  mem.NextGC,
+			CPUFraction:  mem.GCCPUFraction,
+		},
+	}
+	if mem.NumGC > 0 {
+		body.GC.LastPauseMs = float64(mem.PauseNs[(mem.NumGC+255)%256]) / float64(time.Millisecond)
+		body.GC.LastGC = time.Unix(0, int64(mem.LastGC))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/health"
+)
+
+// @dev health endpoints for the orchestrator and load balancers
+// @dev /livez: the process serves requests, no dependency is checked, a failing database must not get the pod restarted
+// @dev /readyz: the configured checks (health.Checker), 503 while one fails or the server shuts down
+// @dev /healthz: same report as /readyz, for load balancers and monitors expecting the classic path
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+type livenessBody struct {
+	Status string `json:"status"`
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Live: GET /livez, always 200 while the server answers
+func (h *HealthHandler) Live(w http.ResponseWriter, _ *http.Request) {
+	writeHealth(w, http.StatusOK, livenessBody{Status: health.StatusOK})
+}
+
+// Ready: GET /readyz and GET /healthz, per check results with latencies
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Report(r.Context())
+
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(w, status, report)
+}
+
+func writeHealth(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	// probes must see the current state, not a cached one of a proxy
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/apierror"
+	"github.com/anuragShingare30/go-boilerplate/internal/audit"
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/rs/zerolog"
+)
+
+// @dev admin handler to read and change the log level of the running process
+
+type LogLevelHandler struct {
+	loggerService *loggerConfig.LoggerService
+	logger        *zerolog.Logger
+}
+
+type logLevelBody struct {
+	Level string `json:"level" validate:"required"`
+}
+
+func NewLogLevelHandler(loggerService *loggerConfig.LoggerService, logger *zerolog.Logger) *LogLevelHandler {
+	return &LogLevelHandler{
+		loggerService: loggerService,
+		logger:        logger,
+	}
+}
+
+// Get: GET /admin/loglevel, returns the current level
+func (h *LogLevelHandler) Get(w http.ResponseWriter, _ *http.Request) {
+	writeLogLevel(w, h.loggerService.Level().Level())
+}
+
+// Put: PUT /admin/loglevel with {"level": "debug"}, changes the level of all loggers
+func (h *LogLevelHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var body logLevelBody
+	if err := httpx.Bind(r, &body); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	level, err := loggerConfig.ParseLevel(body.Level)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation(httpx.FieldError{Field: "level", Rule: "level", Message: err.Error()}))
+		return
+	}
+
+	previous := h.loggerService.Level().Level()
+	h.loggerService.Level().Set(level)
+	h.logger.Info().
+		Str("from", previous.String()).
+		Str("to", level.String()).
+		Msg("log level changed via admin endpoint")
+
+	_ = audit.Event(r.Context()).
+		Actor("admin").
+		Action("loglevel.update").
+		Resource("loglevel").
+		Meta("from", previous.String()).
+		Meta("to", level.String()).
+		Emit()
+
+	writeLogLevel(w, level)
+}
+
+func writeLogLevel(w http.ResponseWriter, level zerolog.Level) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(logLevelBody{Level: level.String()})
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/apierror"
+	"github.com/anuragShingare30/go-boilerplate/internal/audit"
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+	"github.com/anuragShingare30/go-boilerplate/internal/maintenance"
+	"github.com/rs/zerolog"
+)
+
+// @dev admin handler to put the API into maintenance and take it out again, for every replica at once
+
+type MaintenanceHandler struct {
+	sw     *maintenance.Switch
+	logger *zerolog.Logger
+}
+
+type maintenanceBody struct {
+	Message string `json:"message"`
+	// "30m", empty until DELETE
+	Duration string `json:"duration"`
+}
+
+func NewMaintenanceHandler(sw *maintenance.Switch, logger *zerolog.Logger) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		sw:     sw,
+		logger: logger,
+	}
+}
+
+// Get: GET /admin/maintenance, returns the current state
+func (h *MaintenanceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	state, err := h.sw.State(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, state)
+}
+
+// Put: PUT /admin/maintenance with {"message": "database upgrade", "duration": "30m"}, turns maintenance on
+func (h *MaintenanceHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var body maintenanceBody
+	if err := httpx.Bind(r, &body); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	var duration time.Duration
+	if body.Duration != "" {
+		var err error
+		duration, err = time.ParseDuration(body.Duration)
+		if err != nil || duration <= 0 {
+			apierror.Write(w, r, apierror.Validation(httpx.FieldError{Field: "duration", Rule: "duration", Message: "must be a positive duration like 30m"}))
+			return
+		}
+	}
+
+	state, err := h.sw.Enable(r.Context(), body.Message, duration)
+	if err != nil {
+		apierror.Write(w, r, noKey(err))
+		return
+	}
+	h.logger.Warn().
+		Str("message", body.Message).
+		Dur("duration", duration).
+		Msg("maintenance mode enabled via admin endpoint")
+
+	_ = audit.Event(r.Context()).
+		Actor("admin").
+		Action("maintenance.enable").
+		Resource("maintenance").
+		Meta("message", body.Message).
+		Meta("duration", duration.String()).
+		Emit()
+
+	httpx.JSON(w, http.StatusOK, state)
+}
+
+// Delete: DELETE /admin/maintenance, turns the maintenance of the redis key off
+func (h *MaintenanceHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.sw.Disable(r.Context()); err != nil {
+		apierror.Write(w, r, noKey(err))
+		return
+	}
+	h.logger.Warn().Msg("maintenance mode disabled via admin endpoint")
+
+	_ = audit.Event(r.Context()).
+		Actor("admin").
+		Action("maintenance.disable").
+		Resource("maintenance").
+		Emit()
+
+	state, err := h.sw.State(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	httpx.JSON(w, http.StatusOK, state)
+}
+
+// noKey: without a redis key the switch is the config flag, there is nothing to change at runtime
+func noKey(err error) error {
+	if errors.Is(err, maintenance.ErrNoKey) {
+		return apierror.Conflict("maintenance is switched by server.maintenance.enabled only, no redis key is configured").Wrap(err)
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/database"
+	"github.com/anuragShingare30/go-boilerplate/internal/redis"
+)
+
+// @dev checks of the built in dependencies, registered as "db" and "redis" in main
+
+// Database: db.HealthCheck, unhealthy replicas are reported in the details without failing the check
+func Database(db *database.Database) CheckFunc {
+	return func(ctx context.Context) (map[string]any, error) {
+		health, err := db.HealthCheck(ctx)
+		details := map[string]any{
+			"pool_utilization": health.Pool.Utilization,
+			"acquired_conns":   health.Pool.AcquiredConns,
+			"max_conns":        health.Pool.MaxConns,
+		}
+		if health.ServerVersion != "" {
+			details["server_version"] = health.ServerVersion
+		}
+		if len(health.Replicas) > 0 {
+			replicas := make([]map[string]any, 0, len(health.Replicas))
+			for _, replica := range health.Replicas {
+				replicas = append(replicas, map[string]any{
+					"host":    replica.Host,
+					"healthy": replica.Healthy,
+					"lag_ms":  replica.Lag.Milliseconds(),
+					"error":   replica.Error,
+				})
+			}
+			details["replicas"] = replicas
+		}
+		return details, err
+	}
+}
+
+// Redis: client.HealthCheck, PING round trip and pool stats
+func Redis(client *redis.Client) CheckFunc {
+	return func(ctx context.Context) (map[string]any, error) {
+		health, err := client.HealthCheck(ctx)
+		details := map[string]any{
+			"total_conns": health.Pool.TotalConns,
+			"idle_conns":  health.Pool.IdleConns,
+			"timeouts":    health.Pool.Timeouts,
+		}
+		if health.ServerVersion != "" {
+			details["server_version"] = health.ServerVersion
+		}
+		return details, err
+	}
+}
@@ -0,0 +1,242 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev health checks: the checks of observability.health_checks.checks, for the readiness endpoint
+
+checker := health.New(cfg.Observability.HealthChecks, &log)
+checker.Register("db", health.Database(db))
+checker.Register("payments", func(ctx context.Context) (map[string]any, error) { ... })
+checker.Start(ctx)
+    → every interval: each configured check in its own goroutine, limited by timeout
+        → a check changing state is logged (warn when it fails, info when it recovers)
+    → Report(ctx): the last report, run again when it is older than interval
+    → Shutdown(): the report says shutting_down, the load balancer stops sending traffic before the server closes
+
+checks are selected by config, registered names which are not listed don't run
+listed names without a registered check fail, a typo doesn't hide a dependency
+*/
+
+const (
+	StatusOK           = "ok"
+	StatusError        = "error"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc: checks one dependency, details end up in the report (versions, pool usage, ...)
+type CheckFunc func(ctx context.Context) (map[string]any, error)
+
+// Report: result of every configured check
+type Report struct {
+	Status    string                 `json:"status"`
+	Checks    map[string]CheckResult `json:"checks,omitempty"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
+
+// CheckResult: result of one check
+type CheckResult struct {
+	Status    string         `json:"status"`
+	LatencyMs float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// Checker: runs the registered checks and keeps the last report
+type Checker struct {
+	cfg config.HealthChecksConfig
+	log *zerolog.Logger
+
+	mu     sync.RWMutex
+	checks map[string]CheckFunc
+	last   *Report
+
+	// one run at a time, concurrent probes wait for it and share the report
+	runMu        sync.Mutex
+	shuttingDown atomic.Bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New: checker of observability.health_checks, checks are added with Register
+func New(cfg config.HealthChecksConfig, logger *zerolog.Logger) *Checker {
+	return &Checker{
+		cfg:    cfg,
+		log:    logger,
+		checks: make(map[string]CheckFunc),
+	}
+}
+
+// Register: adds check under name, it only runs when name is in observability.health_checks.checks
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Start: refreshes the report every interval until ctx is done or Close is called, nothing to do when disabled
+func (c *Checker) Start(ctx context.Context) {
+	if !c.cfg.Enabled {
+		return
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			c.refresh(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Close: stops the background checks
+func (c *Checker) Close() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+// Shutdown: reports shutting_down from now on, call it when the graceful shutdown starts
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+// Report: the last report, checks run again when it is older than interval
+func (c *Checker) Report(ctx context.Context) Report {
+	report := Report{Status: StatusOK, CheckedAt: time.Now()}
+	if c.cfg.Enabled {
+		report = c.current(ctx)
+	}
+
+	if c.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+	}
+	return report
+}
+
+// current: last report when fresh, otherwise a new run
+func (c *Checker) current(ctx context.Context) Report {
+	c.mu.RLock()
+	last := c.last
+	c.mu.RUnlock()
+	if last != nil && time.Since(last.CheckedAt) < c.cfg.Interval {
+		return *last
+	}
+	// the report is shared, a probe giving up early must not cancel the checks of the others
+	return c.refresh(context.WithoutCancel(ctx))
+}
+
+// refresh: runs the checks unless a concurrent run finished meanwhile
+func (c *Checker) refresh(ctx context.Context) Report {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	c.mu.RLock()
+	previous := c.last
+	c.mu.RUnlock()
+	// another caller ran the checks while this one waited
+	if previous != nil && time.Since(previous.CheckedAt) < c.cfg.Interval/2 {
+		return *previous
+	}
+
+	report := c.run(ctx)
+	c.logChanges(previous, &report)
+
+	c.mu.Lock()
+	c.last = &report
+	c.mu.Unlock()
+	return report
+}
+
+// run: every configured check concurrently, each with its own timeout
+func (c *Checker) run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := make(map[string]CheckFunc, len(c.cfg.Checks))
+	for _, name := range c.cfg.Checks {
+		checks[name] = c.checks[name]
+	}
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks)), CheckedAt: time.Now()}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := c.runCheck(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusError
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// runCheck: one check limited by the timeout, a panicking check fails instead of taking the probe down
+func (c *Checker) runCheck(ctx context.Context, check CheckFunc) (result CheckResult) {
+	if check == nil {
+		return CheckResult{Status: StatusError, Error: "no check registered under this name"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	defer func() {
+		if p := recover(); p != nil {
+			result = CheckResult{Status: StatusError, Error: fmt.Sprintf("check panicked: %v", p)}
+		}
+		result.LatencyMs = float64(time.Since(started).Microseconds()) / 1000
+	}()
+
+	details, err := check(ctx)
+	if err != nil {
+		return CheckResult{Status: StatusError, Error: err.Error(), Details: details}
+	}
+	return CheckResult{Status: StatusOK, Details: details}
+}
+
+// logChanges: logs checks which failed or recovered since the previous report
+func (c *Checker) logChanges(previous, report *Report) {
+	for name, result := range report.Checks {
+		wasOK := true
+		if previous != nil {
+			if last, ok := previous.Checks[name]; ok {
+				wasOK = last.Status == StatusOK
+			}
+		}
+		switch {
+		case result.Status != StatusOK && wasOK:
+			c.log.Warn().Str("check", name).Str("error", result.Error).Msg("health check failed")
+		case result.Status == StatusOK && !wasOK:
+			c.log.Info().Str("check", name).Msg("health check recovered")
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package httpx
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+@dev request binding: one call instead of decoding, parsing and checking by hand in every handler
+
+type CreateUserRequest struct {
+	OrgID  string   `path:"org"`                             → r.PathValue("org")
+	DryRun bool     `query:"dry_run"`                        → ?dry_run=true
+	Tags   []string `query:"tag"`                            → ?tag=a&tag=b or ?tag=a,b
+	Email  string   `json:"email" validate:"required,email"` → JSON body
+}
+
+var req CreateUserRequest
+if err := httpx.Bind(r, &req); err != nil {
+	httpx.Error(w, r, err)   → 400 {"error": {"code": "validation_failed", "details": [{"field": "email", ...}]}}
+	return
+}
+
+order: path → query → body → validate, the body may not set a field twice (unknown json fields are refused)
+the same tags describe the request in the OpenAPI document (internal/openapi)
+*/
+
+// BindError: request which can't be decoded or doesn't validate
+type BindError struct {
+	Status  int // 400, 413 for bodies over the body limit, 415 for other content types
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error // decoding error, nil for validation failures
+}
+
+func (e *BindError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// Bind: fills dst (pointer to struct) from path values, query and JSON body of r, then validates it
+func Bind(r *http.Request, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("httpx.Bind needs a pointer to a struct, got %T", dst)
+	}
+
+	if fields := bindParams(v.Elem(), r); len(fields) > 0 {
+		return &BindError{Status: http.StatusBadRequest, Code: "invalid_parameters", Message: "invalid request parameters", Fields: fields}
+	}
+	if err := decodeBody(r, dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+// decodeBody: JSON body into dst, empty bodies are skipped so validation reports the missing fields
+func decodeBody(r *http.Request, dst any) error {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil
+	}
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			return &BindError{
+				Status:  http.StatusUnsupportedMediaType,
+				Code:    "unsupported_media_type",
+				Message: "request body must be application/json",
+			}
+		}
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return bodyError(err)
+	}
+	// a second value after the object is a broken client, not something to ignore
+	if decoder.More() {
+		return &BindError{Status: http.StatusBadRequest, Code: "invalid_body", Message: "request body must be a single JSON value"}
+	}
+	return nil
+}
+
+// bodyError: json decoding errors as messages a client can act on
+func bodyError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &BindError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    "body_too_large",
+			Message: "request body larger than " + strconv.FormatInt(maxBytesErr.Limit, 10) + " bytes",
+			Err:     err,
+		}
+	case errors.As(err, &syntaxErr):
+		return &BindError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_body",
+			Message: "malformed JSON at offset " + strconv.FormatInt(syntaxErr.Offset, 10),
+			Err:     err,
+		}
+	case errors.As(err, &typeErr):
+		return &BindError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_body",
+			Message: "request body has a field of the wrong type",
+			Fields:  []FieldError{{Field: typeErr.Field, Rule: "type", Message: "must be " + jsonTypeName(typeErr.Type)}},
+			Err:     err,
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &BindError{Status: http.StatusBadRequest, Code: "invalid_body", Message: "request body is cut off", Err: err}
+	}
+
+	// unknown fields only come as plain errors: json: unknown field "x"
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &BindError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_body",
+			Message: "request body has an unknown field",
+			Fields:  []FieldError{{Field: strings.Trim(field, `"`), Rule: "unknown", Message: "is not a field of this request"}},
+			Err:     err,
+		}
+	}
+	return &BindError{Status: http.StatusBadRequest, Code: "invalid_body", Message: "invalid request body", Err: err}
+}
+
+// bindParams: path and query tagged fields, every value which doesn't parse is reported
+func bindParams(v reflect.Value, r *http.Request) []FieldError {
+	var fields []FieldError
+	query := r.URL.Query()
+	t := v.Type()
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if name, ok := field.Tag.Lookup("path"); ok && name != "" && name != "-" {
+			if value := r.PathValue(name); value != "" {
+				if err := setValue(v.Field(i), []string{value}); err != nil {
+					fields = append(fields, FieldError{Field: name, Rule: "type", Message: err.Error()})
+				}
+			}
+		}
+
+		if name, ok := field.Tag.Lookup("query"); ok && name != "" && name != "-" {
+			if values, ok := query[name]; ok {
+				if err := setValue(v.Field(i), values); err != nil {
+					fields = append(fields, FieldError{Field: name, Rule: "type", Message: err.Error()})
+				}
+			}
+		}
+	}
+	return fields
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	durationType      = reflect.TypeFor[time.Duration]()
+	textUnmarshalerTy = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// setValue: parses the string values into field, slices take repeated values or one comma separated value
+func setValue(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return setValue(field.Elem(), values)
+	}
+
+	if field.Kind() == reflect.Slice && !field.Addr().Type().Implements(textUnmarshalerTy) {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), []string{strings.TrimSpace(value)}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	return setString(field, values[len(values)-1])
+}
+
+// setString: one value by the kind of field
+func setString(field reflect.Value, value string) error {
+	if field.Addr().Type().Implements(textUnmarshalerTy) && field.Type() != timeType {
+		if err := field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("is not a valid value")
+		}
+		return nil
+	}
+
+	switch {
+	case field.Type() == timeType:
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("must be an RFC 3339 time")
+		}
+		field.Set(reflect.ValueOf(parsed))
+		return nil
+	case field.Type() == durationType:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration like 30s")
+		}
+		field.SetInt(int64(parsed))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a positive integer")
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		field.SetFloat(parsed)
+	default:
+		return fmt.Errorf("can't be set from a parameter")
+	}
+	return nil
+}
+
+// jsonTypeName: the json word for the Go type a value should have had
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,176 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+/**
+@dev content negotiation: one handler, the body in the format the client asks for
+
+httpx.Render(w, r, http.StatusOK, user)
+    Accept: application/json, a wildcard, none → JSON (the first encoder of SetEncoders)
+    Accept: application/msgpack                → msgpack, the json tags name the fields
+    Accept: application/xml;q=0.9, text/html   → XML, falls back to JSON for values XML can't hold (maps)
+    Accept: image/png                          → JSON all the same, an API rather answers than 406s
+
+server.negotiation.formats picks the encoders, SetEncoders takes custom ones (CBOR, protobuf, ...) as well
+the error envelope of apierror.Write is negotiated the same way
+*/
+
+// Encoder: one response format
+type Encoder interface {
+	// MediaTypes: the types of the Accept header it answers, the first one is the Content-Type
+	MediaTypes() []string
+	Encode(w io.Writer, v any) error
+}
+
+// JSONEncoder: application/json, the format of httpx.JSON
+type JSONEncoder struct{}
+
+func (JSONEncoder) MediaTypes() []string { return []string{"application/json"} }
+
+func (JSONEncoder) Encode(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }
+
+// MsgpackEncoder: application/msgpack with the field names and omitempty of the json tags
+type MsgpackEncoder struct{}
+
+func (MsgpackEncoder) MediaTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+}
+
+func (MsgpackEncoder) Encode(w io.Writer, v any) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	return encoder.Encode(v)
+}
+
+// XMLEncoder: application/xml of the xml tags, maps are not supported by encoding/xml
+type XMLEncoder struct{}
+
+func (XMLEncoder) MediaTypes() []string { return []string{"application/xml", "text/xml"} }
+
+func (XMLEncoder) Encode(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// NamedEncoder: encoder of a server.negotiation.formats entry, nil for unknown names
+func NamedEncoder(name string) Encoder {
+	switch name {
+	case "json":
+		return JSONEncoder{}
+	case "msgpack":
+		return MsgpackEncoder{}
+	case "xml":
+		return XMLEncoder{}
+	}
+	return nil
+}
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = []Encoder{JSONEncoder{}}
+)
+
+// SetEncoders: the formats Render negotiates, the first one is the default, JSON only until called
+func SetEncoders(list ...Encoder) {
+	if len(list) == 0 {
+		list = []Encoder{JSONEncoder{}}
+	}
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders = list
+}
+
+// Render: v with status in the format of the Accept header of r
+func Render(w http.ResponseWriter, r *http.Request, status int, v any) {
+	encodersMu.RLock()
+	list := encoders
+	encodersMu.RUnlock()
+
+	encoder, contentType := negotiate(r.Header.Get("Accept"), list)
+	// caches key the response by Accept as well
+	if len(list) > 1 {
+		w.Header().Add("Vary", "Accept")
+	}
+
+	var body bytes.Buffer
+	if err := encoder.Encode(&body, v); err != nil {
+		fallback := list[0]
+		if encoder.MediaTypes()[0] == fallback.MediaTypes()[0] {
+			loggerConfig.Err(r.Context(), err).Msg("response encoding failed")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		loggerConfig.Err(r.Context(), err).Str("content_type", contentType).Msg("response encoding failed, default format used")
+		body.Reset()
+		if err := fallback.Encode(&body, v); err != nil {
+			loggerConfig.Err(r.Context(), err).Msg("response encoding failed")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		contentType = fallback.MediaTypes()[0]
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(body.Bytes())
+}
+
+// negotiate: encoder of the highest q value in accept, the first of list without a match
+// ties go to the earlier entry of accept, wildcards match the earlier encoders first
+func negotiate(accept string, list []Encoder) (Encoder, string) {
+	best, bestType, bestQ := list[0], list[0].MediaTypes()[0], -1.0
+	if accept == "" {
+		return best, bestType
+	}
+
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 || q <= bestQ {
+			continue
+		}
+		if encoder, contentType, ok := match(mediaType, list); ok {
+			best, bestType, bestQ = encoder, contentType, q
+		}
+	}
+	return best, bestType
+}
+
+// match: encoder for one media range of Accept, the wildcards "*/*" and "application/*" included
+func match(mediaRange string, list []Encoder) (Encoder, string, bool) {
+	prefix, wildcard := strings.CutSuffix(mediaRange, "*")
+	if mediaRange == "*/*" {
+		prefix = ""
+	}
+	for _, encoder := range list {
+		for _, mediaType := range encoder.MediaTypes() {
+			if mediaType == mediaRange || (wildcard && strings.HasPrefix(mediaType, prefix)) {
+				return encoder, mediaType, true
+			}
+		}
+	}
+	return nil, "", false
+}
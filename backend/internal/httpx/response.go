@@ -0,0 +1,63 @@
+package httpx
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+)
+
+// @dev responses: JSON bodies and the error envelope every endpoint and middleware answers with
+// @dev {"error": {"code": "validation_failed", "message": "...", "request_id": "...", "details": [{"field": "email", ...}]}}
+// @dev the envelope is rendered in the negotiated format (render.go), <response><error><code>... in XML
+
+// ErrorBody: the error envelope
+type ErrorBody struct {
+	XMLName xml.Name    `json:"-" xml:"response"`
+	Error   ErrorDetail `json:"error" xml:"error"`
+}
+
+type ErrorDetail struct {
+	Code      string       `json:"code" xml:"code"`
+	Message   string       `json:"message" xml:"message"`
+	RequestID string       `json:"request_id,omitempty" xml:"request_id,omitempty"`
+	Details   []FieldError `json:"details,omitempty" xml:"details>detail,omitempty"`
+}
+
+// FieldError: one invalid field of the request, field is the json / query / path name
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`
+	Rule    string `json:"rule" xml:"rule"`
+	Message string `json:"message" xml:"message"`
+}
+
+// JSON: v as JSON body with status
+func JSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// WriteError: the error envelope with status, the request id of r is filled in
+func WriteError(w http.ResponseWriter, r *http.Request, status int, detail ErrorDetail) {
+	if detail.RequestID == "" {
+		detail.RequestID = loggerConfig.RequestIDFromContext(r.Context())
+	}
+	Render(w, r, status, ErrorBody{Error: detail})
+}
+
+// Error: err as error envelope, a *BindError with its status and field details, anything else as 500
+// the cause of a 500 is logged, the client only gets a generic message
+// handlers use apierror.Write, which maps the errors of the other packages as well
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	var bindErr *BindError
+	if errors.As(err, &bindErr) {
+		WriteError(w, r, bindErr.Status, ErrorDetail{Code: bindErr.Code, Message: bindErr.Message, Details: bindErr.Fields})
+		return
+	}
+
+	loggerConfig.Err(r.Context(), err).Str("path", r.URL.Path).Msg("request failed")
+	WriteError(w, r, http.StatusInternalServerError, ErrorDetail{Code: "internal_error", Message: "internal server error"})
+}
@@ -0,0 +1,122 @@
+package httpx
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// @dev validation: go-playground/validator with the field names clients know (json / query / path tag)
+// @dev every failing field is reported at once, "items[2].name" for nested ones
+
+var validate = newValidate()
+
+func newValidate() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query", "path"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	return v
+}
+
+// RegisterValidation: adds a rule for the validate tags of request structs, call it at startup
+func RegisterValidation(tag string, fn validator.Func) error {
+	return validate.RegisterValidation(tag, fn)
+}
+
+// Validate: runs the validate tags of v, a *BindError with every failing field
+func Validate(v any) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		// not a struct or a broken rule, a bug and not the client's fault
+		return err
+	}
+
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, FieldError{
+			Field:   fieldName(fieldErr.Namespace()),
+			Rule:    fieldErr.Tag(),
+			Message: ruleMessage(fieldErr),
+		})
+	}
+	return &BindError{
+		Status:  http.StatusBadRequest,
+		Code:    "validation_failed",
+		Message: "request validation failed",
+		Fields:  fields,
+	}
+}
+
+// fieldName: namespace without the struct name, "CreateUserRequest.address.city" → "address.city"
+func fieldName(namespace string) string {
+	_, name, found := strings.Cut(namespace, ".")
+	if !found {
+		return namespace
+	}
+	return name
+}
+
+// ruleMessage: what the client has to change, by rule
+func ruleMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	// min / max count characters and items for strings and collections
+	unit := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Map:
+		unit = " items"
+	}
+	sized := unit != ""
+
+	switch fieldErr.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(param), ", ")
+	case "min", "gte":
+		if sized {
+			return "must have at least " + param + unit
+		}
+		return "must be at least " + param
+	case "max", "lte":
+		if sized {
+			return "must have at most " + param + unit
+		}
+		return "must be at most " + param
+	case "gt":
+		return "must be greater than " + param
+	case "lt":
+		return "must be less than " + param
+	case "len":
+		return "must have exactly " + param + unit
+	case "email":
+		return "must be a valid email address"
+	case "url", "uri", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "eqfield":
+		return "must be equal to " + param
+	default:
+		return "failed the " + fieldErr.Tag() + " rule"
+	}
+}
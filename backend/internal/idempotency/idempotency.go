@@ -0,0 +1,116 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+/**
+@dev idempotency keys: the first request with a key runs, retries with the same key get its stored response
+
+Idempotency-Key: 8e03978e-40d5-43e8-bc93-6894a57f9324
+    → Reserve: SET NX of a pending record (lock_ttl), only one replica runs the request
+    → Complete: the response replaces it for ttl
+    → retry while pending: 409, retry after completion: the stored response again
+    → same key, other request (method, path or body differ): 422, keys can't be reused
+
+5xx responses and panics Release the key, the request failed and a retry may run it again
+*/
+
+// Record: state of one key, Status 0 while the first request still runs
+type Record struct {
+	Fingerprint string      `json:"fingerprint"` // hash of method, path and body of the first request
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Pending: the first request of the key hasn't finished
+func (r Record) Pending() bool {
+	return r.Status == 0
+}
+
+// Store: keeps the records, shared by the replicas
+type Store interface {
+	// Reserve: claims key for fingerprint, false with the existing record when the key is taken
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (Record, bool, error)
+	// Complete: stores the response of the reserved key
+	Complete(ctx context.Context, key string, record Record, ttl time.Duration) error
+	// Release: frees a reserved key which has no response, the next request with it runs again
+	Release(ctx context.Context, key, fingerprint string) error
+}
+
+// reserveScript: SET NX of the pending record, the existing record when the key is taken
+var reserveScript = goredis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return false
+end
+return redis.call("GET", KEYS[1])
+`)
+
+// releaseScript: DEL while the key holds the pending record of the caller, a stored response stays
+var releaseScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisStore: records as JSON below "idempotency:"
+type RedisStore struct {
+	client goredis.UniversalClient
+}
+
+func NewRedis(client goredis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func redisKey(key string) string {
+	return "idempotency:" + key
+}
+
+// pendingValue: the stored value of a reservation, compared by Release
+func pendingValue(fingerprint string) string {
+	return `{"fingerprint":"` + fingerprint + `","pending":true}`
+}
+
+func (s *RedisStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (Record, bool, error) {
+	existing, err := reserveScript.Run(ctx, s.client, []string{redisKey(key)}, pendingValue(fingerprint), ttl.Milliseconds()).Text()
+	if errors.Is(err, goredis.Nil) {
+		return Record{}, true, nil
+	}
+	if err != nil {
+		return Record{}, false, fmt.Errorf("idempotency: reserve %s: %w", key, err)
+	}
+
+	var record Record
+	if err := json.Unmarshal([]byte(existing), &record); err != nil {
+		return Record{}, false, fmt.Errorf("idempotency: decode record %s: %w", key, err)
+	}
+	return record, false, nil
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("idempotency: encode record %s: %w", key, err)
+	}
+	if err := s.client.Set(ctx, redisKey(key), body, ttl).Err(); err != nil {
+		return fmt.Errorf("idempotency: complete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, key, fingerprint string) error {
+	if err := releaseScript.Run(ctx, s.client, []string{redisKey(key)}, pendingValue(fingerprint)).Err(); err != nil {
+		return fmt.Errorf("idempotency: release %s: %w", key, err)
+	}
+	return nil
+}
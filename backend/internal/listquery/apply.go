@@ -0,0 +1,64 @@
+package listquery
+
+import (
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Apply: filters, order, limit and offset of q on query
+func (q Query) Apply(query sq.SelectBuilder) sq.SelectBuilder {
+	query = q.OrderBy(q.Where(query))
+	query = query.Limit(uint64(q.Limit))
+	if offset := q.Offset(); offset > 0 {
+		query = query.Offset(uint64(offset))
+	}
+	return query
+}
+
+// Where: the filters of q as WHERE conditions, values are bind parameters
+func (q Query) Where(query sq.SelectBuilder) sq.SelectBuilder {
+	for _, filter := range q.Filters {
+		query = query.Where(filter.condition())
+	}
+	return query
+}
+
+// OrderBy: the sort of q, the fields were checked against Spec.Sorts by Parse
+func (q Query) OrderBy(query sq.SelectBuilder) sq.SelectBuilder {
+	for _, sort := range q.Sort {
+		direction := " ASC"
+		if sort.Desc {
+			direction = " DESC"
+		}
+		query = query.OrderBy(sort.Field + direction)
+	}
+	return query
+}
+
+func (f Filter) condition() sq.Sqlizer {
+	value := f.Values[0]
+	switch f.Op {
+	case Ne:
+		return sq.NotEq{f.Field: value}
+	case In:
+		return sq.Eq{f.Field: f.Values}
+	case Nin:
+		return sq.NotEq{f.Field: f.Values}
+	case Gt:
+		return sq.Gt{f.Field: value}
+	case Gte:
+		return sq.GtOrEq{f.Field: value}
+	case Lt:
+		return sq.Lt{f.Field: value}
+	case Lte:
+		return sq.LtOrEq{f.Field: value}
+	case Contains:
+		return sq.ILike{f.Field: "%" + likeEscaper.Replace(value) + "%"}
+	default:
+		return sq.Eq{f.Field: value}
+	}
+}
+
+// likeEscaper: % and _ of the value match themselves, not any characters
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
@@ -0,0 +1,219 @@
+package listquery
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/database/pagination"
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+)
+
+/**
+@dev list endpoints: paging, sorting and filtering from the query string, only what the spec allows reaches the SQL
+
+var usersList = listquery.Spec{
+	Sorts:       []string{"created_at", "email", "name"},
+	DefaultSort: "-created_at",
+	Filters: map[string][]listquery.Op{
+		"status":     {listquery.Eq, listquery.In},
+		"created_at": {listquery.Gte, listquery.Lt},
+		"email":      {listquery.Contains},
+	},
+}
+
+GET /users?page=2&limit=50&sort=-created_at,name&status=in:active,pending&created_at=gte:2025-01-01
+q, err := usersList.Parse(r)   → *httpx.BindError (400) for unknown sorts, operators and bad numbers
+    → offset:  users, err := repository.ListQuery[User](ctx, db, q.Apply(repository.Builder.Select("*").From("users")))
+    → keyset:  sql, args, _ := q.Where(builder).ToSql(); page, err := keyset.Fetch(ctx, db, sql, args, q.Pagination())
+r.Get("/users", h.List).Describe(router.Doc{Request: usersList, ...})   → the parameters in the OpenAPI document
+
+filters: ?field=value is eq, ?field=op:value otherwise, repeated params are ANDed (?created_at=gte:a&created_at=lt:b)
+*/
+
+// Op: filter operator, the prefix before the colon of a filter value
+type Op string
+
+const (
+	Eq       Op = "eq"
+	Ne       Op = "ne"
+	In       Op = "in"  // comma separated values
+	Nin      Op = "nin" // not in, comma separated values
+	Gt       Op = "gt"
+	Gte      Op = "gte"
+	Lt       Op = "lt"
+	Lte      Op = "lte"
+	Contains Op = "contains" // case insensitive substring
+)
+
+var operators = []Op{Eq, Ne, In, Nin, Gt, Gte, Lt, Lte, Contains}
+
+// reserved: parameters of paging and sorting, they can't be filters
+var reserved = []string{"page", "limit", "cursor", "sort"}
+
+// Spec: what a list endpoint accepts, names are column names
+type Spec struct {
+	Sorts       []string
+	DefaultSort string // used without ?sort, same syntax, e.g. "-created_at,id"
+	Filters     map[string][]Op
+	// DefaultLimit / MaxLimit: 0 takes the ones of the pagination package
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Query: parsed list parameters
+type Query struct {
+	Page    int // 1 based, 1 with a cursor as well
+	Limit   int
+	Cursor  string // keyset cursor (pagination.Page.Next), excludes page
+	Sort    []Sort
+	Filters []Filter
+}
+
+// Sort: one ORDER BY column
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Filter: one WHERE condition, Values has one value except for in / nin
+type Filter struct {
+	Field  string
+	Op     Op
+	Values []string
+}
+
+// Parse: list parameters of r, everything not allowed by s is a 400 with the offending parameters
+func (s Spec) Parse(r *http.Request) (Query, error) {
+	return s.ParseValues(r.URL.Query())
+}
+
+// ParseValues: Parse of an already parsed query string
+func (s Spec) ParseValues(values url.Values) (Query, error) {
+	var fields []httpx.FieldError
+	invalid := func(field, rule, message string) {
+		fields = append(fields, httpx.FieldError{Field: field, Rule: rule, Message: message})
+	}
+
+	q := Query{Page: 1, Limit: s.defaultLimit(), Cursor: values.Get("cursor")}
+
+	if raw := values.Get("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		switch {
+		case err != nil || page < 1:
+			invalid("page", "min", "must be an integer of at least 1")
+		case q.Cursor != "" && page != 1:
+			invalid("page", "excluded_with", "can't be combined with cursor")
+		default:
+			q.Page = page
+		}
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > s.maxLimit() {
+			invalid("limit", "max", "must be an integer between 1 and "+strconv.Itoa(s.maxLimit()))
+		} else {
+			q.Limit = limit
+		}
+	}
+
+	rawSort := values.Get("sort")
+	if rawSort == "" {
+		rawSort = s.DefaultSort
+	}
+	for _, part := range strings.Split(rawSort, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field, desc := strings.CutPrefix(part, "-")
+		if !slices.Contains(s.Sorts, field) {
+			invalid("sort", "oneof", "can't sort by "+strconv.Quote(field)+", allowed: "+strings.Join(s.Sorts, ", "))
+			continue
+		}
+		q.Sort = append(q.Sort, Sort{Field: field, Desc: desc})
+	}
+
+	// sorted, so filters come out in the same order for the same request (stable SQL, cacheable plans)
+	for _, name := range slices.Sorted(maps.Keys(s.Filters)) {
+		if slices.Contains(reserved, name) {
+			invalid(name, "reserved", "is a paging parameter and can't be a filter")
+			continue
+		}
+		for _, raw := range values[name] {
+			filter := parseFilter(name, raw)
+			if !slices.Contains(s.Filters[name], filter.Op) {
+				invalid(name, "operator", "operator "+string(filter.Op)+" is not allowed, allowed: "+joinOps(s.Filters[name]))
+				continue
+			}
+			q.Filters = append(q.Filters, filter)
+		}
+	}
+
+	if len(fields) > 0 {
+		return Query{}, &httpx.BindError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_parameters",
+			Message: "invalid list parameters",
+			Fields:  fields,
+		}
+	}
+	return q, nil
+}
+
+// parseFilter: "in:a,b" → in [a b], values without a known operator prefix are eq, so "12:30" stays a value
+func parseFilter(field, raw string) Filter {
+	op := Eq
+	value := raw
+	if prefix, rest, ok := strings.Cut(raw, ":"); ok && slices.Contains(operators, Op(prefix)) {
+		op, value = Op(prefix), rest
+	}
+
+	values := []string{value}
+	if op == In || op == Nin {
+		values = strings.Split(value, ",")
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+	}
+	return Filter{Field: field, Op: op, Values: values}
+}
+
+// Offset: rows before the page, 0 with a cursor
+func (q Query) Offset() int {
+	if q.Cursor != "" {
+		return 0
+	}
+	return (q.Page - 1) * q.Limit
+}
+
+// Pagination: keyset parameters for pagination.Keyset.Fetch
+func (q Query) Pagination() pagination.Params {
+	return pagination.Params{Limit: q.Limit, After: q.Cursor}
+}
+
+func (s Spec) defaultLimit() int {
+	if s.DefaultLimit > 0 {
+		return min(s.DefaultLimit, s.maxLimit())
+	}
+	return min(pagination.DefaultLimit, s.maxLimit())
+}
+
+func (s Spec) maxLimit() int {
+	if s.MaxLimit > 0 {
+		return s.MaxLimit
+	}
+	return pagination.MaxLimit
+}
+
+func joinOps(ops []Op) string {
+	names := make([]string, len(ops))
+	for i, op := range ops {
+		names[i] = string(op)
+	}
+	return strings.Join(names, ", ")
+}
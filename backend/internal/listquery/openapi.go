@@ -0,0 +1,60 @@
+package listquery
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/openapi"
+)
+
+// OpenAPIParameters: page, limit, cursor, sort and one parameter per filter, for router.Doc.Request
+func (s Spec) OpenAPIParameters() []openapi.Parameter {
+	one := float64(1)
+	maxLimit := float64(s.maxLimit())
+
+	params := []openapi.Parameter{
+		{
+			Name:        "page",
+			In:          "query",
+			Description: "page number, starting at 1; can't be combined with cursor",
+			Schema:      &openapi.Schema{Type: "integer", Minimum: &one},
+		},
+		{
+			Name:        "limit",
+			In:          "query",
+			Description: "items per page, " + strconv.Itoa(s.defaultLimit()) + " by default",
+			Schema:      &openapi.Schema{Type: "integer", Minimum: &one, Maximum: &maxLimit},
+		},
+		{
+			Name:        "cursor",
+			In:          "query",
+			Description: "next_cursor of the previous page",
+			Schema:      &openapi.Schema{Type: "string"},
+		},
+	}
+
+	if len(s.Sorts) > 0 {
+		description := "comma separated fields, - in front sorts descending; one of " + strings.Join(s.Sorts, ", ")
+		if s.DefaultSort != "" {
+			description += "; " + s.DefaultSort + " by default"
+		}
+		params = append(params, openapi.Parameter{
+			Name:        "sort",
+			In:          "query",
+			Description: description,
+			Schema:      &openapi.Schema{Type: "string"},
+		})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Filters)) {
+		params = append(params, openapi.Parameter{
+			Name:        name,
+			In:          "query",
+			Description: "filter as value or operator:value, operators: " + joinOps(s.Filters[name]) + " (in / nin take comma separated values)",
+			Schema:      &openapi.Schema{Type: "string"},
+		})
+	}
+	return params
+}
@@ -0,0 +1,268 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev distributed lock: exclusive execution across the replicas of the service, e.g. cron jobs
+
+lock.WithLock(ctx, "reports:daily", func(ctx context.Context) error { ... })
+    → Acquire: SET lock:{key} <owner> NX PX ttl, retried every lock.retry_interval until ctx is done
+        → the same script INCRs lock:{key}:fence, every holder gets a larger fencing token than the one before
+    → the lock is extended every ttl/3 while fn runs, ctx of fn is cancelled when it can't be extended (lock lost)
+    → Release: DEL only while the value is still our owner id, a lock taken over after expiry is left alone
+
+fencing token: a holder paused longer than ttl (GC, network) can still think it owns the lock,
+pass Token() along with writes and reject tokens lower than the last one seen (e.g. WHERE fence < $token)
+
+keys use a {hash tag}, lock and fence live in the same cluster slot
+*/
+
+var (
+	// ErrNotAcquired: the lock is held by someone else
+	ErrNotAcquired = errors.New("lock: not acquired")
+	// ErrNotHeld: the lock expired or was taken over, Extend / Release found another owner
+	ErrNotHeld = errors.New("lock: not held")
+	// ErrNoLocker: Acquire / WithLock were used before SetDefault
+	ErrNoLocker = errors.New("lock: no default locker, call lock.SetDefault")
+)
+
+// acquireScript: SET NX + fencing token in one round trip, 0 when the lock is taken
+var acquireScript = goredis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+// extendScript: PEXPIRE while the lock is ours
+var extendScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript: DEL while the lock is ours
+var releaseScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Locker: creates locks on a redis client
+type Locker struct {
+	client        goredis.UniversalClient
+	ttl           time.Duration
+	retryInterval time.Duration
+	log           *zerolog.Logger
+}
+
+// New: locker on client with the lock.* config
+func New(client goredis.UniversalClient, cfg config.LockConfig, logger *zerolog.Logger) *Locker {
+	return &Locker{
+		client:        client,
+		ttl:           cfg.TTL,
+		retryInterval: cfg.RetryInterval,
+		log:           logger,
+	}
+}
+
+// Lock: a held lock, released with Release
+type Lock struct {
+	locker *Locker
+	key    string
+	owner  string
+	token  int64
+	ttl    time.Duration // expiry it was acquired with, the keepalive extends by it
+}
+
+// Key: name the lock was acquired with
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Token: fencing token, larger than the token of every earlier holder of the key
+func (l *Lock) Token() int64 {
+	return l.token
+}
+
+// TryAcquire: lock on key for ttl (lock.ttl when 0), ErrNotAcquired when it is held
+func (lk *Locker) TryAcquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if ttl <= 0 {
+		ttl = lk.ttl
+	}
+	owner, err := newOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	lockKey := redisKey(key)
+	token, err := acquireScript.Run(ctx, lk.client, []string{lockKey, lockKey + ":fence"}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if token == 0 {
+		return nil, ErrNotAcquired
+	}
+	return &Lock{locker: lk, key: key, owner: owner, token: token, ttl: ttl}, nil
+}
+
+// Acquire: waits for the lock on key until ctx is done, retried every lock.retry_interval
+func (lk *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	for {
+		l, err := lk.TryAcquire(ctx, key, ttl)
+		if !errors.Is(err, ErrNotAcquired) {
+			return l, err
+		}
+
+		select {
+		case <-time.After(lk.retryInterval):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to acquire lock %s: %w", key, ctx.Err())
+		}
+	}
+}
+
+// Extend: resets the expiry of the lock to ttl (the acquired one when 0), ErrNotHeld when it expired meanwhile
+func (l *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = l.ttl
+	}
+	extended, err := extendScript.Run(ctx, l.locker.client, []string{redisKey(l.key)}, l.owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to extend lock %s: %w", l.key, err)
+	}
+	if extended == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Release: unlocks, ErrNotHeld when the lock already expired
+func (l *Lock) Release(ctx context.Context) error {
+	released, err := releaseScript.Run(ctx, l.locker.client, []string{redisKey(l.key)}, l.owner).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.key, err)
+	}
+	if released == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// WithLock: runs fn while holding the lock on key, waits for the lock until ctx is done
+func (lk *Locker) WithLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	l, err := lk.Acquire(ctx, key, lk.ttl)
+	if err != nil {
+		return err
+	}
+
+	fnCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.keepAlive(fnCtx, cancel)
+	}()
+
+	fnErr := fn(fnCtx)
+	cancel(nil)
+	<-done
+
+	// released even when ctx is done, the next holder doesn't have to wait for the expiry
+	if err := l.Release(context.WithoutCancel(ctx)); err != nil {
+		if errors.Is(err, ErrNotHeld) {
+			lk.log.Warn().Str("key", key).Int64("token", l.token).Msg("lock expired before it was released")
+		} else {
+			fnErr = errors.Join(fnErr, err)
+		}
+	}
+	return fnErr
+}
+
+// keepAlive: extends the lock every ttl/3 of its acquired ttl until ctx is done, cancels ctx with ErrNotHeld when the lock is lost
+func (l *Lock) keepAlive(ctx context.Context, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := l.Extend(ctx, l.ttl)
+			if err == nil {
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, ErrNotHeld) {
+				l.locker.log.Error().Str("key", l.key).Int64("token", l.token).Msg("lock lost, cancelling its work")
+				cancel(ErrNotHeld)
+				return
+			}
+			// redis hiccup, the lock is still valid until its ttl, try again on the next tick
+			l.locker.log.Warn().Err(err).Str("key", l.key).Msg("failed to extend lock")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// redisKey: the hash tag keeps lock and fence counter in the same cluster slot
+func redisKey(key string) string {
+	return "lock:{" + key + "}"
+}
+
+// newOwner: random id of a holder, only the holder can extend or release
+func newOwner() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock owner: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// defaultLocker: used by Acquire / WithLock, nil until SetDefault is called
+var defaultLocker *Locker
+
+// SetDefault: makes lk the locker of the package functions, call it once at startup
+func SetDefault(lk *Locker) {
+	defaultLocker = lk
+}
+
+// Acquire: Locker.Acquire of the default locker
+func Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if defaultLocker == nil {
+		return nil, ErrNoLocker
+	}
+	return defaultLocker.Acquire(ctx, key, ttl)
+}
+
+// TryAcquire: Locker.TryAcquire of the default locker
+func TryAcquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if defaultLocker == nil {
+		return nil, ErrNoLocker
+	}
+	return defaultLocker.TryAcquire(ctx, key, ttl)
+}
+
+// WithLock: Locker.WithLock of the default locker
+func WithLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	if defaultLocker == nil {
+		return ErrNoLocker
+	}
+	return defaultLocker.WithLock(ctx, key, fn)
+}
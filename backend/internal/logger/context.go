@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+// @dev request scoped logger: middleware enriches a logger (trace ids, request id) and stores it in the context
+// @dev handlers, repositories and jobs call FromContext(ctx) instead of passing *zerolog.Logger around
+
+// WithContext: returns a copy of ctx which carries the logger
+func WithContext(ctx context.Context, logger zerolog.Logger) context.Context {
+	return logger.WithContext(ctx)
+}
+
+// FromContext: returns the logger stored in ctx
+// without one it returns the default logger set by SetDefault, a disabled logger if none was set
+func FromContext(ctx context.Context) *zerolog.Logger {
+	return zerolog.Ctx(ctx)
+}
+
+// SetDefault: logger returned by FromContext for contexts which carry no logger (background jobs, tests)
+func SetDefault(logger *zerolog.Logger) {
+	zerolog.DefaultContextLogger = logger
+}
+
+// RequestIDField: field name of the request id on every log line of a request
+const RequestIDField = "request_id"
+
+type requestIDKey struct{}
+
+// WithRequestID: companion of WithTraceContext, adds the request id to the logger
+func WithRequestID(logger zerolog.Logger, requestID string) zerolog.Logger {
+	if requestID == "" {
+		return logger
+	}
+
+	return logger.With().Str(RequestIDField, requestID).Logger()
+}
+
+// ContextWithRequestID: stores the request id in ctx and attaches it to the logger of ctx
+// so every line logged through FromContext during the request carries request_id (next to trace.id/span.id)
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return WithContext(ctx, WithRequestID(*FromContext(ctx), requestID))
+}
+
+// RequestIDFromContext: returns the request id stored by ContextWithRequestID, "" when there is none
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
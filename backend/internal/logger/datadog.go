@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/**
+@dev Datadog: alternative to New Relic, selected with observability.provider = datadog
+
+Integration flow:
+
+NewLoggerService (starts the datadogWriter when an api key is configured)
+    → NewLoggerWithService (tees every JSON event into datadogWriter in production)
+        → datadogWriter batches events and POSTs them to the HTTP intake (https://http-intake.logs.<site>/api/v2/logs)
+            → Shutdown sends what is still buffered
+*/
+
+const (
+	datadogBatchSize     = 500         // intake accepts max 1000 entries per request
+	datadogFlushInterval = time.Second // max time an event waits in the buffer
+	datadogBufferSize    = 10000       // events are dropped when the intake can't keep up
+)
+
+// datadogWriter: zerolog writer which ships events to the Datadog logs HTTP intake
+type datadogWriter struct {
+	url      string
+	apiKey   string
+	service  string
+	source   string
+	tags     string
+	hostname string
+	client   *http.Client
+	events   chan map[string]any
+	wg       sync.WaitGroup
+	mu       sync.RWMutex // guards closed, so no event is sent on the closed channel
+	closed   bool
+}
+
+func newDatadogWriter(cfg *config.ObservabilityConfig) *datadogWriter {
+	hostname, _ := os.Hostname()
+
+	w := &datadogWriter{
+		url:      "https://http-intake.logs." + cfg.Datadog.Site + "/api/v2/logs",
+		apiKey:   cfg.Datadog.APIKey,
+		service:  cfg.ServiceName,
+		source:   cfg.Datadog.Source,
+		tags:     strings.Join(append([]string{"env:" + cfg.Environment}, cfg.Datadog.Tags...), ","),
+		hostname: hostname,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		events:   make(chan map[string]any, datadogBufferSize),
+	}
+
+	w.wg.Add(1)
+	go w.run()
+
+	return w
+}
+
+// Write implements io.Writer
+func (w *datadogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter, never blocks the caller
+func (w *datadogWriter) WriteLevel(_ zerolog.Level, p []byte) (int, error) {
+	var event map[string]any
+	if err := json.Unmarshal(p, &event); err != nil {
+		event = map[string]any{zerolog.MessageFieldName: string(p)}
+	}
+
+	// reserved attributes, "level" is picked up by Datadog's status remapper as is
+	event["ddsource"] = w.source
+	event["ddtags"] = w.tags
+	event["service"] = w.service
+	event["hostname"] = w.hostname
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return len(p), nil
+	}
+
+	select {
+	case w.events <- event:
+	default:
+		// buffer is full, dropping is better than blocking the request which logs
+	}
+
+	return len(p), nil
+}
+
+// run: collects events into batches and sends them
+func (w *datadogWriter) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(datadogFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]map[string]any, 0, datadogBatchSize)
+	for {
+		select {
+		case event, ok := <-w.events:
+			if !ok {
+				w.send(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= datadogBatchSize {
+				w.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			w.send(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (w *datadogWriter) send(batch []map[string]any) {
+	if len(batch) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(batch)
+	if err != nil {
+		fmt.Println("failed to encode datadog logs:", err)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		fmt.Println("failed to create datadog request:", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", w.apiKey)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		fmt.Println("failed to send logs to datadog:", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		fmt.Println("datadog intake rejected logs with status", resp.StatusCode)
+	}
+}
+
+// Close: stops accepting events and sends the remaining buffer
+func (w *datadogWriter) Close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.events)
+	w.mu.Unlock()
+
+	w.wg.Wait()
+}
+
+// WithDatadogTraceContext: adds dd.trace_id / dd.span_id of the active OpenTelemetry span to the logger
+// Datadog correlates logs and traces with the lower 64 bits of the ids, as unsigned decimal strings
+func WithDatadogTraceContext(logger zerolog.Logger, ctx context.Context) zerolog.Logger {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return logger
+	}
+
+	traceID := spanContext.TraceID()
+	spanID := spanContext.SpanID()
+
+	return logger.With().
+		Str("dd.trace_id", strconv.FormatUint(binary.BigEndian.Uint64(traceID[8:]), 10)).
+		Str("dd.span_id", strconv.FormatUint(binary.BigEndian.Uint64(spanID[:]), 10)).
+		Logger()
+}
@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev dedup: repeated messages are collapsed before they reach stdout, files or the log forwarder
+
+Flow (observability.logging.dedup):
+
+event with the same level + message + error as an earlier one in the current interval
+    → the first Threshold events of the interval are written as usual
+        → the rest are counted, not written
+            → when the interval is over one entry "<message> (repeated N times)" with repeated=N is written
+*/
+
+// dedupFilter: state shared by every logger of a LoggerService, flushes the summaries in the background
+type dedupFilter struct {
+	threshold int
+	interval  time.Duration
+
+	mu      sync.Mutex
+	entries map[dedupKey]*dedupEntry
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// dedupKey: events are identical when they are written by the same logger with the same level, message and error
+type dedupKey struct {
+	writer  *dedupWriter
+	level   zerolog.Level
+	message string
+	err     string
+}
+
+type dedupEntry struct {
+	start      time.Time // start of the interval
+	count      int       // events seen in the interval
+	suppressed int       // events not written in the interval
+	last       []byte    // last suppressed event, base of the summary
+}
+
+func newDedupFilter(cfg *config.LogDedupConfig) *dedupFilter {
+	f := &dedupFilter{
+		threshold: cfg.Threshold,
+		interval:  cfg.Interval,
+		entries:   make(map[dedupKey]*dedupEntry),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go f.run()
+
+	return f
+}
+
+// wrap: dedup writer in front of next, one per logger
+func (f *dedupFilter) wrap(next zerolog.LevelWriter) zerolog.LevelWriter {
+	return &dedupWriter{filter: f, next: next}
+}
+
+// run: writes the summaries of finished intervals and forgets quiet messages
+func (f *dedupFilter) run() {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			f.flush(false)
+		case <-f.stop:
+			f.flush(true)
+			return
+		}
+	}
+}
+
+// flush: writes the summary of every interval which is over, of every interval when all is set
+func (f *dedupFilter) flush(all bool) {
+	now := time.Now()
+
+	type summary struct {
+		key   dedupKey
+		entry *dedupEntry
+	}
+	var summaries []summary
+
+	f.mu.Lock()
+	for key, entry := range f.entries {
+		if !all && now.Sub(entry.start) < f.interval {
+			continue
+		}
+		delete(f.entries, key)
+		if entry.suppressed > 0 {
+			summaries = append(summaries, summary{key: key, entry: entry})
+		}
+	}
+	f.mu.Unlock()
+
+	// written outside the lock, the next writer may be slow (network sinks)
+	for _, s := range summaries {
+		s.key.writer.writeSummary(s.key.level, s.entry)
+	}
+}
+
+// Close: stops the background flush and writes the pending summaries
+func (f *dedupFilter) Close() {
+	select {
+	case <-f.stop:
+		return
+	default:
+	}
+	close(f.stop)
+	<-f.done
+}
+
+// dedupWriter: zerolog writer counting identical events before passing them on
+type dedupWriter struct {
+	filter *dedupFilter
+	next   zerolog.LevelWriter
+}
+
+// Write implements io.Writer
+func (w *dedupWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (w *dedupWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	var fields struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return w.next.WriteLevel(level, p)
+	}
+
+	key := dedupKey{writer: w, level: level, message: fields.Message, err: fields.Error}
+	now := time.Now()
+
+	w.filter.mu.Lock()
+	entry, ok := w.filter.entries[key]
+	if !ok {
+		entry = &dedupEntry{start: now}
+		w.filter.entries[key] = entry
+	}
+	entry.count++
+	if entry.count > w.filter.threshold {
+		entry.suppressed++
+		entry.last = append(entry.last[:0], p...)
+		w.filter.mu.Unlock()
+		return len(p), nil
+	}
+	w.filter.mu.Unlock()
+
+	return w.next.WriteLevel(level, p)
+}
+
+// writeSummary: last suppressed event with the repeat count in message and repeated field
+func (w *dedupWriter) writeSummary(level zerolog.Level, entry *dedupEntry) {
+	var fields map[string]any
+	if err := json.Unmarshal(entry.last, &fields); err != nil {
+		return
+	}
+
+	msg, _ := fields[zerolog.MessageFieldName].(string)
+	fields[zerolog.MessageFieldName] = fmt.Sprintf("%s (repeated %d times)", msg, entry.suppressed)
+	fields["repeated"] = entry.suppressed
+	fields[zerolog.TimestampFieldName] = time.Now().Format(zerolog.TimeFieldFormat)
+
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return
+	}
+
+	if _, err := w.next.WriteLevel(level, append(out, '\n')); err != nil {
+		fmt.Println("failed to write repeated log summary:", err)
+	}
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// @dev ECS mode (observability.logging.format = ecs): stdout JSON following the Elastic Common Schema
+// @dev Elastic (filebeat / elastic-agent) and most SIEMs ingest it without an ingest pipeline
+// @dev dotted keys are used like the official ecs-logging libraries do, Elasticsearch expands them into objects
+
+const (
+	ecsVersion   = "8.11.0"
+	ecsTimestamp = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// ecsFieldNames: zerolog / application fields with a direct ECS counterpart
+var ecsFieldNames = map[string]string{
+	zerolog.LevelFieldName:      "log.level",
+	zerolog.ErrorFieldName:      "error.message",
+	zerolog.ErrorStackFieldName: "error.stack_trace",
+	"service":                   "service.name",
+	"environment":               "service.environment",
+	"module":                    "log.logger",
+	RequestIDField:              "http.request.id",
+}
+
+// ecsWriter: rewrites events to the ECS layout before writing them
+type ecsWriter struct {
+	next io.Writer
+}
+
+// Write implements io.Writer
+func (w ecsWriter) Write(p []byte) (int, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return w.next.Write(p)
+	}
+
+	out := make(map[string]any, len(fields)+2)
+	out["@timestamp"] = eventTime(fields).Format(ecsTimestamp)
+	out["ecs.version"] = ecsVersion
+
+	for key, value := range fields {
+		switch key {
+		case zerolog.TimestampFieldName:
+			continue
+		case zerolog.CallerFieldName:
+			// "path/file.go:42" → log.origin.file.name / log.origin.file.line
+			caller, _ := value.(string)
+			file, line, found := strings.Cut(caller, ":")
+			out["log.origin.file.name"] = file
+			if n, err := strconv.Atoi(line); found && err == nil {
+				out["log.origin.file.line"] = n
+			}
+			continue
+		case zerolog.ErrorStackFieldName:
+			// pkgerrors marshals the stack as a list of frames, ECS wants one string
+			if _, ok := value.(string); !ok {
+				if encoded, err := json.Marshal(value); err == nil {
+					value = string(encoded)
+				} else {
+					value = fmt.Sprint(value)
+				}
+			}
+		}
+
+		if ecsKey, ok := ecsFieldNames[key]; ok {
+			key = ecsKey
+		}
+		out[key] = value
+	}
+
+	encoded, err := json.Marshal(out)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := w.next.Write(append(encoded, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
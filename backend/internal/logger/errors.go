@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	pkgErrors "github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+)
+
+/**
+@dev structured error logging: same field set for every logged error instead of ad-hoc .Err(err) calls
+
+logger.Err(ctx, err).Str("user_id", id).Msg("failed to delete user")
+    → error:             err.Error(), as before
+    → error.code:        code of the first error in the chain implementing ErrorCoder (SQLSTATE for postgres errors)
+    → error.type:        type of the first error implementing ErrorTyper, Go type of the root cause otherwise
+    → error.cause:       message of the root cause (last error of the Unwrap chain)
+    → stack:             stack of the deepest error carrying one (github.com/pkg/errors)
+*/
+
+const (
+	ErrorCodeField  = "error.code"
+	ErrorTypeField  = "error.type"
+	ErrorCauseField = "error.cause"
+)
+
+// ErrorCoder: implemented by application errors with a stable, machine readable code
+type ErrorCoder interface {
+	ErrorCode() string
+}
+
+// ErrorTyper: implemented by application errors which name their category (validation, not_found, ...)
+type ErrorTyper interface {
+	ErrorType() string
+}
+
+// stackTracer: errors created or wrapped with github.com/pkg/errors
+type stackTracer interface {
+	StackTrace() pkgErrors.StackTrace
+}
+
+// Err: error level event of the context logger with the structured error fields
+func Err(ctx context.Context, err error) *zerolog.Event {
+	countError(zerolog.ErrorLevel, err)
+	return ErrorFields(FromContext(ctx).Error(), err)
+}
+
+// ErrWithLevel: like Err with another level, e.g. expected errors logged as warn
+func ErrWithLevel(ctx context.Context, level zerolog.Level, err error) *zerolog.Event {
+	countError(level, err)
+	return ErrorFields(FromContext(ctx).WithLevel(level), err)
+}
+
+// ErrorFields: adds the structured error fields to any event, nil events and errors are passed through
+func ErrorFields(event *zerolog.Event, err error) *zerolog.Event {
+	if event == nil || err == nil {
+		return event
+	}
+
+	chain := ErrorChain(err)
+	root := chain[len(chain)-1]
+
+	event = event.AnErr(zerolog.ErrorFieldName, err)
+
+	if code := ErrorCode(err); code != "" {
+		event = event.Str(ErrorCodeField, code)
+	}
+
+	var typer ErrorTyper
+	if errors.As(err, &typer) {
+		event = event.Str(ErrorTypeField, typer.ErrorType())
+	} else {
+		event = event.Str(ErrorTypeField, fmt.Sprintf("%T", root))
+	}
+
+	if root != err {
+		event = event.Str(ErrorCauseField, root.Error())
+	}
+
+	// deepest stack is the closest one to where the error happened
+	for i := len(chain) - 1; i >= 0; i-- {
+		if _, ok := chain[i].(stackTracer); ok {
+			event = event.Interface(zerolog.ErrorStackFieldName, pkgerrors.MarshalStack(chain[i]))
+			break
+		}
+	}
+
+	return event
+}
+
+// ErrorCode: code of the first error in the chain which has one, "" otherwise
+func ErrorCode(err error) string {
+	var coder ErrorCoder
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+
+	return ""
+}
+
+// ErrorChain: err followed by every error it wraps, errors.Join branches are followed through their first error
+func ErrorChain(err error) []error {
+	var chain []error
+	for err != nil {
+		chain = append(chain, err)
+
+		switch wrapped := err.(type) {
+		case interface{ Unwrap() error }:
+			err = wrapped.Unwrap()
+		case interface{ Unwrap() []error }:
+			errs := wrapped.Unwrap()
+			if len(errs) == 0 {
+				return chain
+			}
+			err = errs[0]
+		default:
+			return chain
+		}
+	}
+	return chain
+}
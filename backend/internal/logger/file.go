@@ -0,0 +1,21 @@
+package logger
+
+import (
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// @dev rotating log file: written next to stdout for bare-metal/VM deployments without a log shipper
+// @dev lumberjack rotates by size, deletes by age/count and gzips the rotated files
+
+// newFileWriter: creates the rotating file writer, the log directory is created on first write
+func newFileWriter(cfg *config.LogFileConfig) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   cfg.Path,
+		MaxSize:    cfg.MaxSizeMB,
+		MaxAge:     cfg.MaxAgeDays,
+		MaxBackups: cfg.MaxBackups,
+		Compress:   cfg.Compress,
+		LocalTime:  true,
+	}
+}
@@ -0,0 +1,209 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev GELF and Logstash: feed Graylog / ELK pipelines without a converter sidecar
+
+GELF writer: every event becomes a GELF 1.1 message (short_message, level, _extra fields)
+    → UDP: optionally gzipped, split into chunks when bigger than one datagram
+    → TCP: null byte delimited, never compressed
+    → sent from a background goroutine (netWriter), a down Graylog doesn't slow down logging
+
+Logstash mode (observability.logging.format = logstash): stdout JSON with @timestamp / @version fields
+*/
+
+const (
+	gelfChunkSize     = 1420 // bytes per UDP datagram, fits a 1500 byte MTU with IP / UDP headers (and some tunnel overhead)
+	gelfMaxChunks     = 128  // limit of the GELF spec
+	gelfChunkHeader   = 12   // magic (2) + message id (8) + sequence number (1) + sequence count (1)
+	logstashVersion   = "1"
+	logstashTimestamp = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// gelfWriter: zerolog writer sending GELF messages to Graylog
+type gelfWriter struct {
+	network  string
+	compress bool
+	host     string
+	sender   *netWriter
+}
+
+func newGELFWriter(cfg *config.GELFConfig) *gelfWriter {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	w := &gelfWriter{
+		network:  cfg.Network,
+		compress: cfg.Compress && cfg.Network == "udp",
+		host:     host,
+	}
+	if cfg.Network == "tcp" {
+		w.sender = newNetWriter("gelf", cfg.Network, cfg.Address, writeGELFTCP)
+	} else {
+		w.sender = newNetWriter("gelf", cfg.Network, cfg.Address, writeGELFUDP)
+	}
+	return w
+}
+
+// Write implements io.Writer
+func (w *gelfWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter, never blocks the caller on the network
+func (w *gelfWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	msg, err := w.encode(level, p)
+	if err != nil {
+		return 0, err
+	}
+	// rejected here, the writer would take it for a broken connection
+	if w.network == "udp" && gelfChunks(msg) > gelfMaxChunks {
+		return 0, fmt.Errorf("gelf message of %d bytes needs more than %d chunks", len(msg), gelfMaxChunks)
+	}
+	w.sender.send(msg)
+	return len(p), nil
+}
+
+// encode: converts the zerolog JSON event into a GELF 1.1 message
+func (w *gelfWriter) encode(level zerolog.Level, p []byte) ([]byte, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(p, &fields); err != nil {
+		fields = map[string]any{zerolog.MessageFieldName: string(bytes.TrimSpace(p))}
+	}
+
+	shortMessage, _ := fields[zerolog.MessageFieldName].(string)
+	if shortMessage == "" {
+		shortMessage = "-" // short_message is mandatory
+	}
+
+	gelf := map[string]any{
+		"version":       "1.1",
+		"host":          w.host,
+		"short_message": shortMessage,
+		"timestamp":     float64(eventTime(fields).UnixMilli()) / 1000,
+		"level":         syslogSeverity(level),
+	}
+
+	for key, value := range fields {
+		switch key {
+		case zerolog.MessageFieldName, zerolog.TimestampFieldName, zerolog.LevelFieldName:
+			continue
+		case "id":
+			// _id is reserved by Graylog
+			key = "event_id"
+		}
+		// additional fields need the underscore prefix and no dots
+		gelf["_"+strings.ReplaceAll(key, ".", "_")] = value
+	}
+
+	msg, err := json.Marshal(gelf)
+	if err != nil {
+		return nil, fmt.Errorf("encoding gelf message: %w", err)
+	}
+
+	if !w.compress {
+		return msg, nil
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(msg); err != nil {
+		return nil, fmt.Errorf("compressing gelf message: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("compressing gelf message: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeGELFTCP(conn net.Conn, msg []byte) error {
+	_, err := conn.Write(append(msg, 0))
+	return err
+}
+
+// writeGELFUDP: sends the message in one datagram, or as GELF chunks when it is too big
+func writeGELFUDP(conn net.Conn, msg []byte) error {
+	if len(msg) <= gelfChunkSize {
+		_, err := conn.Write(msg)
+		return err
+	}
+
+	payloadSize := gelfChunkSize - gelfChunkHeader
+	count := gelfChunks(msg)
+
+	messageID := make([]byte, 8)
+	if _, err := io.ReadFull(rand.Reader, messageID); err != nil {
+		return err
+	}
+
+	for i := 0; i < count; i++ {
+		end := min((i+1)*payloadSize, len(msg))
+
+		chunk := make([]byte, 0, gelfChunkSize)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, messageID...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, msg[i*payloadSize:end]...)
+
+		if _, err := conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// gelfChunks: number of UDP chunks of msg
+func gelfChunks(msg []byte) int {
+	payloadSize := gelfChunkSize - gelfChunkHeader
+	return (len(msg) + payloadSize - 1) / payloadSize
+}
+
+// Close: sends the queued messages and closes the connection to the GELF input
+func (w *gelfWriter) Close() error {
+	w.sender.Close()
+	return nil
+}
+
+// logstashWriter: rewrites events to the Logstash JSON layout before writing them
+type logstashWriter struct {
+	next io.Writer
+}
+
+// Write implements io.Writer
+func (w logstashWriter) Write(p []byte) (int, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return w.next.Write(p)
+	}
+
+	fields["@timestamp"] = eventTime(fields).Format(logstashTimestamp)
+	fields["@version"] = logstashVersion
+	delete(fields, zerolog.TimestampFieldName)
+
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := w.next.Write(append(out, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// @dev runtime log level: change the level of the running process without a restart
+// @dev every logger created through the LoggerService shares one LevelVar, so a single Set() affects all of them
+
+// LevelVar: zerolog level which can be read and changed concurrently
+// it is used as a zerolog hook, events below the current level are discarded
+type LevelVar struct {
+	level atomic.Int32
+}
+
+// NewLevelVar: creates a LevelVar starting at the given level
+func NewLevelVar(level zerolog.Level) *LevelVar {
+	lv := &LevelVar{}
+	lv.Set(level)
+	return lv
+}
+
+// Level: returns the current level
+func (lv *LevelVar) Level() zerolog.Level {
+	return zerolog.Level(lv.level.Load())
+}
+
+// Set: changes the level for every logger sharing this LevelVar
+func (lv *LevelVar) Set(level zerolog.Level) {
+	lv.level.Store(int32(level))
+}
+
+// Run implements zerolog.Hook
+func (lv *LevelVar) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	if level < lv.Level() {
+		e.Discard()
+	}
+}
+
+// ParseLevel: converts a config level string (debug, info, warn, error) into zerolog level
+func ParseLevel(level string) (zerolog.Level, error) {
+	switch level {
+	case "debug":
+		return zerolog.DebugLevel, nil
+	case "info":
+		return zerolog.InfoLevel, nil
+	case "warn":
+		return zerolog.WarnLevel, nil
+	case "error":
+		return zerolog.ErrorLevel, nil
+	default:
+		return zerolog.InfoLevel, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+// WatchSIGHUP: re-reads the logging level from env every time the process receives SIGHUP
+// blocks until ctx is done, so run it in a goroutine
+func (ls *LoggerService) WatchSIGHUP(ctx context.Context, logger *zerolog.Logger) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			levelStr := config.ReloadLogLevel()
+			if levelStr == "" {
+				logger.Warn().Msg("received SIGHUP but no logging level is set in env")
+				continue
+			}
+
+			level, err := ParseLevel(levelStr)
+			if err != nil {
+				logger.Error().Err(err).Msg("received SIGHUP with invalid logging level")
+				continue
+			}
+
+			previous := ls.level.Level()
+			ls.level.Set(level)
+			logger.Info().
+				Str("from", previous.String()).
+				Str("to", level.String()).
+				Msg("log level changed via SIGHUP")
+		}
+	}
+}
+
+// skipDiscarded: wraps a hook added after the LevelVar, so it does not see events the LevelVar discarded
+// discarded events reach later hooks with zerolog.Disabled level
+func skipDiscarded(hook zerolog.Hook) zerolog.Hook {
+	return zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		if level == zerolog.Disabled {
+			return
+		}
+		hook.Run(e, level, msg)
+	})
+}
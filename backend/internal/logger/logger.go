@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,10 +9,13 @@ import (
 	"time"
 
 	"github.com/anuragShingare30/go-boilerplate/internal/config"
-	"github.com/newrelic/go-agent/v3/integrations/logcontext-v2/nrzerolog"
+	"github.com/newrelic/go-agent/v3/integrations/logcontext-v2/zerologWriter"
 	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/pkgerrors"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 /**
@@ -31,14 +35,91 @@ NewLoggerService (initializes NewRelic)
 
 // here struct element is in small case - internal use only
 type LoggerService struct {
-	nrApp *newrelic.Application
+	nrApp          *newrelic.Application
+	level          *LevelVar                // shared by all loggers, can be changed at runtime
+	otelProvider   *sdklog.LoggerProvider   // nil unless logs are exported over OTLP
+	tracerProvider *sdktrace.TracerProvider // nil unless traces are exported over OTLP
+	logsToNR       bool                     // forward logs to New Relic, APM works without it
+	fileWriter     *lumberjack.Logger       // nil unless file logging is enabled
+	moduleLevels   map[string]*LevelVar     // per module levels from observability.logging.levels
+	redactor       *Redactor                // nil when redaction is disabled
+	ddWriter       *datadogWriter           // nil unless provider is datadog with an api key
+	syslogWriter   *syslogWriter            // nil unless syslog output is enabled
+	gelfWriter     *gelfWriter              // nil unless gelf output is enabled
+	dedup          *dedupFilter             // nil unless dedup of repeated messages is enabled
+	cfg            *config.ObservabilityConfig
 }
 
 // NewLoggerService: initializes and returns a new LoggerService instance
 // we use newrelic package to init the NewRelic service/application
 func NewLoggerService(cfg *config.ObservabilityConfig) *LoggerService {
+	initialLevel, _ := ParseLevel(cfg.GetLogLevel())
 	service := &LoggerService{
-		nrApp: nil,
+		nrApp:        nil,
+		level:        NewLevelVar(initialLevel),
+		logsToNR:     cfg.HasLogWriter("newrelic"),
+		moduleLevels: make(map[string]*LevelVar, len(cfg.Logging.Levels)),
+		cfg:          cfg,
+		redactor:     NewRedactor(&cfg.Logging.Redaction),
+	}
+
+	for module, levelStr := range cfg.Logging.Levels {
+		moduleLevel, err := ParseLevel(levelStr)
+		if err != nil {
+			fmt.Printf("ignoring log level of module %s: %v\n", module, err)
+			continue
+		}
+		service.moduleLevels[module] = NewLevelVar(moduleLevel)
+	}
+
+	// one dedup state per process, collapses repeated messages of all loggers
+	if cfg.Logging.Dedup.Enabled {
+		service.dedup = newDedupFilter(&cfg.Logging.Dedup)
+	}
+
+	// one rotating file per process, every logger of this service writes into it
+	if cfg.HasLogWriter("file") {
+		service.fileWriter = newFileWriter(&cfg.Logging.File)
+	}
+
+	if cfg.HasLogWriter("syslog") {
+		writer, err := newSyslogWriter(cfg)
+		if err != nil {
+			fmt.Println("failed to initialize syslog output:", err)
+		} else {
+			service.syslogWriter = writer
+		}
+	}
+
+	if cfg.HasLogWriter("gelf") {
+		service.gelfWriter = newGELFWriter(&cfg.Logging.GELF)
+	}
+
+	// OTLP export is independent of New Relic, it can run instead of it or next to it
+	if cfg.HasLogWriter("otlp") {
+		provider, err := newOTLPLoggerProvider(cfg)
+		if err != nil {
+			fmt.Println("failed to initialize otlp log exporter:", err)
+		} else {
+			service.otelProvider = provider
+		}
+	}
+
+	if cfg.OTLPTracingEnabled() {
+		provider, err := newOTLPTracerProvider(cfg)
+		if err != nil {
+			fmt.Println("failed to initialize otlp trace exporter:", err)
+		} else {
+			service.tracerProvider = provider
+		}
+	}
+
+	// Datadog replaces New Relic, the New Relic app is not started at all
+	if cfg.IsDatadog() {
+		if cfg.HasLogWriter("datadog") && cfg.Datadog.APIKey != "" {
+			service.ddWriter = newDatadogWriter(cfg)
+		}
+		return service
 	}
 
 	if cfg.NewRelic.LicenseKey == "" {
@@ -49,7 +130,8 @@ func NewLoggerService(cfg *config.ObservabilityConfig) *LoggerService {
 	configOptions = append(configOptions,
 		newrelic.ConfigAppName(cfg.ServiceName),
 		newrelic.ConfigLicense(cfg.NewRelic.LicenseKey),
-		newrelic.ConfigAppLogForwardingEnabled(cfg.NewRelic.AppLogForwardingEnabled),
+		newrelic.ConfigAppLogForwardingEnabled(cfg.NewRelic.AppLogForwardingEnabled && service.logsToNR),
+		newrelic.ConfigAppLogDecoratingEnabled(cfg.NewRelic.LocalDecoratingEnabled && service.logsToNR),
 		newrelic.ConfigDistributedTracerEnabled(cfg.NewRelic.DistributedTracingEnabled),
 	)
 
@@ -72,11 +154,47 @@ func NewLoggerService(cfg *config.ObservabilityConfig) *LoggerService {
 	return service
 }
 
-// Shutdown: Gracefully shuts down New Relic
+// Shutdown: Gracefully shuts down New Relic, writes pending dedup summaries, sends buffered Datadog logs, closes syslog, gelf and the log file, flushes pending OTLP logs
 func (ls *LoggerService) Shutdown() {
+	// pending "repeated N times" summaries go out before the writers are closed
+	if ls.dedup != nil {
+		ls.dedup.Close()
+	}
 	if ls.nrApp != nil {
 		ls.nrApp.Shutdown(10 * time.Second)
 	}
+	if ls.ddWriter != nil {
+		ls.ddWriter.Close()
+	}
+	if ls.syslogWriter != nil {
+		if err := ls.syslogWriter.Close(); err != nil {
+			fmt.Println("failed to close syslog connection:", err)
+		}
+	}
+	if ls.gelfWriter != nil {
+		if err := ls.gelfWriter.Close(); err != nil {
+			fmt.Println("failed to close gelf connection:", err)
+		}
+	}
+	if ls.fileWriter != nil {
+		if err := ls.fileWriter.Close(); err != nil {
+			fmt.Println("failed to close log file:", err)
+		}
+	}
+	if ls.tracerProvider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := ls.tracerProvider.Shutdown(ctx); err != nil {
+			fmt.Println("failed to flush otlp traces:", err)
+		}
+	}
+	if ls.otelProvider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := ls.otelProvider.Shutdown(ctx); err != nil {
+			fmt.Println("failed to flush otlp logs:", err)
+		}
+	}
 }
 
 // GetApplication: returns the New Relic application instance
@@ -84,74 +202,225 @@ func (ls *LoggerService) GetApplication() *newrelic.Application {
 	return ls.nrApp
 }
 
+// Redactor: the redaction of the log writers for values logged by hand (bodies, headers), nil when disabled
+func (ls *LoggerService) Redactor() *Redactor {
+	return ls.redactor
+}
+
+// Level: returns the level shared by all loggers of this service, call Set() on it to change the level at runtime
+func (ls *LoggerService) Level() *LevelVar {
+	return ls.level
+}
+
+// ModuleLevel: returns the level of a module, modules without own level share the global one
+func (ls *LoggerService) ModuleLevel(module string) *LevelVar {
+	if level, ok := ls.moduleLevels[module]; ok {
+		return level
+	}
+	return ls.level
+}
+
+// ForModule: derives a logger for a subsystem, e.g. ForModule("database"), tagged with a "module" field
+// its level comes from observability.logging.levels, so noisy subsystems can be tuned independently
+func (ls *LoggerService) ForModule(module string) zerolog.Logger {
+	return newLogger(ls.cfg, ls, ls.ModuleLevel(module)).
+		With().
+		Str("module", module).
+		Logger()
+}
+
 // NewLoggerWithService creates logger with NewRelic integration
 func NewLoggerWithService(cfg *config.ObservabilityConfig, loggerService *LoggerService) zerolog.Logger {
-	var logLevel zerolog.Level
-	level := cfg.GetLogLevel()
-
-	switch level {
-	case "debug":
-		logLevel = zerolog.DebugLevel
-	case "info":
-		logLevel = zerolog.InfoLevel
-	case "warn":
-		logLevel = zerolog.WarnLevel
-	case "error":
-		logLevel = zerolog.ErrorLevel
-	default:
-		logLevel = zerolog.InfoLevel
+	var level *LevelVar
+	if loggerService != nil {
+		level = loggerService.level
 	}
+	return newLogger(cfg, loggerService, level)
+}
+
+// newLogger: builds a logger whose level is controlled by the given LevelVar (static config level when nil)
+func newLogger(cfg *config.ObservabilityConfig, loggerService *LoggerService, level *LevelVar) zerolog.Logger {
+	// unknown levels fall back to info
+	logLevel, _ := ParseLevel(cfg.GetLogLevel())
 
 	// Don't set global level - let each logger have its own level
 	zerolog.TimeFieldFormat = "2006-01-02 15:04:05"
 	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
 
-	var writer io.Writer
+	// Tee every event into each configured sink (observability.logging.writers)
+	var writers []io.Writer
+	for _, name := range cfg.LogWriters() {
+		if w := logWriter(name, cfg, loggerService); w != nil {
+			writers = append(writers, w)
+		}
+	}
+	tee := zerolog.MultiLevelWriter(writers...)
+	var writer io.Writer = tee
 
-	// Setup base writer
-	// If LoggerService has an active NewRelic app, wraps the writer with zerologWriter.New() to automatically forward logs to NewRelic
-	if cfg.IsProduction() && cfg.Logging.Format == "json" {
-		// In production, write to stdout
-		writer = os.Stdout
+	// Collapse repeated messages before they reach any sink
+	if loggerService != nil && loggerService.dedup != nil {
+		writer = loggerService.dedup.wrap(tee)
+	}
 
-		// Wrap with New Relic zerologWriter for log forwarding in production
-		// ....
-	} else {
-		// Uses ConsoleWriter for human-readable, colored output
-		// No NewRelic integration (logs stay local)
-		// Development mode - use console writer
-		consoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: "2006-01-02 15:04:05"}
-		writer = consoleWriter
+	// Redact secrets and PII once, before the event reaches any of the writers above
+	if loggerService != nil && loggerService.redactor != nil {
+		writer = newRedactingWriter(writer, loggerService.redactor)
 	}
 
 	// Logger creation
-	logger := zerolog.New(writer).
+	logger := withMetadata(zerolog.New(writer).
 		Level(logLevel).
 		With().
 		Timestamp().
 		Str("service", cfg.ServiceName).
-		Str("environment", cfg.Environment).
+		Str("environment", cfg.Environment)).
 		Logger()
 
+	// Sampling of high volume levels, configured per level
+	if sampler := newSampler(&cfg.Logging.Sampling); sampler != nil {
+		logger = logger.Sample(sampler)
+	}
+
+	// Runtime level changes: logger lets every level through and the shared LevelVar hook discards what is below it
+	if level != nil {
+		logger = logger.Level(zerolog.TraceLevel).Hook(level)
+	}
+
+	// log_events_total{level}, after the level hook so only written events are counted
+	logger = logger.Hook(skipDiscarded(metricsHook))
+
+	// file:line of the log call for local development, the other sinks of the logger get the caller field too
+	if cfg.Logging.Console.Caller && cfg.HasLogWriter("console") {
+		logger = logger.With().Caller().Logger()
+	}
+
 	// Include stack traces for errors in development
 	if !cfg.IsProduction() {
 		logger = logger.With().Stack().Logger()
 	}
 
-	// Add New Relic hook for log forwarding in production
-	if cfg.IsProduction() && loggerService != nil && loggerService.nrApp != nil {
-		nrHook := nrzerolog.NewRelicHook{
-			App: loggerService.nrApp,
+	return logger
+}
+
+// stdoutWriter: destination of the stdout sink, a variable so tests can read the lines
+var stdoutWriter io.Writer = os.Stdout
+
+// logWriter: writer of one entry of observability.logging.writers, nil when the sink is not initialized
+func logWriter(name string, cfg *config.ObservabilityConfig, loggerService *LoggerService) io.Writer {
+	switch name {
+	case "stdout":
+		// JSON in the layout of logging.format, console format falls back to plain JSON
+		stdout := stdoutWriter
+		if newRelicDecoratesStdout(cfg, loggerService) {
+			// decorated lines are forwarded as well, see the newrelic case
+			stdout = newNewRelicWriter(stdoutWriter, loggerService.nrApp)
+		}
+		switch cfg.Logging.Format {
+		case "logstash":
+			return logstashWriter{next: stdout}
+		case "ecs":
+			return ecsWriter{next: stdout}
+		default:
+			return stdout
 		}
-		logger = logger.Hook(nrHook)
+	case "console":
+		// human-readable, colored output for development
+		return newConsoleWriter(&cfg.Logging.Console)
 	}
 
-	return logger
+	// the remaining sinks are owned by the LoggerService
+	if loggerService == nil {
+		return nil
+	}
+
+	switch name {
+	case "file":
+		if loggerService.fileWriter != nil {
+			return loggerService.fileWriter
+		}
+	case "syslog":
+		if loggerService.syslogWriter != nil {
+			return loggerService.syslogWriter
+		}
+	case "gelf":
+		if loggerService.gelfWriter != nil {
+			return loggerService.gelfWriter
+		}
+	case "otlp":
+		if loggerService.otelProvider != nil {
+			return newOTLPWriter(loggerService.otelProvider, cfg.ServiceName)
+		}
+	case "datadog":
+		if loggerService.ddWriter != nil {
+			return loggerService.ddWriter
+		}
+	case "newrelic":
+		// forward-only, the event is already written to stdout / file by the other sinks
+		// trace.id / span.id added by WithTraceContext are part of the forwarded JSON, so NR links logs and traces
+		if loggerService.nrApp != nil && loggerService.logsToNR && !newRelicDecoratesStdout(cfg, loggerService) {
+			return newNewRelicWriter(io.Discard, loggerService.nrApp)
+		}
+	}
+	return nil
+}
+
+// newRelicDecoratesStdout: stdout goes through the New Relic writer, which forwards and decorates it in one go
+func newRelicDecoratesStdout(cfg *config.ObservabilityConfig, loggerService *LoggerService) bool {
+	return loggerService != nil &&
+		loggerService.nrApp != nil &&
+		loggerService.logsToNR &&
+		cfg.NewRelic.LocalDecoratingEnabled &&
+		cfg.HasLogWriter("stdout")
 }
 
+// newConsoleWriter: ConsoleWriter with colors, part and field layout from observability.logging.console
+func newConsoleWriter(cfg *config.ConsoleConfig) zerolog.ConsoleWriter {
+	writer := zerolog.ConsoleWriter{
+		Out:           os.Stdout,
+		TimeFormat:    cfg.TimeFormat,
+		NoColor:       cfg.NoColor || os.Getenv("NO_COLOR") != "", // https://no-color.org
+		FieldsOrder:   cfg.FieldsOrder,
+		FieldsExclude: cfg.FieldsExclude,
+	}
+	if writer.TimeFormat == "" {
+		writer.TimeFormat = "2006-01-02 15:04:05"
+	}
+
+	if len(cfg.PartsOrder) > 0 {
+		parts := map[string]string{
+			"time":    zerolog.TimestampFieldName,
+			"level":   zerolog.LevelFieldName,
+			"caller":  zerolog.CallerFieldName,
+			"message": zerolog.MessageFieldName,
+		}
+		for _, part := range cfg.PartsOrder {
+			writer.PartsOrder = append(writer.PartsOrder, parts[part])
+		}
+	}
+
+	return writer
+}
+
+// newRelicWriter: zerologWriter reports the length of the decorated line, zerolog expects the length of the event
+type newRelicWriter struct {
+	next zerologWriter.ZerologWriter
+}
+
+// newNewRelicWriter: forwards the events to app and writes them to out, a variable so tests can see what is forwarded
+var newNewRelicWriter = func(out io.Writer, app *newrelic.Application) io.Writer {
+	return newRelicWriter{next: zerologWriter.New(out, app)}
+}
+
+// Write implements io.Writer
+func (w newRelicWriter) Write(p []byte) (int, error) {
+	if _, err := w.next.Write(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
 
 // WithTraceContext: adds New Relic transaction context to logger
-// newrelic.Transaction: represents a single web request or background task being monitored by NewRelic. 
+// newrelic.Transaction: represents a single web request or background task being monitored by NewRelic.
 // It's typically created at the start of an HTTP handler using the NewRelic middleware.
 // Request duration, Response status codes, Database query times, External API calls, Errors and panics, Custom events/metrics
 // -> kind of trace which have a starting point and end point, all the interaction and components it touches during complete request lifecylce are included in single transaction. If something goes wrong, we can take a particular tnx and explore.
@@ -168,12 +437,12 @@ func WithTraceContext(logger zerolog.Logger, txn *newrelic.Transaction) zerolog.
 }
 
 // NewPgxLogger: creates a database logger(development). For production, newrelic with pgx
-func NewPgxLogger(level zerolog.Level) zerolog.Logger{
+func NewPgxLogger(level zerolog.Level) zerolog.Logger {
 	// Using console writer for development
 	// In production, we will use newrelic and pgx
 	writer := zerolog.ConsoleWriter{
-		Out: os.Stdout,
-		NoColor: true,
+		Out:        os.Stdout,
+		NoColor:    true,
 		TimeFormat: "2006-01-02 15:04:05",
 		FormatFieldValue: func(i any) string {
 			switch v := i.(type) {
@@ -187,7 +456,7 @@ func NewPgxLogger(level zerolog.Level) zerolog.Logger{
 				return v
 			// for arguments
 			case []byte:
-			var obj interface{}
+				var obj interface{}
 				if err := json.Unmarshal(v, &obj); err == nil {
 					pretty, _ := json.MarshalIndent(obj, "", "    ")
 					return "\n" + string(pretty)
@@ -210,7 +479,7 @@ func NewPgxLogger(level zerolog.Level) zerolog.Logger{
 // GetPgxTraceLogLevel: converts zerolog level to pgx tracelog level
 func GetPgxTraceLogLevel(level zerolog.Level) int {
 	switch level {
-	case zerolog.DebugLevel:
+	case zerolog.TraceLevel, zerolog.DebugLevel:
 		return 6 // tracelog.LogLevelDebug
 	case zerolog.InfoLevel:
 		return 4 // tracelog.LogLevelInfo
@@ -221,4 +490,4 @@ func GetPgxTraceLogLevel(level zerolog.Level) int {
 	default:
 		return 0 // tracelog.LogLevelNone
 	}
-}
\ No newline at end of file
+}
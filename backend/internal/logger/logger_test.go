@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// forwardCapture: records the events which reach the New Relic writer
+type forwardCapture struct {
+	mu     sync.Mutex
+	events [][]byte
+}
+
+func (c *forwardCapture) lines() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.events
+}
+
+// captureOutput: stdout sink into a buffer, the New Relic writer wrapped so every forwarded event is recorded
+func captureOutput(t *testing.T) (*bytes.Buffer, *forwardCapture) {
+	t.Helper()
+
+	stdout := &bytes.Buffer{}
+	forwarded := &forwardCapture{}
+
+	previousStdout, previousNewRelic := stdoutWriter, newNewRelicWriter
+	stdoutWriter = stdout
+	newNewRelicWriter = func(out io.Writer, app *newrelic.Application) io.Writer {
+		next := previousNewRelic(out, app)
+		return writerFunc(func(p []byte) (int, error) {
+			forwarded.mu.Lock()
+			forwarded.events = append(forwarded.events, bytes.Clone(p))
+			forwarded.mu.Unlock()
+			return next.Write(p)
+		})
+	}
+	t.Cleanup(func() {
+		stdoutWriter, newNewRelicWriter = previousStdout, previousNewRelic
+	})
+
+	return stdout, forwarded
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// newTestApp: New Relic app with log forwarding, the collector is unreachable so nothing leaves the test
+func newTestApp(t *testing.T) *newrelic.Application {
+	t.Helper()
+
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("logger-test"),
+		newrelic.ConfigLicense(strings.Repeat("0", 40)),
+		newrelic.ConfigDistributedTracerEnabled(true),
+		newrelic.ConfigAppLogForwardingEnabled(true),
+		newrelic.ConfigAppLogDecoratingEnabled(true),
+		func(c *newrelic.Config) { c.Host = "127.0.0.1:1" },
+	)
+	if err != nil {
+		t.Fatalf("failed to create New Relic app: %v", err)
+	}
+	t.Cleanup(func() { app.Shutdown(time.Millisecond) })
+	return app
+}
+
+func decodeLine(t *testing.T, line []byte) map[string]any {
+	t.Helper()
+
+	var fields map[string]any
+	if err := json.Unmarshal(line, &fields); err != nil {
+		t.Fatalf("log line is not JSON: %v\n%s", err, line)
+	}
+	return fields
+}
+
+func TestNewLoggerWithServiceEnrichesAndForwards(t *testing.T) {
+	tests := []struct {
+		name         string
+		decorate     bool // observability.newrelic.local_decorating_enabled
+		withApp      bool
+		wantForwards int
+	}{
+		{name: "decorated stdout forwards", decorate: true, withApp: true, wantForwards: 1},
+		{name: "newrelic sink forwards", decorate: false, withApp: true, wantForwards: 1},
+		{name: "without agent stdout only", decorate: true, withApp: false, wantForwards: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, forwarded := captureOutput(t)
+
+			cfg := config.DefaultObservabilityConfig()
+			cfg.ServiceName = "orders"
+			cfg.Environment = "production"
+			cfg.Logging.Writers = []string{"stdout", "newrelic"}
+			cfg.NewRelic.LocalDecoratingEnabled = tt.decorate
+
+			// no license key, the app is set up by the test instead
+			service := NewLoggerService(cfg)
+			var txn *newrelic.Transaction
+			if tt.withApp {
+				service.nrApp = newTestApp(t)
+				txn = service.nrApp.StartTransaction("POST /orders")
+				defer txn.End()
+			}
+
+			logger := WithTraceContext(NewLoggerWithService(cfg, service), txn)
+			logger.Info().Str("order_id", "42").Msg("order placed")
+
+			lines := bytes.Split(bytes.TrimSpace(stdout.Bytes()), []byte("\n"))
+			if len(lines) != 1 {
+				t.Fatalf("stdout got %d lines, want 1:\n%s", len(lines), stdout.String())
+			}
+			line := decodeLine(t, lines[0])
+
+			for field, want := range map[string]string{
+				"service":     "orders",
+				"environment": "production",
+				"version":     ProcessMetadata().Version,
+				"host":        ProcessMetadata().Host,
+				"instance_id": ProcessMetadata().InstanceID,
+				"message":     "order placed",
+				"order_id":    "42",
+			} {
+				if line[field] != want {
+					t.Errorf("stdout %s = %v, want %q", field, line[field], want)
+				}
+			}
+
+			if tt.withApp {
+				// span.id stays empty until the agent is connected, the key is there all the same
+				traceID := txn.GetTraceMetadata().TraceID
+				if traceID == "" || line["trace.id"] != traceID {
+					t.Errorf("stdout trace.id = %v, want %q", line["trace.id"], traceID)
+				}
+				if _, ok := line["span.id"]; !ok {
+					t.Error("stdout line has no span.id")
+				}
+			}
+
+			events := forwarded.lines()
+			if len(events) != tt.wantForwards {
+				t.Fatalf("forwarded %d events, want %d", len(events), tt.wantForwards)
+			}
+			for _, event := range events {
+				fields := decodeLine(t, event)
+				if fields["message"] != "order placed" || fields["trace.id"] != line["trace.id"] || fields["service"] != "orders" {
+					t.Errorf("forwarded event differs from the stdout line:\n%s", event)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"runtime/debug"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// @dev static metadata: tells apart the replicas and releases shipping logs into the same account
+// @dev build time -> go build -ldflags "-X github.com/anuragShingare30/go-boilerplate/internal/logger.Version=v1.2.3 -X github.com/anuragShingare30/go-boilerplate/internal/logger.Commit=$(git rev-parse --short HEAD)"
+
+// Version: release of the binary, set with ldflags
+var Version = "dev"
+
+// Commit: git SHA of the binary, set with ldflags, falls back to the vcs info go build embeds
+var Commit = ""
+
+// Metadata: fields added to every log line of the process
+type Metadata struct {
+	Version    string
+	Commit     string
+	Host       string
+	InstanceID string
+}
+
+var (
+	metadata     Metadata
+	metadataOnce sync.Once
+)
+
+// ProcessMetadata: metadata of the running process, computed once
+// INSTANCE_ID (e.g. the pod uid from the downward API) wins over the random id generated at startup
+func ProcessMetadata() Metadata {
+	metadataOnce.Do(func() {
+		metadata = Metadata{
+			Version:    Version,
+			Commit:     Commit,
+			InstanceID: os.Getenv("INSTANCE_ID"),
+		}
+
+		if metadata.Commit == "" {
+			metadata.Commit = vcsRevision()
+		}
+
+		host, err := os.Hostname()
+		if err != nil {
+			host = "unknown"
+		}
+		metadata.Host = host
+
+		if metadata.InstanceID == "" {
+			id := make([]byte, 8)
+			_, _ = rand.Read(id)
+			metadata.InstanceID = hex.EncodeToString(id)
+		}
+	})
+	return metadata
+}
+
+// withMetadata: adds version, commit, host and instance_id to the logger context
+func withMetadata(ctx zerolog.Context) zerolog.Context {
+	m := ProcessMetadata()
+	return ctx.
+		Str("version", m.Version).
+		Str("commit", m.Commit).
+		Str("host", m.Host).
+		Str("instance_id", m.InstanceID)
+}
+
+// vcsRevision: short git SHA embedded by go build (-buildvcs), "unknown" for go run / test binaries
+func vcsRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && setting.Value != "" {
+			return setting.Value[:min(len(setting.Value), 12)]
+		}
+	}
+	return "unknown"
+}
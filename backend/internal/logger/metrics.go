@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+)
+
+// @dev log metrics: alerting on error log spikes straight from Prometheus, without querying the logging backend
+// @dev events dropped by sampling or below the runtime level are not counted, same as they are not written
+
+var (
+	logEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "log_events_total",
+		Help: "Number of log events written, by level.",
+	}, []string{"level"})
+
+	errorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "errors_total",
+		Help: "Number of errors logged with logger.Err / logger.ErrWithLevel, by error code.",
+	}, []string{"code"})
+)
+
+// unknownErrorCode: label of errors without ErrorCoder / SQLSTATE
+const unknownErrorCode = "unknown"
+
+func init() {
+	// series exist from the start, so increase() / rate() work for the first event too
+	for _, level := range []zerolog.Level{
+		zerolog.TraceLevel, zerolog.DebugLevel, zerolog.InfoLevel, zerolog.WarnLevel,
+		zerolog.ErrorLevel, zerolog.FatalLevel, zerolog.PanicLevel,
+	} {
+		logEventsTotal.WithLabelValues(level.String())
+	}
+}
+
+// metricsHook: counts every event which is written
+var metricsHook = zerolog.HookFunc(func(_ *zerolog.Event, level zerolog.Level, _ string) {
+	if level == zerolog.NoLevel {
+		return
+	}
+	logEventsTotal.WithLabelValues(level.String()).Inc()
+})
+
+// countError: increments errors_total for errors logged at error level or above
+func countError(level zerolog.Level, err error) {
+	if err == nil || level < zerolog.ErrorLevel || level >= zerolog.NoLevel {
+		return
+	}
+
+	code := ErrorCode(err)
+	if code == "" {
+		code = unknownErrorCode
+	}
+	errorsTotal.WithLabelValues(code).Inc()
+}
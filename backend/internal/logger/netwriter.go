@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/retry"
+)
+
+// @dev network outputs (syslog, gelf): the logging goroutine only queues the message, one background goroutine owns the connection
+// @dev a down server costs a dial every few seconds (backoff), not a dial timeout on every event, messages are dropped meanwhile
+
+const (
+	netWriterBufferSize     = 10000 // messages are dropped when the server can't keep up
+	netWriterDialTimeout    = 5 * time.Second
+	netWriterInitialBackoff = 500 * time.Millisecond
+	netWriterMaxBackoff     = 30 * time.Second
+)
+
+// netWriter: sends queued messages over one connection, reconnects with backoff when it breaks
+type netWriter struct {
+	name    string
+	network string
+	address string
+	write   func(conn net.Conn, msg []byte) error // framing of the output, e.g. octet counting or GELF chunks
+
+	messages chan []byte
+	wg       sync.WaitGroup
+	mu       sync.RWMutex // guards closed, so no message is sent on the closed channel
+	closed   bool
+
+	// owned by run
+	conn      net.Conn
+	backoff   time.Duration
+	nextDial  time.Time
+	dropped   int
+	lastError error
+}
+
+func newNetWriter(name, network, address string, write func(conn net.Conn, msg []byte) error) *netWriter {
+	w := &netWriter{
+		name:     name,
+		network:  network,
+		address:  address,
+		write:    write,
+		messages: make(chan []byte, netWriterBufferSize),
+	}
+
+	w.wg.Add(1)
+	go w.run()
+
+	return w
+}
+
+// send: queues msg, never blocks the caller
+func (w *netWriter) send(msg []byte) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return
+	}
+
+	select {
+	case w.messages <- msg:
+	default:
+		// buffer is full, dropping is better than blocking the request which logs
+	}
+}
+
+// run: writes the queued messages until Close, then closes the connection
+func (w *netWriter) run() {
+	defer w.wg.Done()
+
+	for msg := range w.messages {
+		w.deliver(msg)
+	}
+
+	if w.conn != nil {
+		if err := w.conn.Close(); err != nil {
+			fmt.Printf("failed to close %s connection: %v\n", w.name, err)
+		}
+		w.conn = nil
+	}
+}
+
+// deliver: writes msg, one retry with a fresh connection, the server may have restarted
+// without a connection and before the next dial is due the message is dropped
+func (w *netWriter) deliver(msg []byte) {
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil && !w.connect() {
+			w.dropped++
+			return
+		}
+
+		err := w.write(w.conn, msg)
+		if err == nil {
+			return
+		}
+
+		_ = w.conn.Close()
+		w.conn = nil
+		w.lastError = err
+	}
+	w.dropped++
+}
+
+// connect: dials when the backoff allows it, false while the server is out of reach
+func (w *netWriter) connect() bool {
+	if time.Now().Before(w.nextDial) {
+		return false
+	}
+
+	conn, err := net.DialTimeout(w.network, w.address, netWriterDialTimeout)
+	if err != nil {
+		w.lastError = err
+		w.backoff = min(max(w.backoff*2, netWriterInitialBackoff), netWriterMaxBackoff)
+		w.nextDial = time.Now().Add(retry.Jitter(w.backoff, 0.2))
+		// once per dial, the backoff keeps this from flooding stdout
+		fmt.Printf("failed to connect to %s %s, retrying in %s: %v\n", w.name, w.address, w.backoff, err)
+		return false
+	}
+
+	if w.dropped > 0 {
+		fmt.Printf("%s connection to %s restored, %d messages dropped (last error: %v)\n", w.name, w.address, w.dropped, w.lastError)
+		w.dropped = 0
+	}
+	w.conn = conn
+	w.backoff = 0
+	return true
+}
+
+// Close: stops accepting messages, writes what is queued and closes the connection
+func (w *netWriter) Close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.messages)
+	w.mu.Unlock()
+
+	w.wg.Wait()
+}
@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+/**
+@dev OTLP log export: ships zerolog output to any OpenTelemetry collector (Grafana Loki, Elastic, Honeycomb)
+selected with observability.logging.exporter = otlp | both (both = provider + otlp)
+
+Integration flow:
+
+NewLoggerService (creates the OTel LoggerProvider with a batching OTLP/HTTP exporter)
+    → NewLoggerWithService (tees every JSON event into otlpWriter)
+        → otlpWriter converts the event into an OTel log record and emits it
+            → Shutdown flushes the batch which has not been exported yet
+*/
+
+// newOTLPLoggerProvider: creates the OTel logger provider exporting over OTLP/HTTP
+func newOTLPLoggerProvider(cfg *config.ObservabilityConfig) (*sdklog.LoggerProvider, error) {
+	options := []otlploghttp.Option{
+		otlploghttp.WithEndpointURL(cfg.Logging.OTLP.Endpoint),
+	}
+	if cfg.Logging.OTLP.Insecure {
+		options = append(options, otlploghttp.WithInsecure())
+	}
+	if len(cfg.Logging.OTLP.Headers) > 0 {
+		options = append(options, otlploghttp.WithHeaders(cfg.Logging.OTLP.Headers))
+	}
+
+	exporter, err := otlploghttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp log exporter: %w", err)
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+		attribute.String("deployment.environment", cfg.Environment),
+	)
+
+	return sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+	), nil
+}
+
+// otlpWriter: zerolog writer which turns every JSON event into an OTel log record
+type otlpWriter struct {
+	logger otellog.Logger
+}
+
+func newOTLPWriter(provider *sdklog.LoggerProvider, serviceName string) *otlpWriter {
+	return &otlpWriter{logger: provider.Logger(serviceName)}
+}
+
+// Write implements io.Writer, used when zerolog has no level for the event
+func (w *otlpWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (w *otlpWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(p, &fields); err != nil {
+		// not a JSON event, ship the raw line as body
+		fields = map[string]any{zerolog.MessageFieldName: string(p)}
+	}
+
+	var record otellog.Record
+	record.SetObservedTimestamp(time.Now())
+	record.SetTimestamp(eventTime(fields))
+	record.SetSeverity(otelSeverity(level))
+	record.SetSeverityText(level.String())
+
+	if msg, ok := fields[zerolog.MessageFieldName].(string); ok {
+		record.SetBody(attribute.StringValue(msg))
+	}
+
+	for key, value := range fields {
+		switch key {
+		case zerolog.MessageFieldName, zerolog.LevelFieldName, zerolog.TimestampFieldName:
+			continue
+		}
+		record.AddAttributes(otelAttribute(key, value))
+	}
+
+	w.logger.Emit(context.Background(), record)
+	return len(p), nil
+}
+
+// eventTime: parses the zerolog timestamp field, falls back to now
+func eventTime(fields map[string]any) time.Time {
+	if ts, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		if t, err := time.ParseInLocation(zerolog.TimeFieldFormat, ts, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// otelAttribute: converts a decoded JSON field into an OTel attribute
+func otelAttribute(key string, value any) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	default:
+		// objects, arrays and null are kept as JSON text
+		raw, _ := json.Marshal(v)
+		return attribute.String(key, string(raw))
+	}
+}
+
+// otelSeverity: converts zerolog level to OTel severity
+func otelSeverity(level zerolog.Level) otellog.Severity {
+	switch level {
+	case zerolog.TraceLevel:
+		return otellog.SeverityTrace
+	case zerolog.DebugLevel:
+		return otellog.SeverityDebug
+	case zerolog.InfoLevel:
+		return otellog.SeverityInfo
+	case zerolog.WarnLevel:
+		return otellog.SeverityWarn
+	case zerolog.ErrorLevel:
+		return otellog.SeverityError
+	case zerolog.FatalLevel:
+		return otellog.SeverityFatal
+	case zerolog.PanicLevel:
+		return otellog.SeverityFatal4
+	default:
+		return otellog.SeverityUndefined
+	}
+}
@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// @dev redaction: secrets and PII must never reach stdout, log files, OTLP or New Relic
+// @dev zerolog hooks can't change fields which are already encoded, so redaction wraps the writer
+// @dev and rewrites the JSON event; New Relic forwarding is one of the wrapped writers, so it gets the redacted event too
+
+// Redactor: hides values of sensitive keys and text matching sensitive patterns
+type Redactor struct {
+	keys        []string
+	patterns    []*regexp.Regexp
+	replacement string
+}
+
+// NewRedactor: creates the redactor from config, nil when redaction is disabled
+func NewRedactor(cfg *config.RedactionConfig) *Redactor {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	r := &Redactor{replacement: cfg.Replacement}
+	if r.replacement == "" {
+		r.replacement = "[REDACTED]"
+	}
+
+	for _, key := range cfg.Keys {
+		r.keys = append(r.keys, strings.ToLower(key))
+	}
+
+	// patterns are validated while loading config, invalid ones are skipped here
+	for _, pattern := range cfg.Patterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			r.patterns = append(r.patterns, re)
+		}
+	}
+
+	return r
+}
+
+// RedactString: replaces every sensitive pattern inside s
+func (r *Redactor) RedactString(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, r.replacement)
+	}
+	return s
+}
+
+// partialJSONField: "key": value of a JSON text which may be cut off, the value ends at the cut as well
+var partialJSONField = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"\s*:\s*("(?:[^"\\]|\\.)*"?|[^,{}\[\]]*)`)
+
+// RedactPartialJSON: RedactJSON for JSON text which doesn't parse, e.g. a body truncated at a size cap
+// values of sensitive keys are replaced field by field, patterns apply to the whole text
+func (r *Redactor) RedactPartialJSON(s string) string {
+	s = partialJSONField.ReplaceAllStringFunc(s, func(field string) string {
+		match := partialJSONField.FindStringSubmatch(field)
+		if !r.sensitiveKey(match[1]) {
+			return field
+		}
+		return `"` + match[1] + `":"` + r.replacement + `"`
+	})
+	return r.RedactString(s)
+}
+
+// RedactJSON: returns the JSON document with sensitive keys and patterns redacted
+// input which is not a JSON object is treated as plain text
+func (r *Redactor) RedactJSON(p []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(p))
+	decoder.UseNumber()
+
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return []byte(r.RedactString(string(p)))
+	}
+
+	if !r.redactMap(fields) {
+		// nothing sensitive, keep the original bytes and field order
+		return p
+	}
+
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return p
+	}
+
+	// keep the trailing newline written by zerolog
+	if bytes.HasSuffix(p, []byte("\n")) {
+		out = append(out, '\n')
+	}
+	return out
+}
+
+// redactMap: redacts in place, reports whether anything was changed
+func (r *Redactor) redactMap(fields map[string]any) bool {
+	changed := false
+
+	for key, value := range fields {
+		if r.sensitiveKey(key) {
+			fields[key] = r.replacement
+			changed = true
+			continue
+		}
+
+		redacted, valueChanged := r.redactValue(value)
+		if valueChanged {
+			fields[key] = redacted
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+func (r *Redactor) redactValue(value any) (any, bool) {
+	switch v := value.(type) {
+	case string:
+		redacted := r.RedactString(v)
+		return redacted, redacted != v
+	case map[string]any:
+		return v, r.redactMap(v)
+	case []any:
+		changed := false
+		for i, item := range v {
+			redacted, itemChanged := r.redactValue(item)
+			if itemChanged {
+				v[i] = redacted
+				changed = true
+			}
+		}
+		return v, changed
+	default:
+		return value, false
+	}
+}
+
+func (r *Redactor) sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range r.keys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactingWriter: redacts every event before passing it to the next writer
+type redactingWriter struct {
+	next     zerolog.LevelWriter
+	redactor *Redactor
+}
+
+func newRedactingWriter(next io.Writer, redactor *Redactor) zerolog.LevelWriter {
+	lw, ok := next.(zerolog.LevelWriter)
+	if !ok {
+		lw = zerolog.LevelWriterAdapter{Writer: next}
+	}
+	return &redactingWriter{next: lw, redactor: redactor}
+}
+
+// Write implements io.Writer
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (w *redactingWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if _, err := w.next.WriteLevel(level, w.redactor.RedactJSON(p)); err != nil {
+		return 0, err
+	}
+	// zerolog expects the length of the original event
+	return len(p), nil
+}
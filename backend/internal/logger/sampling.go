@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// @dev log sampling: keep 1 in N events of the noisy levels (trace/debug/info), error and above always go through
+// @dev sampled out events never reach the writers (New Relic forwarding included), so forwarding quota is saved as well
+
+// newSampler: builds the per level sampler from config, nil when sampling is disabled
+func newSampler(cfg *config.LogSamplingConfig) zerolog.Sampler {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	return &zerolog.LevelSampler{
+		TraceSampler: levelSampler(cfg, cfg.Trace),
+		DebugSampler: levelSampler(cfg, cfg.Debug),
+		InfoSampler:  levelSampler(cfg, cfg.Info),
+		WarnSampler:  levelSampler(cfg, cfg.Warn),
+		// ErrorSampler stays nil -> every error is kept
+	}
+}
+
+// levelSampler: 1 in n sampler, with an unsampled burst in front of it when configured
+func levelSampler(cfg *config.LogSamplingConfig, n uint32) zerolog.Sampler {
+	if n <= 1 {
+		return nil
+	}
+
+	basic := &zerolog.BasicSampler{N: n}
+	if cfg.Burst == 0 {
+		return basic
+	}
+
+	return &zerolog.BurstSampler{
+		Burst:       cfg.Burst,
+		Period:      cfg.Period,
+		NextSampler: basic,
+	}
+}
@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/rs/zerolog"
+)
+
+// @dev slog bridge: third-party libraries logging through log/slog end up in the same zerolog logger
+// @dev so they get the same output format, redaction and New Relic forwarding as application logs
+
+// SlogHandler: slog.Handler backed by a zerolog logger
+type SlogHandler struct {
+	logger  zerolog.Logger
+	attrs   []groupedAttr // added with WithAttrs, applied to every record
+	group   string        // prefix of attribute keys, "group." for every open group
+	datadog bool          // dd.trace_id / dd.span_id of the OTel span instead of the New Relic ids
+}
+
+// groupedAttr: attribute with the group prefix which was open when it was added
+type groupedAttr struct {
+	prefix string
+	attr   slog.Attr
+}
+
+// NewSlogHandler: creates the slog handler writing into logger
+func NewSlogHandler(logger zerolog.Logger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+// SetSlogDefault: makes logger the backend of slog.Default() and of the stdlib log package
+// records of contexts without request logger get the trace ids of cfg.Provider
+func SetSlogDefault(logger zerolog.Logger, cfg *config.ObservabilityConfig) {
+	handler := NewSlogHandler(logger)
+	handler.datadog = cfg.IsDatadog()
+	slog.SetDefault(slog.New(handler))
+}
+
+// Enabled implements slog.Handler
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return zerologLevel(level) >= h.logger.GetLevel()
+}
+
+// Handle implements slog.Handler
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	logger := h.logger
+
+	// request scoped logger carries request id and trace ids already, handler attrs are added on top of it
+	if ctxLogger := zerolog.Ctx(ctx); ctxLogger != zerolog.DefaultContextLogger && ctxLogger.GetLevel() != zerolog.Disabled {
+		logger = *ctxLogger
+	} else if h.datadog {
+		logger = WithDatadogTraceContext(logger, ctx)
+	} else if txn := newrelic.FromContext(ctx); txn != nil {
+		logger = WithTraceContext(logger, txn)
+	}
+
+	event := logger.WithLevel(zerologLevel(record.Level))
+	if event == nil {
+		return nil
+	}
+
+	for _, ga := range h.attrs {
+		appendAttr(event, ga.prefix, ga.attr)
+	}
+
+	record.Attrs(func(attr slog.Attr) bool {
+		appendAttr(event, h.group, attr)
+		return true
+	})
+
+	event.Msg(record.Message)
+	return nil
+}
+
+// WithAttrs implements slog.Handler
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	merged := make([]groupedAttr, 0, len(h.attrs)+len(attrs))
+	merged = append(merged, h.attrs...)
+	for _, attr := range attrs {
+		merged = append(merged, groupedAttr{prefix: h.group, attr: attr})
+	}
+	return &SlogHandler{logger: h.logger, attrs: merged, group: h.group, datadog: h.datadog}
+}
+
+// WithGroup implements slog.Handler
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, attrs: h.attrs, group: h.group + name + ".", datadog: h.datadog}
+}
+
+// appendAttr: adds a slog attribute to the event, groups are flattened into dotted keys
+func appendAttr(event *zerolog.Event, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	key := prefix + attr.Key
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			appendAttr(event, groupPrefix, groupAttr)
+		}
+	case slog.KindString:
+		event.Str(key, attr.Value.String())
+	case slog.KindInt64:
+		event.Int64(key, attr.Value.Int64())
+	case slog.KindUint64:
+		event.Uint64(key, attr.Value.Uint64())
+	case slog.KindFloat64:
+		event.Float64(key, attr.Value.Float64())
+	case slog.KindBool:
+		event.Bool(key, attr.Value.Bool())
+	case slog.KindDuration:
+		event.Dur(key, attr.Value.Duration())
+	case slog.KindTime:
+		event.Time(key, attr.Value.Time())
+	default:
+		if err, ok := attr.Value.Any().(error); ok {
+			event.AnErr(key, err)
+			return
+		}
+		event.Interface(key, attr.Value.Any())
+	}
+}
+
+// zerologLevel: converts slog level to zerolog level, levels between the named ones round down
+func zerologLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zerolog.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zerolog.WarnLevel
+	case level >= slog.LevelInfo:
+		return zerolog.InfoLevel
+	case level >= slog.LevelDebug:
+		return zerolog.DebugLevel
+	default:
+		return zerolog.TraceLevel
+	}
+}
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// @dev syslog output: every event is sent as an RFC5424 message with the JSON event as MSG part
+// @dev stdlib log/syslog only speaks the old BSD format, so the message is formatted here
+// @dev TCP uses octet counting framing (RFC6587), UDP and unix sockets send one message per datagram
+// @dev messages go out from a background goroutine (netWriter), a down syslog server doesn't slow down logging
+
+// syslogFacilities: facility codes from RFC5424 section 6.2.1
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogWriter: zerolog writer sending RFC5424 messages, reconnects when the connection breaks
+type syslogWriter struct {
+	network  string
+	facility int
+	appName  string
+	hostname string
+	procID   string
+	sender   *netWriter
+}
+
+func newSyslogWriter(cfg *config.ObservabilityConfig) (*syslogWriter, error) {
+	facility, ok := syslogFacilities[cfg.Logging.Syslog.Facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", cfg.Logging.Syslog.Facility)
+	}
+
+	appName := cfg.Logging.Syslog.AppName
+	if appName == "" {
+		appName = cfg.ServiceName
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-" // nil value in RFC5424
+	}
+
+	return &syslogWriter{
+		network:  cfg.Logging.Syslog.Network,
+		facility: facility,
+		appName:  appName,
+		hostname: hostname,
+		procID:   strconv.Itoa(os.Getpid()),
+		sender: newNetWriter("syslog", cfg.Logging.Syslog.Network, cfg.Logging.Syslog.Address, func(conn net.Conn, msg []byte) error {
+			_, err := conn.Write(msg)
+			return err
+		}),
+	}, nil
+}
+
+// Write implements io.Writer
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter, never blocks the caller
+func (w *syslogWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	w.sender.send(w.format(level, bytes.TrimRight(p, "\n")))
+	return len(p), nil
+}
+
+// format: <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (w *syslogWriter) format(level zerolog.Level, event []byte) []byte {
+	priority := w.facility*8 + syslogSeverity(level)
+
+	header := fmt.Sprintf("<%d>1 %s %s %s %s - - ",
+		priority,
+		time.Now().Format(time.RFC3339Nano),
+		w.hostname,
+		w.appName,
+		w.procID,
+	)
+
+	msg := append([]byte(header), event...)
+
+	if w.network == "tcp" {
+		// octet counting: "<length> <message>"
+		return append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	return msg
+}
+
+// Close: sends the queued messages and closes the connection to the syslog server
+func (w *syslogWriter) Close() error {
+	w.sender.Close()
+	return nil
+}
+
+// syslogSeverity: converts zerolog level to RFC5424 severity
+func syslogSeverity(level zerolog.Level) int {
+	switch level {
+	case zerolog.PanicLevel:
+		return 1 // alert
+	case zerolog.FatalLevel:
+		return 2 // critical
+	case zerolog.ErrorLevel:
+		return 3 // error
+	case zerolog.WarnLevel:
+		return 4 // warning
+	case zerolog.InfoLevel, zerolog.NoLevel:
+		return 6 // informational
+	default:
+		return 7 // debug
+	}
+}
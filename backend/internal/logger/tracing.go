@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// @dev OTLP traces: for setups without New Relic, spans (e.g. of otelpgx) go to any OpenTelemetry collector
+// @dev the provider is registered globally, so libraries using otel.Tracer(...) export through it as well
+
+// newOTLPTracerProvider: batching OTLP/HTTP trace exporter with parent based ratio sampling
+func newOTLPTracerProvider(cfg *config.ObservabilityConfig) (*sdktrace.TracerProvider, error) {
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpointURL(cfg.Tracing.OTLP.Endpoint),
+	}
+	if cfg.Tracing.OTLP.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Tracing.OTLP.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(cfg.Tracing.OTLP.Headers))
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp trace exporter: %w", err)
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+		attribute.String("deployment.environment", cfg.Environment),
+		attribute.String("service.version", ProcessMetadata().Version),
+	)
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider, nil
+}
+
+// TracerProvider: the OTel tracer provider, nil unless traces are exported over OTLP
+func (ls *LoggerService) TracerProvider() trace.TracerProvider {
+	if ls.tracerProvider == nil {
+		return nil
+	}
+	return ls.tracerProvider
+}
@@ -0,0 +1,152 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	goredis "github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+/**
+@dev maintenance mode: the API answers 503 while operators migrate or repair, probes and /admin keep working
+
+server.maintenance.enabled       → on from startup, e.g. a replica which must not take traffic yet
+PUT /admin/maintenance {"message": "database upgrade", "duration": "30m"}
+    → SET <server.maintenance.key> {"message":...,"until":...} EX 1800, every replica turns it on
+      within server.maintenance.poll_interval, it ends by itself when the key expires
+DELETE /admin/maintenance        → DEL of the key, off again (the config flag stays on if set)
+
+a replica which can't read the key keeps its last state, a redis outage doesn't flip the API
+*/
+
+// ErrNoKey: server.maintenance.key is empty, only the config flag switches
+var ErrNoKey = errors.New("maintenance: no redis key configured")
+
+// State: whether the API is in maintenance, and why
+type State struct {
+	Enabled bool      `json:"enabled"`
+	Message string    `json:"message,omitempty"`
+	Until   time.Time `json:"until,omitzero"`   // zero without end time
+	Source  string    `json:"source,omitempty"` // "config" or "redis"
+}
+
+// Switch: the maintenance state of the config flag and the redis key
+type Switch struct {
+	cfg    config.MaintenanceConfig
+	client goredis.UniversalClient
+
+	group     singleflight.Group    // one redis read at a time, requests arriving meanwhile share it
+	cached    atomic.Pointer[State] // last known state, nil before the first read
+	checkedAt atomic.Int64          // unix nanoseconds of the last read
+}
+
+// New: switch of cfg, client may be nil without key
+func New(cfg config.MaintenanceConfig, client goredis.UniversalClient) *Switch {
+	return &Switch{cfg: cfg, client: client}
+}
+
+// stored: value of the redis key
+type stored struct {
+	Message string    `json:"message,omitempty"`
+	Until   time.Time `json:"until,omitzero"`
+}
+
+// State: current state, the redis key is read at most once per poll interval
+// the error is a failed read, the state is the last known one then
+func (s *Switch) State(ctx context.Context) (State, error) {
+	if s.cfg.Enabled {
+		return State{Enabled: true, Source: "config"}, nil
+	}
+	if s.cfg.Key == "" || s.client == nil {
+		return State{}, nil
+	}
+
+	if cached := s.cached.Load(); cached != nil && time.Since(time.Unix(0, s.checkedAt.Load())) < s.cfg.PollInterval {
+		return *cached, nil
+	}
+
+	// the read is shared, a request giving up doesn't fail it for the others
+	state, err, _ := s.group.Do(s.cfg.Key, func() (any, error) {
+		return s.refresh(context.WithoutCancel(ctx))
+	})
+	return state.(State), err
+}
+
+// refresh: reads the redis key, a failed read keeps the last known state
+func (s *Switch) refresh(ctx context.Context) (State, error) {
+	var last State
+	if cached := s.cached.Load(); cached != nil {
+		last = *cached
+	}
+	// the next request reads again after the interval, a failing redis isn't hit by every request
+	s.cached.CompareAndSwap(nil, &last)
+	s.checkedAt.Store(time.Now().UnixNano())
+
+	value, err := s.client.Get(ctx, s.cfg.Key).Result()
+	switch {
+	case errors.Is(err, goredis.Nil):
+		s.remember(State{})
+		return State{}, nil
+	case err != nil:
+		return last, fmt.Errorf("maintenance: read %s: %w", s.cfg.Key, err)
+	}
+	state := decode(value)
+	s.remember(state)
+	return state, nil
+}
+
+// Enable: turns maintenance on for every replica, for duration or until Disable when 0
+func (s *Switch) Enable(ctx context.Context, message string, duration time.Duration) (State, error) {
+	if s.cfg.Key == "" || s.client == nil {
+		return State{}, ErrNoKey
+	}
+
+	value := stored{Message: message}
+	if duration > 0 {
+		value.Until = time.Now().Add(duration).UTC().Truncate(time.Second)
+	}
+	body, err := json.Marshal(value)
+	if err != nil {
+		return State{}, fmt.Errorf("maintenance: encode: %w", err)
+	}
+	if err := s.client.Set(ctx, s.cfg.Key, body, duration).Err(); err != nil {
+		return State{}, fmt.Errorf("maintenance: enable: %w", err)
+	}
+
+	state := State{Enabled: true, Message: value.Message, Until: value.Until, Source: "redis"}
+	s.remember(state)
+	return state, nil
+}
+
+// Disable: removes the redis key, the config flag can't be turned off at runtime
+func (s *Switch) Disable(ctx context.Context) error {
+	if s.cfg.Key == "" || s.client == nil {
+		return ErrNoKey
+	}
+	if err := s.client.Del(ctx, s.cfg.Key).Err(); err != nil {
+		return fmt.Errorf("maintenance: disable: %w", err)
+	}
+	s.remember(State{})
+	return nil
+}
+
+// remember: the replica which switched sees the change right away, the others after their poll interval
+func (s *Switch) remember(state State) {
+	s.cached.Store(&state)
+	s.checkedAt.Store(time.Now().UnixNano())
+}
+
+// decode: a value set by hand (SET maintenance 1) turns it on as well
+func decode(value string) State {
+	var v stored
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return State{Enabled: true, Source: "redis"}
+	}
+	return State{Enabled: true, Message: v.Message, Until: v.Until, Source: "redis"}
+}
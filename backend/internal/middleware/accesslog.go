@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/clientip"
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/anuragShingare30/go-boilerplate/internal/router"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// @dev access log: one line per request once the response is written, logged through the request scoped logger
+// @dev so it carries request_id and trace.id like every other line of the request
+
+// AccessLog: logs method, route, status, bytes and latency of every request after it in the chain
+// use it right after RequestID and before Recover, so the 500 of a recovered panic is logged as well
+func AccessLog(cfg config.AccessLogConfig) func(http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	// the level is validated by config, unknown values fall back to info
+	level, _ := loggerConfig.ParseLevel(cfg.Level)
+	exclude := make(map[string]struct{}, len(cfg.Exclude))
+	for _, path := range cfg.Exclude {
+		exclude[path] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := exclude[r.URL.Path]; ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rw := &responseRecorder{ResponseWriter: w}
+			defer func() {
+				logRequest(r, rw, time.Since(start), level)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// logRequest: the access log line, 5xx are errors whatever the configured level is
+func logRequest(r *http.Request, rw *responseRecorder, latency time.Duration, level zerolog.Level) {
+	status := rw.Status()
+	if status >= http.StatusInternalServerError {
+		level = zerolog.ErrorLevel
+	}
+
+	ctx := r.Context()
+	event := loggerConfig.FromContext(ctx).WithLevel(level).
+		Str("method", r.Method).
+		Str("route", router.RoutePattern(r)).
+		Str("path", r.URL.Path).
+		Int("status", status).
+		Int64("bytes", rw.bytes).
+		Dur("latency", latency).
+		Str("remote_ip", remoteIP(r)).
+		Str("user_agent", r.UserAgent())
+	// the proxy the request came through, when the client ip was taken from its headers
+	if peer := peerIP(r); peer != remoteIP(r) {
+		event = event.Str("peer_ip", peer)
+	}
+
+	// with New Relic trace.id is on the logger already, without it the OpenTelemetry span is used
+	if newrelic.FromContext(ctx) == nil {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			event = event.Str("trace.id", spanContext.TraceID().String())
+		}
+	}
+
+	event.Msg("request")
+}
+
+// remoteIP: client ip of ClientIP, without it the host part of the peer address (the load balancer's one behind it)
+func remoteIP(r *http.Request) string {
+	if ip := clientip.FromContext(r.Context()); ip != "" {
+		return ip
+	}
+	return peerIP(r)
+}
+
+// peerIP: host part of the peer address
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// responseRecorder: remembers status and body size of the response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Status: status code sent, 200 when the handler wrote nothing
+func (w *responseRecorder) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Unwrap: lets http.ResponseController reach Flush / Hijack of the underlying writer
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
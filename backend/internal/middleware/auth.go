@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// @dev auth middlewares: guard routes before the request reaches the handler
+
+// RequireAdminToken: allows the request only when it carries "Authorization: Bearer <secret>"
+// secret is the auth.secret_key from config, used for internal admin endpoints
+func RequireAdminToken(secret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			// constant time compare, so the secret can't be guessed from response timings
+			if !ok || secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, r, http.StatusUnauthorized, "unauthorized", "authentication required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/anuragShingare30/go-boilerplate/internal/router"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev body capture: what the client sent and got back, for the requests someone asked for
+
+server.body_capture.routes: ["POST /orders"]   → every request of the route
+X-Debug-Capture: 1 (server.body_capture.header) → that one request, not in production
+    → one "http body capture" line with request_body / response_body, both cut at max_bytes
+    → JSON bodies go through the log redaction key by key (password, token, ...), other text through its patterns
+    → media types outside content_types (uploads, images) are logged with their size only
+
+the request body is captured as the handler reads it, nothing is read which the handler doesn't read
+*/
+
+// BodyCapture: logs the bodies of the captured requests, a pass-through when disabled
+// redactor is the one of the log writers (LoggerService.Redactor), nil logs the bodies as they are
+func BodyCapture(cfg config.BodyCaptureConfig, env string, redactor *loggerConfig.Redactor) (func(http.Handler) http.Handler, error) {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }, nil
+	}
+
+	routes := http.NewServeMux()
+	for _, pattern := range cfg.Routes {
+		if err := registerPattern(routes, pattern); err != nil {
+			return nil, fmt.Errorf("server.body_capture.routes: %w", err)
+		}
+	}
+	// production traffic is captured by configured routes only, a header can't turn it on
+	header := cfg.Header
+	if env == "production" {
+		header = ""
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if matchPattern(routes, r) == "" && (header == "" || r.Header.Get(header) == "") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reqBody := &captureReader{ReadCloser: r.Body, captureBuffer: captureBuffer{max: cfg.MaxBytes}}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = reqBody
+			}
+			cw := &captureWriter{ResponseWriter: w, captureBuffer: captureBuffer{max: cfg.MaxBytes}}
+			defer func() {
+				logBodies(r, cw, reqBody, cfg.ContentTypes, redactor)
+			}()
+
+			next.ServeHTTP(cw, r)
+		})
+	}, nil
+}
+
+// logBodies: the capture line, through the request scoped logger so it has the request id
+func logBodies(r *http.Request, cw *captureWriter, reqBody *captureReader, contentTypes []string, redactor *loggerConfig.Redactor) {
+	status := cw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	event := loggerConfig.FromContext(r.Context()).Info().
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Str("route", router.RoutePattern(r)).
+		Int("status", status)
+	addBody(event, "request", r.Header.Get("Content-Type"), &reqBody.captureBuffer, contentTypes, redactor)
+	addBody(event, "response", cw.Header().Get("Content-Type"), &cw.captureBuffer, contentTypes, redactor)
+	event.Msg("http body capture")
+}
+
+// addBody: <name>_body (object for JSON, string otherwise), <name>_bytes and <name>_truncated
+func addBody(event *zerolog.Event, name, contentType string, body *captureBuffer, contentTypes []string, redactor *loggerConfig.Redactor) {
+	event.Int64(name+"_bytes", body.total)
+	if body.total == 0 {
+		return
+	}
+	if contentType != "" {
+		event.Str(name+"_content_type", contentType)
+	}
+	if !capturedType(contentType, contentTypes) {
+		return
+	}
+	if body.truncated() {
+		event.Bool(name+"_truncated", true)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	// a line break would split the log line, the encoder of httpx.JSON ends bodies with one
+	data := bytes.TrimSpace(body.buf.Bytes())
+
+	switch {
+	case isJSON && json.Valid(data):
+		if redactor != nil {
+			data = redactor.RedactJSON(data)
+		}
+		event.RawJSON(name+"_body", data)
+	case redactor == nil:
+		event.Str(name+"_body", string(data))
+	case isJSON:
+		// cut off at max_bytes, no document to walk
+		event.Str(name+"_body", redactor.RedactPartialJSON(string(data)))
+	default:
+		event.Str(name+"_body", redactor.RedactString(string(data)))
+	}
+}
+
+// capturedType: contentType is in the allowlist, "text/*" covers every text type
+func capturedType(contentType string, allowed []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, candidate := range allowed {
+		if candidate == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(candidate, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// captureBuffer: the first max bytes of a body and its full size
+type captureBuffer struct {
+	max   int
+	buf   bytes.Buffer
+	total int64
+}
+
+func (b *captureBuffer) write(p []byte) {
+	b.total += int64(len(p))
+	if room := b.max - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(room, len(p))])
+	}
+}
+
+func (b *captureBuffer) truncated() bool {
+	return b.total > int64(b.buf.Len())
+}
+
+// captureReader: keeps what the handler reads of the request body
+type captureReader struct {
+	io.ReadCloser
+	captureBuffer
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.write(p[:n])
+	return n, err
+}
+
+// captureWriter: keeps what the handler writes of the response body
+type captureWriter struct {
+	http.ResponseWriter
+	captureBuffer
+	status int
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	if w.status == 0 && status >= 200 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.write(b[:n])
+	return n, err
+}
+
+// Unwrap: lets http.ResponseController reach Flush / Hijack of the underlying writer
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
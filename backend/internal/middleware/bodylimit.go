@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+)
+
+/**
+@dev body limit: request bodies larger than server.body_limit.max_bytes (or the size of the route) are refused with 413
+
+Content-Length over the limit → 413 right away, the body is never read
+otherwise the body is an http.MaxBytesReader
+    → reading past the limit fails with *http.MaxBytesError, the connection is closed after the response
+    → a handler which returns without answering gets the 413 envelope, handlers answering themselves keep their response
+*/
+
+// BodyLimit: caps request bodies of routes after it, per route sizes are keyed by router pattern
+func BodyLimit(cfg config.BodyLimitConfig) (func(http.Handler) http.Handler, error) {
+	routes := http.NewServeMux()
+	for pattern := range cfg.Routes {
+		if err := registerPattern(routes, pattern); err != nil {
+			return nil, fmt.Errorf("server.body_limit.routes: %w", err)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := cfg.MaxBytes
+			if pattern := matchPattern(routes, r); pattern != "" {
+				limit = cfg.Routes[pattern]
+			}
+			if limit <= 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > limit {
+				writeBodyTooLarge(w, r, limit)
+				return
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit)}
+			r.Body = body
+			rw := &headerTracker{ResponseWriter: w}
+
+			next.ServeHTTP(rw, r)
+
+			if body.exceeded && !rw.wroteHeader {
+				writeBodyTooLarge(rw, r, limit)
+			}
+		})
+	}, nil
+}
+
+// writeBodyTooLarge: 413 envelope naming the limit
+func writeBodyTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	writeError(w, r, http.StatusRequestEntityTooLarge, "body_too_large", "request body larger than "+strconv.FormatInt(limit, 10)+" bytes")
+}
+
+// limitedBody: remembers whether a read hit the limit
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/clientip"
+)
+
+// @dev client ip: resolved once per request from the peer address and the headers of the trusted proxies (internal/clientip)
+// @dev the rate limit, the access log (remote_ip) and audit records read it from the context
+
+// ClientIP: stores the client ip of the request in its context, use it right after RequestID
+func ClientIP(resolver *clientip.Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := clientip.ContextWithIP(r.Context(), resolver.ClientIP(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
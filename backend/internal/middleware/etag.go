@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/etag"
+)
+
+/**
+@dev ETags of GET / HEAD responses: the 200 body is held back, tagged with its hash and compared with If-None-Match
+
+GET /version                           → 200, ETag: "q3Fz..."
+GET /version, If-None-Match: "q3Fz..." → 304 without body, the handler ran but nothing is sent
+
+an ETag or Last-Modified set by the handler (etag.NotModified) is used as it is instead of the hash
+other statuses, bodies over server.etag.max_bytes and flushed streams pass through untagged
+HEAD without body keeps the headers of its handler, only an ETag / Last-Modified of the handler can 304 it
+*/
+
+// ETag: tags and 304s for the responses of the handlers after it
+func ETag(cfg config.ETagConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &etagWriter{ResponseWriter: w, maxBytes: cfg.MaxBytes}
+			next.ServeHTTP(ew, r)
+			ew.finish(r, cfg.Weak)
+		})
+	}
+}
+
+// etagWriter: buffers a 200 response until the handler returns
+type etagWriter struct {
+	http.ResponseWriter
+	maxBytes    int64
+	status      int
+	wroteHeader bool
+	// passthrough: the response goes out as written, without tag
+	passthrough bool
+	buf         bytes.Buffer
+}
+
+func (w *etagWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	// 1xx are interim responses, the final status comes later
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	if status != http.StatusOK {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.maxBytes > 0 && int64(w.buf.Len()+len(b)) > w.maxBytes {
+		if err := w.startPassthrough(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+// Flush: a stream wants its bytes out now, it is sent without tag
+func (w *etagWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := w.startPassthrough(); err != nil {
+		return
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap: lets http.ResponseController reach Hijack / deadlines of the underlying writer
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// startPassthrough: sends the held back status and body, later writes go straight through
+func (w *etagWriter) startPassthrough() error {
+	if w.passthrough {
+		return nil
+	}
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// finish: tag of the buffered body, 304 when the client has it already
+func (w *etagWriter) finish(r *http.Request, weak bool) {
+	// nothing written: net/http sends its empty 200
+	if w.passthrough || !w.wroteHeader {
+		return
+	}
+
+	header := w.Header()
+	// a handler answering HEAD itself (http.ServeContent) writes no body, the hash of nothing isn't the tag of the GET
+	// and its Content-Length is the one of the GET body
+	bodiless := r.Method == http.MethodHead && w.buf.Len() == 0
+	tag := header.Get("ETag")
+	if tag == "" && !bodiless {
+		if weak {
+			tag = etag.Weak(w.buf.Bytes())
+		} else {
+			tag = etag.Strong(w.buf.Bytes())
+		}
+		header.Set("ETag", tag)
+	}
+
+	var modified time.Time
+	if lastModified := header.Get("Last-Modified"); lastModified != "" {
+		modified, _ = http.ParseTime(lastModified)
+	}
+
+	if etag.Fresh(r.Header, tag, modified) {
+		etag.WriteNotModified(w.ResponseWriter)
+		return
+	}
+	if !bodiless {
+		header.Set("Content-Length", strconv.Itoa(w.buf.Len()))
+	}
+	w.ResponseWriter.WriteHeader(http.StatusOK)
+	_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/idempotency"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+)
+
+/**
+@dev Idempotency-Key: a retried POST (timeout, dropped connection) gets the response of the first one instead of running twice
+
+POST /payments, Idempotency-Key: k1   → handler runs, 201 stored for server.idempotency.ttl
+POST /payments, Idempotency-Key: k1   → 201 replayed with Idempotent-Replayed: true, the handler doesn't run
+POST /payments, Idempotency-Key: k1 while the first still runs → 409, retry later
+POST /payments, Idempotency-Key: k1 with another body          → 422, a key belongs to one request
+POST /payments without the header on a server.idempotency.required route → 400
+
+keys are scoped to method, path and Authorization, clients can't replay the responses of each other
+runs after BodyLimit, the body is read in full for the fingerprint
+*/
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+	maxIdempotencyKey = 255
+)
+
+// notReplayed: headers of the first response which belong to it alone
+var notReplayed = []string{"Date", "Content-Length", "Set-Cookie", "Retry-After", "X-Request-Id",
+	"Ratelimit-Limit", "Ratelimit-Remaining", "Ratelimit-Reset", "Ratelimit-Policy"}
+
+// Idempotency: stores and replays the responses of requests with an Idempotency-Key in store
+func Idempotency(cfg config.IdempotencyConfig, store idempotency.Store) (func(http.Handler) http.Handler, error) {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }, nil
+	}
+
+	required := http.NewServeMux()
+	for _, pattern := range cfg.Required {
+		if err := registerPattern(required, pattern); err != nil {
+			return nil, fmt.Errorf("server.idempotency.required: %w", err)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(cfg.Methods, r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.Header.Get(idempotencyHeader)
+			if key == "" {
+				if matchPattern(required, r) != "" {
+					writeError(w, r, http.StatusBadRequest, "idempotency_key_required", "this route needs an "+idempotencyHeader+" header")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKey {
+				writeError(w, r, http.StatusBadRequest, "invalid_idempotency_key", idempotencyHeader+" is longer than 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				// over the body limit of BodyLimit, other failures are a client which broke off the upload
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					writeBodyTooLarge(w, r, maxBytesErr.Limit)
+					return
+				}
+				writeError(w, r, http.StatusBadRequest, "invalid_body", "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			serveIdempotent(w, r, next, cfg, store, scopedKey(r, key), fingerprint(r, body))
+		})
+	}, nil
+}
+
+// serveIdempotent: runs next once per key, replays the stored response afterwards
+func serveIdempotent(w http.ResponseWriter, r *http.Request, next http.Handler, cfg config.IdempotencyConfig, store idempotency.Store, key, fp string) {
+	ctx := r.Context()
+
+	existing, reserved, err := store.Reserve(ctx, key, fp, cfg.LockTTL)
+	if err != nil {
+		// unlike the rate limiter this fails closed, running a payment twice is worse than a retry later
+		loggerConfig.Err(ctx, err).Msg("idempotency store failed")
+		w.Header().Set("Retry-After", "1")
+		writeError(w, r, http.StatusServiceUnavailable, "idempotency_unavailable", "request can't be made idempotent right now, retry later")
+		return
+	}
+
+	if !reserved {
+		switch {
+		case existing.Fingerprint != fp:
+			writeError(w, r, http.StatusUnprocessableEntity, "idempotency_key_reused", idempotencyHeader+" was used for another request")
+		case existing.Pending():
+			w.Header().Set("Retry-After", "1")
+			writeError(w, r, http.StatusConflict, "idempotency_in_progress", "a request with this "+idempotencyHeader+" is still running")
+		default:
+			replay(w, existing)
+		}
+		return
+	}
+
+	rec := &idempotencyRecorder{ResponseWriter: w, maxBytes: cfg.MaxBodyBytes}
+	// the key outlives a cancelled request, the work it did may have happened
+	storeCtx := context.WithoutCancel(ctx)
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+		// panic (re-raised for Recover) or a response which can't be replayed: the next retry runs again
+		if err := store.Release(storeCtx, key, fp); err != nil {
+			loggerConfig.Err(ctx, err).Msg("failed to release idempotency key")
+		}
+	}()
+
+	next.ServeHTTP(rec, r)
+
+	if rec.status == 0 || rec.status >= http.StatusInternalServerError || rec.overflow {
+		if rec.overflow {
+			loggerConfig.FromContext(ctx).Warn().Int64("max_body_bytes", cfg.MaxBodyBytes).Msg("response too large to store for idempotency key")
+		}
+		return
+	}
+
+	record := idempotency.Record{
+		Fingerprint: fp,
+		Status:      rec.status,
+		Header:      replayHeader(rec.header),
+		Body:        rec.body.Bytes(),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := store.Complete(storeCtx, key, record, cfg.TTL); err != nil {
+		loggerConfig.Err(ctx, err).Msg("failed to store idempotent response")
+		return
+	}
+	completed = true
+}
+
+// replay: the stored response, marked so clients can tell it from a fresh one
+func replay(w http.ResponseWriter, record idempotency.Record) {
+	header := w.Header()
+	for name, values := range record.Header {
+		header[name] = values
+	}
+	header.Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.Status)
+	_, _ = w.Write(record.Body)
+}
+
+func replayHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range notReplayed {
+		header.Del(name)
+	}
+	return header
+}
+
+// scopedKey: key of the client below method, path and credentials, the token itself is never stored
+// global middlewares run before routing, the path stands in for the route pattern
+func scopedKey(r *http.Request, key string) string {
+	auth := sha256.Sum256([]byte(r.Header.Get("Authorization")))
+	return r.Method + ":" + r.URL.Path + ":" + hex.EncodeToString(auth[:8]) + ":" + key
+}
+
+// fingerprint: what makes a request the same request, method, path with query and body
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyRecorder: passes the response through and keeps a copy of it
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	maxBytes int64
+	status   int
+	header   http.Header // as of WriteHeader, later changes don't reach the client either
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *idempotencyRecorder) WriteHeader(status int) {
+	if w.status == 0 && status >= 200 {
+		w.status = status
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *idempotencyRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.overflow {
+		if w.maxBytes > 0 && int64(w.body.Len()+len(b)) > w.maxBytes {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap: lets http.ResponseController reach Flush / Hijack of the underlying writer
+func (w *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/anuragShingare30/go-boilerplate/internal/maintenance"
+)
+
+// @dev maintenance mode: 503 with Retry-After while the switch is on, server.maintenance.exclude is served as usual
+// @dev runs before the rate limit, rejected requests don't use up the budget of the clients
+
+const maintenanceMessage = "service is under maintenance, retry later"
+
+// Maintenance: answers with 503 while sw is on
+func Maintenance(cfg config.MaintenanceConfig, sw *maintenance.Switch) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range cfg.Exclude {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			ctx := r.Context()
+			state, err := sw.State(ctx)
+			if err != nil {
+				loggerConfig.Err(ctx, err).Msg("maintenance check failed, last state used")
+			}
+			if !state.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			retryAfter := cfg.RetryAfter
+			if !state.Until.IsZero() {
+				retryAfter = max(time.Until(state.Until), time.Second)
+			}
+			message := maintenanceMessage
+			if state.Message != "" {
+				message = state.Message
+			}
+
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+			writeError(w, r, http.StatusServiceUnavailable, "maintenance", message)
+		})
+	}
+}
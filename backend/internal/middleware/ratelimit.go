@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/anuragShingare30/go-boilerplate/internal/ratelimit"
+)
+
+/**
+@dev rate limiting: server.rate_limit.requests per window and client ip, plus the limits of server.rate_limit.routes
+
+request → route rule of the matching pattern (key "route:<pattern>:<ip>")
+        → global limit (key "ip:<ip>")
+    → over one of them: 429 with Retry-After, the handler doesn't run
+    → every limited response carries RateLimit-Limit / -Remaining / -Reset of the tightest limit
+      and RateLimit-Policy with all of them, e.g. "5;w=60, 100;w=60"
+
+a failing limiter (redis down) lets the request through, the outage is logged instead of taking the api down
+*/
+
+// rateLimitRule: one limit checked per request
+type rateLimitRule struct {
+	key      string
+	requests int
+	window   time.Duration
+}
+
+// RateLimit: limits requests per client ip with limiter (ratelimit.New), a pass-through when disabled
+// route patterns are the ones of the router ("POST /auth/login"), invalid ones are returned as error
+func RateLimit(cfg config.RateLimitConfig, limiter ratelimit.Limiter) (func(http.Handler) http.Handler, error) {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }, nil
+	}
+
+	// routes are matched like the router matches them, the mux only answers which pattern a request has
+	routes := http.NewServeMux()
+	for pattern := range cfg.Routes {
+		if err := registerPattern(routes, pattern); err != nil {
+			return nil, fmt.Errorf("server.rate_limit.routes: %w", err)
+		}
+	}
+
+	exclude := make(map[string]struct{}, len(cfg.Exclude))
+	for _, path := range cfg.Exclude {
+		exclude[path] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := exclude[r.URL.Path]; ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ip := remoteIP(r)
+			rules := make([]rateLimitRule, 0, 2)
+			if pattern := matchPattern(routes, r); pattern != "" {
+				if rule, ok := cfg.Routes[pattern]; ok {
+					rules = append(rules, rateLimitRule{key: "route:" + pattern + ":" + ip, requests: rule.Requests, window: rule.Window})
+				}
+			}
+			rules = append(rules, rateLimitRule{key: "ip:" + ip, requests: cfg.Requests, window: cfg.Window})
+
+			if !checkRateLimits(w, r, limiter, rules) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// checkRateLimits: checks every rule and sets the RateLimit headers, false when the request was answered with 429
+func checkRateLimits(w http.ResponseWriter, r *http.Request, limiter ratelimit.Limiter, rules []rateLimitRule) bool {
+	ctx := r.Context()
+	var tightest *ratelimit.Result
+	policies := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		policies = append(policies, strconv.Itoa(rule.requests)+";w="+strconv.Itoa(int(rule.window.Seconds())))
+	}
+
+	for _, rule := range rules {
+		res, err := limiter.Allow(ctx, rule.key, rule.requests, rule.window)
+		if err != nil {
+			loggerConfig.Err(ctx, err).Str("key", rule.key).Msg("rate limit check failed, request let through")
+			continue
+		}
+
+		if tightest == nil || !res.Allowed || res.Remaining < tightest.Remaining {
+			tightest = &res
+		}
+		if !res.Allowed {
+			break
+		}
+	}
+	if tightest == nil {
+		return true
+	}
+
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(tightest.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(tightest.Reset)))
+	h.Set("RateLimit-Policy", strings.Join(policies, ", "))
+
+	if tightest.Allowed {
+		return true
+	}
+	h.Set("Retry-After", strconv.Itoa(ceilSeconds(tightest.RetryAfter)))
+	writeError(w, r, http.StatusTooManyRequests, "rate_limited", "too many requests")
+	return false
+}
+
+// ceilSeconds: headers count whole seconds, rounded up so clients don't retry too early
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// @dev panic recovery: a panicking handler answers 500 instead of killing the connection without response
+// @dev the panic and its stack are logged with the request scoped logger and noticed on the New Relic transaction
+
+// Recover: catches panics of the handlers after it, use it right after RequestID
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &headerTracker{ResponseWriter: w}
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// net/http aborts responses with it on purpose, it must reach the server
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			ctx := r.Context()
+			err, ok := p.(error)
+			if !ok {
+				err = fmt.Errorf("panic: %v", p)
+			}
+
+			loggerConfig.FromContext(ctx).Error().
+				Interface("panic", p).
+				Str("stack", string(debug.Stack())).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Msg("handler panicked")
+
+			if txn := newrelic.FromContext(ctx); txn != nil {
+				txn.NoticeError(newrelic.Error{
+					Message: err.Error(),
+					Class:   "panic",
+				})
+			}
+
+			// with a started response the status is out already, the client sees a cut off body
+			if rw.wroteHeader {
+				return
+			}
+			writeError(rw, r, http.StatusInternalServerError, "internal_error", "internal server error")
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// writeError: the error envelope of httpx with status, in the format the Accept header of r asks for
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	httpx.WriteError(w, r, status, httpx.ErrorDetail{Code: code, Message: message})
+}
+
+// headerTracker: remembers whether the response was started
+type headerTracker struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *headerTracker) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *headerTracker) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap: lets http.ResponseController reach Flush / Hijack of the underlying writer
+func (w *headerTracker) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/oklog/ulid/v2"
+)
+
+// @dev request id: one id per request in the response header, on every log line (request_id) and on the New Relic transaction
+// @dev an inbound X-Request-ID (from the load balancer or the calling service) is kept, so one id follows the request across services
+
+// RequestIDHeader: header the request id is read from and written to
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength: longer inbound ids are replaced, headers end up in every log line
+const maxRequestIDLength = 128
+
+// RequestID: takes the inbound request id or generates a ULID, stores it in the context and the request scoped logger
+// use it as one of the first global middlewares, everything after it logs with the id
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			// ULIDs sort by time, ids of one incident are next to each other
+			requestID = ulid.Make().String()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		if txn := newrelic.FromContext(r.Context()); txn != nil {
+			txn.AddAttribute(loggerConfig.RequestIDField, requestID)
+		}
+
+		next.ServeHTTP(w, r.WithContext(loggerConfig.ContextWithRequestID(r.Context(), requestID)))
+	})
+}
+
+// validRequestID: ids from outside are only trusted when short and made of safe characters (no log injection)
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
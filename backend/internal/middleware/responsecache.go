@@ -0,0 +1,251 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/cache"
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+)
+
+/**
+@dev response cache: GET responses of the routes in server.response_cache.routes served from the cache for their ttl
+
+GET /products/42                  → handler runs, 200 cached, X-Cache: MISS
+GET /products/42 within the ttl   → cached response, X-Cache: HIT, Age: seconds since it was cached
+GET /products/42 with Authorization / Cookie on a route without private → X-Cache: BYPASS, never cached
+PUT /products/42 (invalidated_by) → 2xx drops every cached response of GET /products/{id}, before the client sees it
+
+key: route pattern, generation of the route, hash of path, query, Accept, the vary headers (and credentials when private)
+invalidation bumps the generation, the old entries are unreachable and expire by themselves
+only 200 responses without Set-Cookie or Cache-Control: no-store are cached, a cache outage serves uncached
+*/
+
+const (
+	cacheStatusHeader = "X-Cache"
+	// generation keys outlive the entries of their route (ttl is at most 24h)
+	generationTTL = 48 * time.Hour
+)
+
+// cachedResponse: entry of the response cache
+type cachedResponse struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header,omitempty"`
+	Body     []byte      `json:"body,omitempty"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// ResponseCache: caches the GET responses of cfg.Routes in c, a pass-through when disabled or without cache
+func ResponseCache(cfg config.ResponseCacheConfig, c cache.Cache) (func(http.Handler) http.Handler, error) {
+	if !cfg.Enabled || c == nil {
+		return func(next http.Handler) http.Handler { return next }, nil
+	}
+
+	cached := http.NewServeMux()
+	mutations := http.NewServeMux()
+	invalidates := make(map[string][]string)
+	for pattern, route := range cfg.Routes {
+		if method, _, _ := strings.Cut(pattern, " "); method != http.MethodGet {
+			return nil, fmt.Errorf("server.response_cache.routes: %q is not a GET route", pattern)
+		}
+		if err := registerPattern(cached, pattern); err != nil {
+			return nil, fmt.Errorf("server.response_cache.routes: %w", err)
+		}
+		for _, mutation := range route.InvalidatedBy {
+			if _, ok := invalidates[mutation]; !ok {
+				if err := registerPattern(mutations, mutation); err != nil {
+					return nil, fmt.Errorf("server.response_cache.routes[%s].invalidated_by: %w", pattern, err)
+				}
+			}
+			invalidates[mutation] = append(invalidates[mutation], pattern)
+		}
+	}
+	responses := c.Namespace("responses")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				if pattern := matchPattern(cached, r); pattern != "" {
+					serveCached(w, r, next, responses, pattern, cfg.Routes[pattern], cfg.MaxBodyBytes)
+					return
+				}
+			}
+
+			pattern := matchPattern(mutations, r)
+			if pattern == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			iw := &invalidatingWriter{ResponseWriter: w, invalidate: func() {
+				invalidateRoutes(r.Context(), responses, invalidates[pattern])
+			}}
+			next.ServeHTTP(iw, r)
+			// no WriteHeader at all is an implicit 200
+			if !iw.decided {
+				iw.decided = true
+				iw.invalidate()
+			}
+		})
+	}, nil
+}
+
+// InvalidateResponseCache: drops the cached responses of the GET route patterns, for changes which don't come through a mutation route
+// c is the cache passed to ResponseCache, e.g. cache.Default()
+func InvalidateResponseCache(ctx context.Context, c cache.Cache, patterns ...string) error {
+	return bumpGenerations(ctx, c.Namespace("responses"), patterns)
+}
+
+// invalidateRoutes: the invalidation of a mutation, a failure is logged, the mutation already happened
+func invalidateRoutes(ctx context.Context, responses cache.Cache, patterns []string) {
+	if err := bumpGenerations(context.WithoutCancel(ctx), responses, patterns); err != nil {
+		loggerConfig.Err(ctx, err).Strs("routes", patterns).Msg("response cache invalidation failed")
+	}
+}
+
+// bumpGenerations: new generation of each route, keys of its cached responses change with it
+func bumpGenerations(ctx context.Context, responses cache.Cache, patterns []string) error {
+	generation := []byte(strconv.FormatInt(time.Now().UnixNano(), 36))
+	var errs []error
+	for _, pattern := range patterns {
+		if err := responses.Set(ctx, generationKey(pattern), generation, generationTTL); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// serveCached: cached response of the route, next fills the cache on a miss
+func serveCached(w http.ResponseWriter, r *http.Request, next http.Handler, responses cache.Cache, pattern string, route config.ResponseCacheRoute, maxBytes int64) {
+	ctx := r.Context()
+	if !route.Private && (r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "") {
+		w.Header().Set(cacheStatusHeader, "BYPASS")
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	generation, err := responses.Get(ctx, generationKey(pattern))
+	if err != nil && !errors.Is(err, cache.ErrMiss) {
+		loggerConfig.Err(ctx, err).Msg("response cache unavailable")
+		w.Header().Set(cacheStatusHeader, "BYPASS")
+		next.ServeHTTP(w, r)
+		return
+	}
+	key := responseKey(r, pattern, string(generation), route)
+
+	// Cache-Control: no-cache of the client skips the lookup, the fresh response replaces the entry
+	if !hasDirective(r.Header.Get("Cache-Control"), "no-cache") {
+		entry, err := cache.GetJSON[cachedResponse](ctx, responses, key)
+		switch {
+		case err == nil:
+			writeCached(w, entry)
+			return
+		case !errors.Is(err, cache.ErrMiss):
+			loggerConfig.Err(ctx, err).Msg("response cache read failed")
+		}
+	}
+
+	w.Header().Set(cacheStatusHeader, "MISS")
+	rec := &idempotencyRecorder{ResponseWriter: w, maxBytes: maxBytes}
+	next.ServeHTTP(rec, r)
+	if !storable(rec, route.Private) {
+		return
+	}
+
+	header := replayHeader(rec.header)
+	header.Del(cacheStatusHeader)
+	entry := cachedResponse{Status: rec.status, Header: header, Body: rec.body.Bytes(), StoredAt: time.Now().UTC()}
+	if err := cache.SetJSON(context.WithoutCancel(ctx), responses, key, entry, route.TTL); err != nil {
+		loggerConfig.Err(ctx, err).Msg("failed to cache response")
+	}
+}
+
+// writeCached: the entry with its age
+func writeCached(w http.ResponseWriter, entry cachedResponse) {
+	header := w.Header()
+	for name, values := range entry.Header {
+		header[name] = values
+	}
+	header.Set(cacheStatusHeader, "HIT")
+	header.Set("Age", strconv.Itoa(max(int(time.Since(entry.StoredAt).Seconds()), 0)))
+	w.WriteHeader(entry.Status)
+	_, _ = w.Write(entry.Body)
+}
+
+// storable: complete 200 responses meant for everyone (or for the credentials of the key on private routes)
+func storable(rec *idempotencyRecorder, private bool) bool {
+	if rec.status != http.StatusOK || rec.overflow || rec.header.Get("Set-Cookie") != "" {
+		return false
+	}
+	cacheControl := rec.header.Get("Cache-Control")
+	return !hasDirective(cacheControl, "no-store") && (private || !hasDirective(cacheControl, "private"))
+}
+
+// responseKey: entry key of r, the query is sorted so ?a=1&b=2 and ?b=2&a=1 share it
+// Accept is always part of it, responses are negotiated (httpx.Render)
+func responseKey(r *http.Request, pattern, generation string, route config.ResponseCacheRoute) string {
+	h := sha256.New()
+	io.WriteString(h, r.URL.Path+"\n"+r.URL.Query().Encode()+"\n")
+	for _, name := range append([]string{"Accept"}, route.Vary...) {
+		io.WriteString(h, name+": "+strings.Join(r.Header.Values(name), ", ")+"\n")
+	}
+	if route.Private {
+		io.WriteString(h, r.Header.Get("Authorization")+"\n"+r.Header.Get("Cookie")+"\n")
+	}
+	if generation == "" {
+		generation = "0"
+	}
+	return pattern + ":" + generation + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+func generationKey(pattern string) string {
+	return "generation:" + pattern
+}
+
+// hasDirective: Cache-Control value contains directive
+func hasDirective(cacheControl, directive string) bool {
+	for part := range strings.SplitSeq(cacheControl, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidatingWriter: calls invalidate once the handler answers with 2xx, before the response goes out
+type invalidatingWriter struct {
+	http.ResponseWriter
+	invalidate func()
+	decided    bool
+}
+
+func (w *invalidatingWriter) WriteHeader(status int) {
+	if !w.decided && status >= 200 {
+		w.decided = true
+		if status < 300 {
+			w.invalidate()
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *invalidatingWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap: lets http.ResponseController reach Flush / Hijack of the underlying writer
+func (w *invalidatingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// @dev per route settings in config are keyed by router pattern ("POST /auth/login")
+// @dev a ServeMux of just those patterns tells which one a request matches, with the precedence rules of the router
+
+// registerPattern: adds pattern to the matching mux, ServeMux panics on invalid or conflicting patterns
+func registerPattern(mux *http.ServeMux, pattern string) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("invalid route pattern %q: %v", pattern, p)
+		}
+	}()
+	mux.Handle(pattern, http.NotFoundHandler())
+	return nil
+}
+
+// matchPattern: the registered pattern request r matches, "" when none
+func matchPattern(mux *http.ServeMux, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	return pattern
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+)
+
+// @dev security headers: set before the handler runs, a handler with needs of its own (e.g. /docs with the inline styles of Swagger UI) overrides them
+// @dev server.security_headers.profile: "api" (production default) locks everything down, "html" lets pages of the same origin load
+
+// securityProfile: the headers of one profile
+type securityProfile struct {
+	csp            string
+	frameOptions   string
+	referrerPolicy string
+}
+
+var securityProfiles = map[string]securityProfile{
+	// JSON is never rendered as a page, a browser which does gets nothing to run or embed
+	"api": {
+		csp:            "default-src 'none'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'",
+		frameOptions:   "DENY",
+		referrerPolicy: "no-referrer",
+	},
+	// the bundle of server.static, scripts / styles / images of the own origin, nothing from others
+	"html": {
+		csp:            "default-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'self'",
+		frameOptions:   "SAMEORIGIN",
+		referrerPolicy: "strict-origin-when-cross-origin",
+	},
+}
+
+// SecurityHeaders: HSTS, X-Content-Type-Options, X-Frame-Options, Referrer-Policy and CSP of cfg.Profile, a pass-through for "off"
+func SecurityHeaders(cfg config.SecurityHeadersConfig) func(http.Handler) http.Handler {
+	profile, ok := securityProfiles[cfg.Profile]
+	if !ok {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	if cfg.CSP != "" {
+		profile.csp = cfg.CSP
+	}
+
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(cfg.HSTSMaxAge.Seconds()), 10)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+			header.Set("X-Frame-Options", profile.frameOptions)
+			header.Set("Referrer-Policy", profile.referrerPolicy)
+			header.Set("Content-Security-Policy", profile.csp)
+			// browsers ignore it on plain http, behind a TLS terminating proxy the forwarded proto tells
+			if hsts != "" && (r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https") {
+				header.Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
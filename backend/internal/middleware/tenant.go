@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/database"
+)
+
+// Tenant: reads the tenant id from header into the request context, see database.WithTenantTx
+// requests without a valid tenant id are rejected before they reach tenant scoped handlers
+func Tenant(header string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant := r.Header.Get(header)
+			if tenant == "" || !database.ValidTenant(tenant) {
+				writeError(w, r, http.StatusBadRequest, "invalid_tenant", "missing or invalid "+header+" header")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(database.ContextWithTenant(r.Context(), tenant)))
+		})
+	}
+}
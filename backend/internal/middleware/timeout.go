@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/anuragShingare30/go-boilerplate/internal/router"
+)
+
+/**
+@dev request timeout: the request context gets server.request_timeout.default (or the deadline of the route)
+
+deadline passes → ctx of the handler is done
+    → database.Exec / Query / WithTx and redis calls with ctx return context.DeadlineExceeded, postgres cancels the query
+    → first response write after the deadline: 504 envelope instead, the handler's own response is dropped
+    → handler returns without answering: 504 envelope
+
+the handler runs on the request goroutine (no http.TimeoutHandler buffering), Flush keeps working for streams
+work which ignores ctx isn't interrupted, the 504 goes out once the handler returns
+*/
+
+// Timeout: deadline on the context of every request after it, per route deadlines are keyed by router pattern
+func Timeout(cfg config.RequestTimeoutConfig) (func(http.Handler) http.Handler, error) {
+	routes := http.NewServeMux()
+	for pattern := range cfg.Routes {
+		if err := registerPattern(routes, pattern); err != nil {
+			return nil, fmt.Errorf("server.request_timeout.routes: %w", err)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := cfg.Default
+			if pattern := matchPattern(routes, r); pattern != "" {
+				timeout = cfg.Routes[pattern]
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			r = r.WithContext(ctx)
+			tw := &timeoutWriter{ResponseWriter: w, req: r, timeout: timeout}
+			next.ServeHTTP(tw, r)
+
+			if !tw.wroteHeader && tw.timedOut() {
+				tw.writeTimeout()
+			}
+		})
+	}, nil
+}
+
+// timeoutWriter: turns the response into a 504 once the deadline has passed
+type timeoutWriter struct {
+	http.ResponseWriter
+	req         *http.Request
+	timeout     time.Duration
+	wroteHeader bool
+	// the 504 was sent, writes of the handler go nowhere
+	discard bool
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	if w.timedOut() {
+		w.writeTimeout()
+		return
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.discard {
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// timedOut: the deadline of this middleware passed, a cancelled client is not a timeout
+func (w *timeoutWriter) timedOut() bool {
+	return errors.Is(w.req.Context().Err(), context.DeadlineExceeded)
+}
+
+// writeTimeout: 504 envelope, logged with the route so slow endpoints can be found
+func (w *timeoutWriter) writeTimeout() {
+	w.wroteHeader = true
+	w.discard = true
+
+	ctx := w.req.Context()
+	loggerConfig.FromContext(ctx).Warn().
+		Str("route", router.RoutePattern(w.req)).
+		Dur("timeout", w.timeout).
+		Msg("request timed out")
+
+	writeError(w.ResponseWriter, w.req, http.StatusGatewayTimeout, "timeout", "request timed out")
+}
+
+// Unwrap: lets http.ResponseController reach Flush / Hijack of the underlying writer
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
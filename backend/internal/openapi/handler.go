@@ -0,0 +1,88 @@
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/router"
+)
+
+/**
+@dev Swagger UI assets: vendored into swaggerui/ and served by Assets, the docs need no CDN and the CSP allows only the own origin
+
+task openapi:ui → downloads swagger-ui-dist of swaggerui/VERSION into swaggerui/, commit the files
+without the vendored bundle the page falls back to unpkg, pinned to swaggerui/VERSION
+*/
+
+//go:embed ui.html
+var uiPage string
+
+//go:embed swaggerui
+var uiAssets embed.FS
+
+// swaggerUIVersion: swagger-ui-dist release of swaggerui/, pinned so the docs don't change under us
+var swaggerUIVersion = func() string {
+	version, _ := uiAssets.ReadFile("swaggerui/VERSION")
+	return strings.TrimSpace(string(version))
+}()
+
+var uiTemplate = template.Must(template.New("ui").Parse(uiPage))
+
+// Handler: GET /openapi.json, the document of the routes of r
+// generated on the first request, every route is registered by then
+func Handler(r *router.Router, info Info) http.HandlerFunc {
+	var once sync.Once
+	var body []byte
+	var err error
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		once.Do(func() {
+			body, err = json.MarshalIndent(Generate(info, r.Routes()), "", "  ")
+		})
+		if err != nil {
+			http.Error(w, "failed to generate openapi document", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}
+}
+
+// UI: GET /docs, Swagger UI reading the document from specURL, its assets from assetsURL (where Assets is mounted)
+func UI(title, specURL, assetsURL string) http.HandlerFunc {
+	bundleURL := assetsURL
+	// replaces the CSP of middleware.SecurityHeaders, swagger-ui sets inline styles and data: images
+	csp := "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'"
+	if _, err := fs.Stat(uiAssets, "swaggerui/swagger-ui-bundle.js"); err != nil {
+		bundleURL = "https://unpkg.com/swagger-ui-dist@" + swaggerUIVersion
+		csp = "default-src 'self'; script-src 'self' https://unpkg.com; style-src 'self' 'unsafe-inline' https://unpkg.com; img-src 'self' data:; frame-ancestors 'none'"
+	}
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Security-Policy", csp)
+		_ = uiTemplate.Execute(w, map[string]string{
+			"Title":     title,
+			"SpecURL":   specURL,
+			"AssetsURL": bundleURL,
+			"InitURL":   assetsURL + "/swagger-init.js",
+		})
+	}
+}
+
+// Assets: the files of swaggerui/, mounted at the assetsURL of UI
+func Assets() http.Handler {
+	sub, _ := fs.Sub(uiAssets, "swaggerui")
+	files := http.FileServerFS(sub)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a new release comes with a new binary, a day is short enough
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,309 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+	"github.com/anuragShingare30/go-boilerplate/internal/router"
+)
+
+/**
+@dev OpenAPI 3 document generated from the routes of the router, the contract can't drift from the code
+
+r.Post("/users", h.Create).Describe(router.Doc{
+	Summary:  "Create a user",
+	Request:  CreateUserRequest{},  → json fields: request body, query:"..." / path:"..." fields: parameters
+	Response: User{},               → schemas from the json and validate tags (schema.go)
+	Status:   http.StatusCreated,
+	Errors:   []int{http.StatusConflict},
+})
+    → GET /openapi.json: document of every described route, built once on the first request
+    → GET /docs: Swagger UI of it, outside production (handler.go)
+
+routes without Describe are left out, internal endpoints (metrics, admin, health) stay undocumented
+*/
+
+// Version: OpenAPI version of the generated document
+const Version = "3.0.3"
+
+const bearerScheme = "bearerAuth"
+
+// Info: title and version of the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document: the OpenAPI document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// PathItem: operations of one path by lowercase method
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+// ParameterDescriber: request values which describe their query parameters themselves (listquery.Spec)
+type ParameterDescriber interface {
+	OpenAPIParameters() []Parameter
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path or query
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+// Generate: document of the described routes
+func Generate(info Info, routes []router.Route) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+	}
+	s := newSchemas()
+	auth := false
+
+	for _, route := range routes {
+		// routes for every method are mounts (static files, pprof), they have no single operation
+		if route.Doc == nil || route.Method == "" {
+			continue
+		}
+
+		path := openAPIPath(route.Pattern)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.Method)] = operation(s, route, path)
+		auth = auth || route.Doc.Auth
+	}
+
+	// the error envelope every error response has, its detail types are components of their own
+	s.byName["Error"] = s.object(reflect.TypeFor[httpx.ErrorBody]())
+	doc.Components.Schemas = s.byName
+	if auth {
+		doc.Components.SecuritySchemes = map[string]*SecurityScheme{
+			bearerScheme: {Type: "http", Scheme: "bearer"},
+		}
+	}
+	return doc
+}
+
+// operation: one method of a path
+func operation(s *schemas, route router.Route, path string) *Operation {
+	d := route.Doc
+	op := &Operation{
+		OperationID: operationID(route.Method, path),
+		Summary:     d.Summary,
+		Description: d.Description,
+		Tags:        d.Tags,
+		Deprecated:  d.Deprecated || route.Deprecated,
+		Responses:   make(map[string]*Response),
+	}
+	if len(op.Tags) == 0 {
+		// versioned routes are tagged by the segment after the version, /api/v1/users → users
+		tagPath := path
+		if _, rest, ok := strings.Cut(path, "/"+route.Version+"/"); ok && route.Version != "" {
+			tagPath = "/" + rest
+		}
+		if segment := firstSegment(tagPath); segment != "" {
+			op.Tags = []string{segment}
+		}
+	}
+
+	var requestType reflect.Type
+	describer, described := d.Request.(ParameterDescriber)
+	if d.Request != nil && !described {
+		requestType = reflect.TypeOf(d.Request)
+		for requestType.Kind() == reflect.Pointer {
+			requestType = requestType.Elem()
+		}
+	}
+	op.Parameters = parameters(s, requestType, path)
+	if described {
+		op.Parameters = append(op.Parameters, describer.OpenAPIParameters()...)
+	}
+	if requestType != nil && hasBody(route.Method) && hasJSONFields(requestType) {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: s.of(requestType)}},
+		}
+	}
+
+	status := d.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if d.Response != nil && status != http.StatusNoContent {
+		success.Content = map[string]MediaType{"application/json": {Schema: s.of(reflect.TypeOf(d.Response))}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	errors := append([]int(nil), d.Errors...)
+	if d.Auth {
+		op.Security = []map[string][]string{{bearerScheme: {}}}
+		errors = append(errors, http.StatusUnauthorized)
+	}
+	// failures every route can have, wherever they come from (validation, middlewares, bugs)
+	errors = append(errors, http.StatusInternalServerError)
+	if d.Request != nil {
+		errors = append(errors, http.StatusBadRequest)
+	}
+	for _, code := range errors {
+		op.Responses[strconv.Itoa(code)] = &Response{
+			Description: http.StatusText(code),
+			Content:     map[string]MediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/Error"}}},
+		}
+	}
+	return op
+}
+
+// parameters: path and query tagged fields of the request, path segments without a field are plain strings
+func parameters(s *schemas, requestType reflect.Type, path string) []Parameter {
+	var params []Parameter
+	seen := make(map[string]bool)
+
+	if requestType != nil && requestType.Kind() == reflect.Struct {
+		for i := range requestType.NumField() {
+			field := requestType.Field(i)
+			for _, in := range []string{"path", "query"} {
+				name, ok := field.Tag.Lookup(in)
+				if !ok || name == "" || name == "-" {
+					continue
+				}
+				schema := s.of(field.Type)
+				applyRules(schema, field.Tag.Get("validate"))
+				params = append(params, Parameter{
+					Name:        name,
+					In:          in,
+					Description: field.Tag.Get("doc"),
+					Required:    in == "path" || isRequired(field),
+					Schema:      schema,
+				})
+				seen[in+":"+name] = true
+			}
+		}
+	}
+
+	for _, name := range pathParams(path) {
+		if !seen["path:"+name] {
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return params
+}
+
+// openAPIPath: ServeMux pattern as OpenAPI path, "{path...}" → "{path}", a trailing "{$}" is dropped
+func openAPIPath(pattern string) string {
+	pattern = strings.TrimSuffix(pattern, "{$}")
+	pattern = strings.ReplaceAll(pattern, "...}", "}")
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return pattern
+}
+
+// pathParams: names of the {name} segments of path
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, segment[1:len(segment)-1])
+		}
+	}
+	return names
+}
+
+// operationID: method and static path segments, "GET /users/{id}" → "getUsersById"
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			b.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		for _, part := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(componentName(part))
+		}
+	}
+	return b.String()
+}
+
+func firstSegment(path string) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if strings.HasPrefix(segment, "{") {
+		return ""
+	}
+	return segment
+}
+
+func hasBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// hasJSONFields: the request struct has a body part besides its parameters
+func hasJSONFields(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		_, query := field.Tag.Lookup("query")
+		_, path := field.Tag.Lookup("path")
+		if _, ok := jsonName(field); ok && field.IsExported() && !query && !path {
+			return true
+		}
+	}
+	return false
+}
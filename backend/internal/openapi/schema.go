@@ -0,0 +1,296 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// @dev JSON schemas from Go types, following encoding/json: json tag names, omitted "-" fields, embedded structs flattened
+// @dev validate tags add what the validator enforces: required, oneof → enum, min / max → bounds; doc tags are descriptions
+
+// Schema: subset of the OpenAPI 3.0 schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemas: named structs of one document, referenced as #/components/schemas/<Name>
+type schemas struct {
+	byName map[string]*Schema
+	names  map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{byName: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+// of: schema of t, named structs become component references
+func (s *schemas) of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// encoding/json writes []byte as base64
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.name(t)}
+	default:
+		// interfaces and everything json can't describe further: any value
+		return &Schema{}
+	}
+}
+
+// name: component name of t, registered with its schema on first use
+func (s *schemas) name(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := componentName(t.Name())
+	// same type name in two packages: the second one gets the package in front
+	if _, taken := s.byName[name]; taken {
+		name = componentName(t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]) + name
+	}
+	s.names[t] = name
+	// placeholder first, recursive types reference themselves
+	s.byName[name] = &Schema{}
+	*s.byName[name] = *s.object(t)
+	return name
+}
+
+// object: properties of the json fields of struct t
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.addFields(schema, t)
+	return schema
+}
+
+func (s *schemas) addFields(schema *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		// parameters are described next to the body, they are not part of it
+		if _, ok := field.Tag.Lookup("query"); ok {
+			continue
+		}
+		if _, ok := field.Tag.Lookup("path"); ok {
+			continue
+		}
+
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+		// embedded structs without a json name are flattened, like encoding/json does
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(schema, embedded)
+				continue
+			}
+		}
+
+		property := s.field(field)
+		schema.Properties[name] = property
+		if isRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// field: schema of a struct field with the rules of its validate and doc tags
+func (s *schemas) field(field reflect.StructField) *Schema {
+	property := s.of(field.Type)
+	description := field.Tag.Get("doc")
+
+	// siblings of $ref are ignored by OpenAPI 3.0, the reference is wrapped instead
+	if property.Ref != "" {
+		if description == "" {
+			return property
+		}
+		return &Schema{Description: description, AllOf: []*Schema{property}}
+	}
+	property.Description = description
+	applyRules(property, field.Tag.Get("validate"))
+	return property
+}
+
+// jsonName: property name of field, false for fields encoding/json leaves out
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// isRequired: validate:"required" before any dive
+func isRequired(field reflect.StructField) bool {
+	for _, rule := range fieldRules(field.Tag.Get("validate")) {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldRules: rules of the field itself, the ones after dive apply to the elements
+func fieldRules(tag string) []string {
+	var rules []string
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "dive" {
+			break
+		}
+		if rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// applyRules: oneof, min, max, len of the validator as schema constraints
+func applyRules(schema *Schema, tag string) {
+	for _, rule := range fieldRules(tag) {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, enumValue(schema.Type, value))
+			}
+		case "min", "gte":
+			setBound(schema, param, true)
+		case "max", "lte":
+			setBound(schema, param, false)
+		case "len":
+			setBound(schema, param, true)
+			setBound(schema, param, false)
+		case "email":
+			schema.Format = "email"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		}
+	}
+}
+
+// setBound: min / max mean length for strings, item count for arrays and the value for numbers
+func setBound(schema *Schema, param string, lower bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	count := int(value)
+
+	switch schema.Type {
+	case "string":
+		if lower {
+			schema.MinLength = &count
+		} else {
+			schema.MaxLength = &count
+		}
+	case "array":
+		if lower {
+			schema.MinItems = &count
+		} else {
+			schema.MaxItems = &count
+		}
+	case "integer", "number":
+		if lower {
+			schema.Minimum = &value
+		} else {
+			schema.Maximum = &value
+		}
+	}
+}
+
+// enumValue: oneof values are strings in the tag, numbers keep their json type
+func enumValue(schemaType, value string) any {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+// componentName: exported looking name, unexported body types are documented as well
+func componentName(name string) string {
+	// generic instances are named like "Page[github.com/...User]"
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		inner := name[i+1 : len(name)-1]
+		inner = inner[strings.LastIndex(inner, ".")+1:]
+		name = name[:i] + componentName(inner)
+	}
+	runes := []rune(name)
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
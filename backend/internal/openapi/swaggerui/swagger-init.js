@@ -0,0 +1,10 @@
+// starts Swagger UI on the document of data-spec-url, a file of its own so the CSP needs no 'unsafe-inline' script
+window.onload = function () {
+	var root = document.getElementById("swagger-ui");
+	window.ui = SwaggerUIBundle({
+		url: root.dataset.specUrl,
+		dom_id: "#swagger-ui",
+		deepLinking: true,
+		persistAuthorization: true,
+	});
+};
@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+/**
+@dev transactional outbox: an event is published if and only if the business change committed
+
+db.WithTx(ctx, opts, func(tx pgx.Tx) error {
+    ... INSERT INTO orders ...
+    return outbox.Enqueue(ctx, tx, outbox.Event{Topic: "order.created", Key: orderID, Payload: order})
+})
+    → row in outbox_events, committed (or rolled back) together with the order
+        → Relay (relay.go) polls pending rows with FOR UPDATE SKIP LOCKED, publishes them to the sink
+            → success: published_at set
+            → failure: attempts + 1, next_attempt_at pushed out with exponential backoff
+
+at-least-once: a crash between publish and the update publishes the event again,
+consumers deduplicate with the message id (header "outbox-id")
+*/
+
+// Event: what the application enqueues, Payload is marshaled to JSON
+type Event struct {
+	Topic   string
+	Key     string // partition / ordering key, e.g. the aggregate id
+	Payload any
+	Headers map[string]string
+}
+
+// Message: an enqueued event as the sinks receive it
+type Message struct {
+	ID        int64
+	Topic     string
+	Key       string
+	Payload   json.RawMessage
+	Headers   map[string]string
+	CreatedAt time.Time
+	Attempts  int
+}
+
+// Sink: where the relay publishes messages, Publish must be safe to call again for the same message
+type Sink interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// Enqueue: writes event into the outbox inside tx
+func Enqueue(ctx context.Context, tx pgx.Tx, event Event) error {
+	if event.Topic == "" {
+		return fmt.Errorf("outbox event without topic")
+	}
+
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+	headers := event.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO outbox_events (topic, key, payload, headers) VALUES ($1, $2, $3, $4)",
+		event.Topic, event.Key, payload, headers,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue outbox event: %w", err)
+	}
+	return nil
+}
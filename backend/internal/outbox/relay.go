@@ -0,0 +1,198 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/database"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+// @dev relay: background worker moving pending outbox rows to the sink, any number of instances can run it
+// @dev FOR UPDATE SKIP LOCKED hands every row to one relay at a time, the lock is held while the batch is published
+
+const (
+	relayBaseBackoff   = time.Second
+	cleanupInterval    = time.Hour
+	maxLastErrorLength = 1024
+)
+
+// Relay: polls the outbox and publishes pending messages
+type Relay struct {
+	db   *database.Database
+	sink Sink
+	cfg  config.OutboxConfig
+	log  *zerolog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewRelay(db *database.Database, sink Sink, cfg config.OutboxConfig, logger *zerolog.Logger) *Relay {
+	return &Relay{db: db, sink: sink, cfg: cfg, log: logger}
+}
+
+// Start: relays in the background until ctx is done or Close is called
+func (r *Relay) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		r.run(ctx)
+	}()
+}
+
+// Close: stops the relay after the running batch and closes the sink
+func (r *Relay) Close() error {
+	if r.cancel != nil {
+		r.cancel()
+		<-r.done
+	}
+	return r.sink.Close()
+}
+
+func (r *Relay) run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	lastCleanup := time.Now()
+
+	for {
+		// a full batch means more rows are waiting, don't sleep in between
+		for {
+			published, err := r.relayBatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				loggerConfig.ErrorFields(r.log.Error(), err).Msg("outbox relay batch failed")
+			}
+			if err != nil || published < r.cfg.BatchSize {
+				break
+			}
+		}
+
+		if r.cfg.Retention > 0 && time.Since(lastCleanup) >= cleanupInterval {
+			r.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// relayBatch: publishes up to batch_size due messages, returns how many were handled
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	handled := 0
+	err := r.db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		handled = 0
+		rows, err := tx.Query(ctx, `
+			SELECT id, topic, key, payload, headers, created_at, attempts
+			FROM outbox_events
+			WHERE published_at IS NULL AND next_attempt_at <= now()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED`, r.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		messages, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Message, error) {
+			var msg Message
+			err := row.Scan(&msg.ID, &msg.Topic, &msg.Key, &msg.Payload, &msg.Headers, &msg.CreatedAt, &msg.Attempts)
+			return msg, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range messages {
+			if err := r.publish(ctx, tx, msg); err != nil {
+				return err
+			}
+			handled++
+		}
+		return nil
+	})
+	return handled, err
+}
+
+// publish: one message, the outcome is stored in tx
+func (r *Relay) publish(ctx context.Context, tx pgx.Tx, msg Message) error {
+	publishCtx, cancel := context.WithTimeout(ctx, r.cfg.PublishTimeout)
+	publishErr := r.sink.Publish(publishCtx, msg)
+	cancel()
+
+	if publishErr == nil {
+		_, err := tx.Exec(ctx, "UPDATE outbox_events SET published_at = now(), attempts = attempts + 1 WHERE id = $1", msg.ID)
+		return err
+	}
+	if ctx.Err() != nil {
+		// shutting down, not the sink's fault: leave the row as it is
+		return errors.Join(publishErr, ctx.Err())
+	}
+
+	backoff := retryBackoff(msg.Attempts+1, r.cfg.MaxBackoff)
+	lastError := errorColumnText(publishErr.Error(), maxLastErrorLength)
+
+	r.log.Warn().
+		Err(publishErr).
+		Int64("outbox_id", msg.ID).
+		Str("topic", msg.Topic).
+		Int("attempt", msg.Attempts+1).
+		Dur("retry_in", backoff).
+		Msg("failed to publish outbox message")
+
+	_, err := tx.Exec(ctx,
+		"UPDATE outbox_events SET attempts = attempts + 1, next_attempt_at = now() + $2::interval, last_error = $3 WHERE id = $1",
+		msg.ID, fmt.Sprintf("%d milliseconds", backoff.Milliseconds()), lastError,
+	)
+	return err
+}
+
+// cleanup: published messages older than the retention are deleted
+func (r *Relay) cleanup(ctx context.Context) {
+	tag, err := r.db.Exec(ctx,
+		"DELETE FROM outbox_events WHERE published_at < now() - $1::interval",
+		fmt.Sprintf("%d seconds", int64(r.cfg.Retention.Seconds())),
+	)
+	if err != nil {
+		r.log.Warn().Err(err).Msg("failed to clean up published outbox messages")
+		return
+	}
+	if tag.RowsAffected() > 0 {
+		r.log.Debug().Int64("deleted", tag.RowsAffected()).Msg("cleaned up published outbox messages")
+	}
+}
+
+// errorColumnText: msg cut to at most limit bytes on a rune boundary, text postgres accepts
+// invalid UTF-8 or a NUL byte would fail the UPDATE and roll back the published rows of the batch
+func errorColumnText(msg string, limit int) string {
+	msg = strings.ReplaceAll(strings.ToValidUTF8(msg, "\uFFFD"), "\x00", "")
+	if len(msg) <= limit {
+		return msg
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	return msg[:cut]
+}
+
+// retryBackoff: exponential backoff with jitter, capped at maxBackoff
+func retryBackoff(attempt int, maxBackoff time.Duration) time.Duration {
+	backoff := relayBaseBackoff << min(attempt-1, 20)
+	if backoff <= 0 || backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	// ±20%, so a failing sink isn't hit by every message at the same moment
+	return time.Duration(float64(backoff) * (0.8 + rand.Float64()*0.4))
+}
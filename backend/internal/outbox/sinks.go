@@ -0,0 +1,172 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	redisClient "github.com/anuragShingare30/go-boilerplate/internal/redis"
+	"github.com/redis/go-redis/v9"
+	"github.com/segmentio/kafka-go"
+)
+
+// @dev sinks: outbox.sink selects one, every message carries its outbox id so consumers can deduplicate
+
+// NewSink: sink of outbox.sink
+func NewSink(cfg *config.Config) (Sink, error) {
+	switch cfg.Outbox.Sink {
+	case "webhook":
+		return newWebhookSink(cfg.Outbox.Webhook), nil
+	case "redis":
+		return newRedisStreamSink(cfg)
+	case "kafka":
+		return newKafkaSink(cfg.Outbox.Kafka), nil
+	default:
+		return nil, fmt.Errorf("unknown outbox sink %q", cfg.Outbox.Sink)
+	}
+}
+
+// webhookSink: POST of the payload, any 2xx response counts as delivered
+type webhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+func newWebhookSink(cfg config.OutboxWebhookConfig) *webhookSink {
+	return &webhookSink{
+		url:    cfg.URL,
+		secret: []byte(cfg.Secret),
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (s *webhookSink) Publish(ctx context.Context, msg Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Outbox-Id", strconv.FormatInt(msg.ID, 10))
+	req.Header.Set("Outbox-Topic", msg.Topic)
+	if msg.Key != "" {
+		req.Header.Set("Outbox-Key", msg.Key)
+	}
+	for name, value := range msg.Headers {
+		req.Header.Set(name, value)
+	}
+	if len(s.secret) > 0 {
+		// receivers verify the body wasn't forged: hex(HMAC-SHA256(secret, body))
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(msg.Payload)
+		req.Header.Set("Outbox-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// drain so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// redisStreamSink: XADD to outbox.redis.stream, "{topic}" in the name is replaced with the topic
+type redisStreamSink struct {
+	client redis.UniversalClient
+	stream string
+	maxLen int64
+}
+
+func newRedisStreamSink(cfg *config.Config) (*redisStreamSink, error) {
+	// same topology (standalone / sentinel / cluster) as the rest of the service
+	client, err := redisClient.NewClient(&cfg.Redis)
+	if err != nil {
+		return nil, err
+	}
+	return &redisStreamSink{
+		client: client,
+		stream: cfg.Outbox.Redis.Stream,
+		maxLen: cfg.Outbox.Redis.MaxLen,
+	}, nil
+}
+
+func (s *redisStreamSink) Publish(ctx context.Context, msg Message) error {
+	headers, err := json.Marshal(msg.Headers)
+	if err != nil {
+		return err
+	}
+
+	args := &redis.XAddArgs{
+		Stream: strings.ReplaceAll(s.stream, "{topic}", msg.Topic),
+		Values: map[string]any{
+			"outbox_id": msg.ID,
+			"topic":     msg.Topic,
+			"key":       msg.Key,
+			"payload":   string(msg.Payload),
+			"headers":   string(headers),
+		},
+	}
+	if s.maxLen > 0 {
+		args.MaxLen = s.maxLen
+		args.Approx = true
+	}
+	return s.client.XAdd(ctx, args).Err()
+}
+
+func (s *redisStreamSink) Close() error {
+	return s.client.Close()
+}
+
+// kafkaSink: one kafka message per outbox message, the topic of the event is the kafka topic
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+func newKafkaSink(cfg config.OutboxKafkaConfig) *kafkaSink {
+	return &kafkaSink{writer: &kafka.Writer{
+		Addr: kafka.TCP(cfg.Brokers...),
+		// same key → same partition, events of one aggregate keep their order
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: cfg.AutoCreateTopics,
+	}}
+}
+
+func (s *kafkaSink) Publish(ctx context.Context, msg Message) error {
+	headers := []kafka.Header{{Key: "outbox-id", Value: []byte(strconv.FormatInt(msg.ID, 10))}}
+	for name, value := range msg.Headers {
+		headers = append(headers, kafka.Header{Key: name, Value: []byte(value)})
+	}
+
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   msg.Topic,
+		Key:     []byte(msg.Key),
+		Value:   msg.Payload,
+		Headers: headers,
+		Time:    msg.CreatedAt,
+	})
+}
+
+func (s *kafkaSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,20 @@
+package pubsub
+
+import "encoding/json"
+
+// Codec: encoding of the payloads on the wire, publishers and subscribers of a topic must agree on it
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec: the default, readable with redis-cli SUBSCRIBE
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,289 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev redis pub/sub: fire and forget messages between the instances of the service, e.g. cache invalidation
+
+pubsub.Subscribe("user.updated", func(ctx context.Context, msg UserUpdated) error { ... })
+pubsub.Publish(ctx, "user.updated", UserUpdated{ID: id})
+    → payload encoded with the codec (JSON), PUBLISH on the topic
+    → one subscriber connection per PubSub, SUBSCRIBE on every topic with a handler
+        → messages go to the buffer of the subscription (pubsub.buffer_size), pubsub.workers goroutines run its handler
+        → a full buffer drops the message, a slow handler doesn't hold up the other topics
+        → handler errors and panics are logged, the worker keeps going
+    → broken connection: go-redis reconnects and subscribes again, messages published meanwhile are lost
+
+delivery is at most once, work which must not get lost goes through the outbox / streams
+*/
+
+const (
+	reconnectMinBackoff = 100 * time.Millisecond
+	reconnectMaxBackoff = 10 * time.Second
+)
+
+// ErrNoPubSub: Publish / Subscribe were used before SetDefault
+var ErrNoPubSub = errors.New("pubsub: no default pubsub, call pubsub.SetDefault")
+
+// ErrClosed: Start / Subscribe after Close, the subscriber connection and the workers are gone
+var ErrClosed = errors.New("pubsub: closed")
+
+// Handler: called with every decoded message of the topic it is subscribed to
+type Handler[T any] func(ctx context.Context, msg T) error
+
+// PubSub: publisher and subscriber connection on a redis client
+type PubSub struct {
+	client     goredis.UniversalClient
+	codec      Codec
+	workers    int
+	bufferSize int
+	log        *zerolog.Logger
+
+	mu            sync.Mutex
+	subscriptions map[string]*subscription
+	conn          *goredis.PubSub // nil until Start
+	closed        bool
+	ctx           context.Context
+	cancel        context.CancelFunc
+	done          chan struct{}
+	wg            sync.WaitGroup
+}
+
+// subscription: handler of one topic and the buffer its workers read from
+type subscription struct {
+	topic    string
+	handle   func(ctx context.Context, payload []byte) error
+	messages chan []byte
+}
+
+// New: pub/sub on client with the pubsub.* config, subscriptions start receiving with Start
+func New(client goredis.UniversalClient, cfg config.PubSubConfig, logger *zerolog.Logger) *PubSub {
+	return &PubSub{
+		client:        client,
+		codec:         JSONCodec{},
+		workers:       max(cfg.Workers, 1),
+		bufferSize:    cfg.BufferSize,
+		log:           logger,
+		subscriptions: make(map[string]*subscription),
+	}
+}
+
+// WithCodec: replaces the JSON codec, call it before the first Publish / Subscribe
+func (ps *PubSub) WithCodec(codec Codec) *PubSub {
+	ps.codec = codec
+	return ps
+}
+
+// Publish: sends payload to every subscriber of topic, nobody listening is no error
+func (ps *PubSub) Publish(ctx context.Context, topic string, payload any) error {
+	data, err := ps.codec.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", topic, err)
+	}
+	if err := ps.client.Publish(ctx, topic, data).Err(); err != nil {
+		return fmt.Errorf("failed to publish %s message: %w", topic, err)
+	}
+	return nil
+}
+
+// SubscribeTo: registers handler for the messages of topic on ps, one handler per topic
+func SubscribeTo[T any](ps *PubSub, topic string, handler Handler[T]) error {
+	return ps.subscribe(topic, func(ctx context.Context, payload []byte) error {
+		var msg T
+		if err := ps.codec.Unmarshal(payload, &msg); err != nil {
+			return fmt.Errorf("failed to decode message: %w", err)
+		}
+		return handler(ctx, msg)
+	})
+}
+
+func (ps *PubSub) subscribe(topic string, handle func(ctx context.Context, payload []byte) error) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.closed {
+		return ErrClosed
+	}
+	if _, exists := ps.subscriptions[topic]; exists {
+		return fmt.Errorf("pubsub topic %s already has a handler", topic)
+	}
+	sub := &subscription{
+		topic:    topic,
+		handle:   handle,
+		messages: make(chan []byte, ps.bufferSize),
+	}
+	ps.subscriptions[topic] = sub
+
+	if ps.conn == nil {
+		return nil
+	}
+	// already running: workers and SUBSCRIBE on the live connection
+	ps.startWorkersLocked(sub)
+	if err := ps.conn.Subscribe(ps.ctx, topic); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Start: subscribes to the registered topics and dispatches their messages until ctx is done or Close is called
+// a PubSub starts once, ErrClosed after Close
+func (ps *PubSub) Start(ctx context.Context) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.closed {
+		return ErrClosed
+	}
+	if ps.conn != nil {
+		return errors.New("pubsub: already started")
+	}
+
+	ps.ctx, ps.cancel = context.WithCancel(ctx)
+	ps.done = make(chan struct{})
+
+	topics := make([]string, 0, len(ps.subscriptions))
+	for topic, sub := range ps.subscriptions {
+		topics = append(topics, topic)
+		ps.startWorkersLocked(sub)
+	}
+	ps.conn = ps.client.Subscribe(ps.ctx, topics...)
+
+	go func() {
+		defer close(ps.done)
+		ps.receive(ps.ctx, ps.conn)
+	}()
+
+	if len(topics) > 0 {
+		ps.log.Info().Strs("topics", topics).Msg("redis pubsub started")
+	}
+	return nil
+}
+
+// Close: stops receiving, waits for the workers to finish the buffered messages
+func (ps *PubSub) Close() {
+	ps.mu.Lock()
+	if ps.closed {
+		ps.mu.Unlock()
+		return
+	}
+	ps.closed = true
+	if ps.conn == nil {
+		ps.mu.Unlock()
+		return
+	}
+	ps.cancel()
+	if err := ps.conn.Close(); err != nil {
+		ps.log.Warn().Err(err).Msg("failed to close redis pubsub connection")
+	}
+	ps.mu.Unlock()
+
+	<-ps.done
+
+	ps.mu.Lock()
+	for _, sub := range ps.subscriptions {
+		close(sub.messages)
+	}
+	ps.conn = nil
+	ps.mu.Unlock()
+
+	ps.wg.Wait()
+}
+
+// receive: reads messages until ctx is done, failed reads back off while go-redis reconnects
+func (ps *PubSub) receive(ctx context.Context, conn *goredis.PubSub) {
+	backoff := reconnectMinBackoff
+
+	for {
+		msg, err := conn.ReceiveMessage(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			ps.log.Warn().Err(err).Dur("retry_in", backoff).Msg("redis pubsub receive failed")
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff = min(backoff*2, reconnectMaxBackoff)
+			continue
+		}
+		backoff = reconnectMinBackoff
+
+		ps.mu.Lock()
+		sub := ps.subscriptions[msg.Channel]
+		ps.mu.Unlock()
+		if sub == nil {
+			continue
+		}
+
+		select {
+		case sub.messages <- []byte(msg.Payload):
+		default:
+			ps.log.Warn().Str("topic", msg.Channel).Msg("pubsub buffer full, message dropped")
+		}
+	}
+}
+
+func (ps *PubSub) startWorkersLocked(sub *subscription) {
+	for range ps.workers {
+		ps.wg.Add(1)
+		go func() {
+			defer ps.wg.Done()
+			for payload := range sub.messages {
+				ps.handle(ps.ctx, sub, payload)
+			}
+		}()
+	}
+}
+
+// handle: runs the handler of sub, a panicking handler doesn't stop its worker
+func (ps *PubSub) handle(ctx context.Context, sub *subscription, payload []byte) {
+	defer func() {
+		if p := recover(); p != nil {
+			ps.log.Error().
+				Interface("panic", p).
+				Str("topic", sub.topic).
+				Msg("pubsub handler panicked")
+		}
+	}()
+
+	// buffered messages are still handled during Close
+	if err := sub.handle(context.WithoutCancel(ctx), payload); err != nil {
+		ps.log.Error().Err(err).Str("topic", sub.topic).Msg("pubsub handler failed")
+	}
+}
+
+// defaultPubSub: used by Publish / Subscribe, nil until SetDefault is called
+var defaultPubSub *PubSub
+
+// SetDefault: makes ps the pub/sub of the package functions, call it once at startup
+func SetDefault(ps *PubSub) {
+	defaultPubSub = ps
+}
+
+// Publish: PubSub.Publish of the default pub/sub
+func Publish(ctx context.Context, topic string, payload any) error {
+	if defaultPubSub == nil {
+		return ErrNoPubSub
+	}
+	return defaultPubSub.Publish(ctx, topic, payload)
+}
+
+// Subscribe: SubscribeTo of the default pub/sub
+func Subscribe[T any](topic string, handler Handler[T]) error {
+	if defaultPubSub == nil {
+		return ErrNoPubSub
+	}
+	return SubscribeTo(defaultPubSub, topic, handler)
+}
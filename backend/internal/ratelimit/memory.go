@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval: how often counters of idle keys are dropped
+const memorySweepInterval = time.Minute
+
+// MemoryLimiter: the sliding window counter of redis.go in a map of the process
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// memoryCounter: counts of the current and the previous window of one key
+type memoryCounter struct {
+	windowMs int64
+	index    int64
+	current  int64
+	previous int64
+}
+
+// NewMemory: limiter of this instance only, for single replica setups and local development
+func NewMemory() *MemoryLimiter {
+	return &MemoryLimiter{counters: make(map[string]*memoryCounter), now: time.Now}
+}
+
+// Allow implements Limiter
+func (l *MemoryLimiter) Allow(_ context.Context, key string, limit int, window time.Duration) (Result, error) {
+	if limit <= 0 || window <= 0 {
+		return Result{}, fmt.Errorf("invalid rate limit %d per %s", limit, window)
+	}
+
+	windowMs := window.Milliseconds()
+	now := l.now()
+	nowMs := now.UnixMilli()
+	index := nowMs / windowMs
+	elapsed := nowMs % windowMs
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= memorySweepInterval {
+		l.sweepLocked(nowMs)
+		l.lastSweep = now
+	}
+
+	// the same key with another window is a separate budget, like the redis keys
+	counterKey := key + ":" + strconv.FormatInt(windowMs, 10)
+	c, ok := l.counters[counterKey]
+	if !ok {
+		c = &memoryCounter{windowMs: windowMs, index: index}
+		l.counters[counterKey] = c
+	}
+	c.advance(index)
+
+	allowed := float64(c.previous)*float64(windowMs-elapsed)/float64(windowMs)+float64(c.current)+1 <= float64(limit)
+	if allowed {
+		c.current++
+	}
+	return result(allowed, limit, c.current, c.previous, windowMs, elapsed), nil
+}
+
+// advance: moves the counter to window index, the current window becomes the previous one
+func (c *memoryCounter) advance(index int64) {
+	switch {
+	case c.index == index:
+	case c.index == index-1:
+		c.previous, c.current = c.current, 0
+	default:
+		c.previous, c.current = 0, 0
+	}
+	c.index = index
+}
+
+// sweepLocked: drops counters which count nothing in the sliding window anymore
+func (l *MemoryLimiter) sweepLocked(nowMs int64) {
+	for key, c := range l.counters {
+		if c.index < nowMs/c.windowMs-1 {
+			delete(l.counters, key)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+/**
+@dev rate limiting: at most limit requests per window and key, e.g. "ip:203.0.113.7" or "user:42"
+
+res, err := limiter.Allow(ctx, "user:"+id, 100, time.Minute)
+    → res.Allowed false: over the limit, res.RetryAfter says when the next request can go through
+    → res.Remaining: requests left in the window, for X-RateLimit-Remaining style headers
+
+the redis limiter (redis.go) is shared by every replica, a key has the same budget on all of them
+the memory limiter (memory.go) counts per process, every replica allows the full limit
+*/
+
+// Limiter: decides whether one more request of key fits into limit per window
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error)
+}
+
+// Result: decision of Allow
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // 0 when allowed
+	Reset      time.Duration // until the current window ends, RetryAfter when denied
+}
+
+// New: limiter of server.rate_limit.backend
+func New(cfg config.RateLimitConfig, client goredis.UniversalClient) (Limiter, error) {
+	switch cfg.Backend {
+	case "memory":
+		return NewMemory(), nil
+	case "redis":
+		if client == nil {
+			return nil, fmt.Errorf("redis rate limit backend needs a redis client")
+		}
+		return NewRedis(client), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+/**
+@dev sliding window counter: one counter per fixed window, the previous window counts with the part of it
+still inside the sliding window
+
+    count = previous * (window - elapsed) / window + current
+
+two small keys per limited key instead of one entry per request (sliding log), off by a few percent at most
+the window index comes from the clock of the caller, replicas need roughly synced clocks (NTP)
+*/
+
+// allowScript: KEYS current / previous counter, ARGV limit, window ms, elapsed ms
+// returns {allowed, current, previous} with current already counting an allowed request
+var allowScript = goredis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local previous = tonumber(redis.call("GET", KEYS[2]) or "0")
+
+if previous * (window - elapsed) / window + current + 1 > limit then
+	return {0, current, previous}
+end
+
+current = redis.call("INCR", KEYS[1])
+if current == 1 then
+	-- read as the previous window during the next one
+	redis.call("PEXPIRE", KEYS[1], window * 2)
+end
+return {1, current, previous}
+`)
+
+// RedisLimiter: sliding window counter in redis
+type RedisLimiter struct {
+	client goredis.UniversalClient
+	now    func() time.Time
+}
+
+// NewRedis: limiter shared by every instance using the same redis
+func NewRedis(client goredis.UniversalClient) *RedisLimiter {
+	return &RedisLimiter{client: client, now: time.Now}
+}
+
+// Allow implements Limiter
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	if limit <= 0 || window <= 0 {
+		return Result{}, fmt.Errorf("invalid rate limit %d per %s", limit, window)
+	}
+
+	windowMs := window.Milliseconds()
+	nowMs := l.now().UnixMilli()
+	index := nowMs / windowMs
+	elapsed := nowMs % windowMs
+
+	// the hash tag keeps both counters in the same cluster slot
+	prefix := "ratelimit:{" + key + "}:" + strconv.FormatInt(windowMs, 10) + ":"
+	keys := []string{prefix + strconv.FormatInt(index, 10), prefix + strconv.FormatInt(index-1, 10)}
+
+	values, err := allowScript.Run(ctx, l.client, keys, limit, windowMs, elapsed).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to check rate limit of %s: %w", key, err)
+	}
+	allowed, current, previous := values[0] == 1, values[1], values[2]
+
+	return result(allowed, limit, current, previous, windowMs, elapsed), nil
+}
+
+// result: Result of the counters after the check, current already counts an allowed request
+func result(allowed bool, limit int, current, previous, windowMs, elapsed int64) Result {
+	count := float64(previous)*float64(windowMs-elapsed)/float64(windowMs) + float64(current)
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: max(limit-int(math.Ceil(count)), 0),
+		Reset:     time.Duration(windowMs-elapsed) * time.Millisecond,
+	}
+	if !allowed {
+		res.RetryAfter = retryAfter(limit, current, previous, windowMs, elapsed)
+		res.Reset = res.RetryAfter
+	}
+	return res
+}
+
+// retryAfter: time until the weighted count leaves room for one more request
+func retryAfter(limit int, current, previous, windowMs, elapsed int64) time.Duration {
+	if current+1 > int64(limit) {
+		// the current window alone is full, it has to become the previous one first
+		return time.Duration(windowMs-elapsed) * time.Millisecond
+	}
+
+	// previous * (window - t) / window + current + 1 <= limit
+	t := float64(windowMs) * (1 - float64(int64(limit)-current-1)/float64(previous))
+	wait := int64(math.Ceil(t)) - elapsed
+	return time.Duration(max(wait, 1)) * time.Millisecond
+}
@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// @dev command hooks: go-redis calls them around every command and pipeline, the next hook does the actual work
+
+// newRelicHook: datastore segment per command / pipeline, what nrredis-v9 records
+type newRelicHook struct {
+	host string
+	port string
+}
+
+// newNewRelicHook: segments name the host of a standalone redis, sentinel / cluster nodes change per command
+func newNewRelicHook(cfg *config.RedisConfig) newRelicHook {
+	if cfg.Mode == ModeSentinel || cfg.Mode == ModeCluster {
+		return newRelicHook{}
+	}
+	addr := redactedAddrs(cfg)[0]
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return newRelicHook{host: host, port: port}
+}
+
+func (h newRelicHook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return next
+}
+
+func (h newRelicHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		segment := h.segment(ctx, cmd.Name())
+		err := next(ctx, cmd)
+		segment.End()
+		return err
+	}
+}
+
+func (h newRelicHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		segment := h.segment(ctx, pipelineOperation(cmds))
+		err := next(ctx, cmds)
+		segment.End()
+		return err
+	}
+}
+
+// segment: a no-op segment without New Relic transaction in ctx
+func (h newRelicHook) segment(ctx context.Context, operation string) *newrelic.DatastoreSegment {
+	return &newrelic.DatastoreSegment{
+		StartTime:    newrelic.FromContext(ctx).StartSegmentNow(),
+		Product:      newrelic.DatastoreRedis,
+		Operation:    operation,
+		Host:         h.host,
+		PortPathOrID: h.port,
+	}
+}
+
+// pipelineOperation: "pipeline:get,set" like nrredis, the distinct command names in order
+func pipelineOperation(cmds []goredis.Cmder) string {
+	names := make([]string, 0, len(cmds))
+	seen := make(map[string]bool, len(cmds))
+	for _, cmd := range cmds {
+		if name := cmd.Name(); !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return "pipeline:" + strings.Join(names, ",")
+}
+
+// errorLogHook: logs failed commands, redis.Nil is the normal "no such key" answer and not logged
+type errorLogHook struct {
+	log *zerolog.Logger
+}
+
+func (h errorLogHook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return next
+}
+
+func (h errorLogHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		started := time.Now()
+		err := next(ctx, cmd)
+		if isCommandError(err) {
+			h.logger(ctx).Error().
+				Err(err).
+				Str("command", cmd.Name()).
+				Dur("duration", time.Since(started)).
+				Msg("redis command failed")
+		}
+		return err
+	}
+}
+
+func (h errorLogHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		started := time.Now()
+		err := next(ctx, cmds)
+		if isCommandError(err) {
+			h.logger(ctx).Error().
+				Err(err).
+				Str("commands", pipelineOperation(cmds)).
+				Int("count", len(cmds)).
+				Dur("duration", time.Since(started)).
+				Msg("redis pipeline failed")
+		}
+		return err
+	}
+}
+
+// logger: request scoped logger when there is one, so the line carries request_id / trace.id
+func (h errorLogHook) logger(ctx context.Context) *zerolog.Logger {
+	if ctxLogger := zerolog.Ctx(ctx); ctxLogger != zerolog.DefaultContextLogger && ctxLogger.GetLevel() != zerolog.Disabled {
+		return ctxLogger
+	}
+	return h.log
+}
+
+// isCommandError: a real failure, not a missing key or a caller which gave up
+func isCommandError(err error) bool {
+	return err != nil && !errors.Is(err, goredis.Nil) && !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+/**
+@dev topologies: redis.mode picks the go-redis client, the rest of the code only sees goredis.UniversalClient
+
+standalone → redis.address, "host:port" or a "redis://" / "rediss://" URL
+sentinel   → redis.master_name + redis.addresses of the sentinels, the sentinels are asked for the current master
+             and the client follows a failover on its own
+cluster    → redis.addresses of some cluster nodes (seeds), the slot map is discovered from them and refreshed on MOVED / ASK
+
+redis.tls.enabled: TLS to every node (and sentinel), "rediss://" enables it for standalone too
+    → redis.tls.ca_file: private CA of managed offerings, redis.tls.insecure_skip_verify: local self signed only
+redis.username / redis.password: AUTH on every connection, redis.db: SELECT after connecting (not in cluster mode)
+*/
+
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// NewClient: client of redis.mode without hooks and without connecting, New adds both
+func NewClient(cfg *config.RedisConfig) (goredis.UniversalClient, error) {
+	opts, err := UniversalOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Mode {
+	case ModeSentinel:
+		return goredis.NewFailoverClient(opts.Failover()), nil
+	case ModeCluster:
+		// a single seed is enough, NewUniversalClient would make it a standalone client
+		return goredis.NewClusterClient(opts.Cluster()), nil
+	case ModeStandalone, "":
+		return goredis.NewClient(opts.Simple()), nil
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", cfg.Mode)
+	}
+}
+
+// UniversalOptions: go-redis options of the redis.* config for every mode
+func UniversalOptions(cfg *config.RedisConfig) (*goredis.UniversalOptions, error) {
+	opts := &goredis.UniversalOptions{
+		MasterName:       cfg.MasterName,
+		SentinelPassword: cfg.SentinelPassword,
+		DB:               cfg.DB,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+		PoolTimeout:      cfg.PoolTimeout,
+		DialTimeout:      cfg.DialTimeout,
+		ReadTimeout:      cfg.ReadTimeout,
+		WriteTimeout:     cfg.WriteTimeout,
+	}
+
+	switch cfg.Mode {
+	case ModeSentinel, ModeCluster:
+		opts.Addrs = cfg.Addresses
+	default:
+		if isRedisURL(cfg.Address) {
+			parsed, err := goredis.ParseURL(cfg.Address)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redis url: %w", err)
+			}
+			opts.Addrs = []string{parsed.Addr}
+			opts.Username = parsed.Username
+			opts.Password = parsed.Password
+			opts.TLSConfig = parsed.TLSConfig
+			if cfg.DB == 0 {
+				opts.DB = parsed.DB // "redis://host:6379/2"
+			}
+		} else {
+			opts.Addrs = []string{cfg.Address}
+		}
+	}
+
+	if cfg.Username != "" {
+		opts.Username = cfg.Username
+	}
+	if cfg.Password != "" {
+		opts.Password = cfg.Password
+	}
+
+	if cfg.TLS.Enabled || opts.TLSConfig != nil {
+		tlsCfg, err := tlsConfig(&cfg.TLS, opts.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsCfg
+	}
+	return opts, nil
+}
+
+// tlsConfig: TLS settings of redis.tls, on top of the ones of a "rediss://" URL
+func tlsConfig(cfg *config.RedisTLSConfig, base *tls.Config) (*tls.Config, error) {
+	tlsCfg := &tls.Config{}
+	if base != nil {
+		tlsCfg = base.Clone()
+	}
+	tlsCfg.MinVersion = tls.VersionTLS12
+	if cfg.ServerName != "" {
+		tlsCfg.ServerName = cfg.ServerName
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redis ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("redis ca file %s contains no PEM certificates", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	// opt-in for self signed development setups, rejected together with ca_file
+	tlsCfg.InsecureSkipVerify = cfg.InsecureSkipVerify
+	return tlsCfg, nil
+}
+
+func isRedisURL(addr string) bool {
+	return strings.HasPrefix(addr, "redis://") || strings.HasPrefix(addr, "rediss://")
+}
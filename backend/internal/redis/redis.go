@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/anuragShingare30/go-boilerplate/internal/retry"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev redis client: one go-redis client for the whole service, built from the redis.* config
+
+redis.New(cfg, &log, loggerService)
+    → client of redis.mode (options.go), pinged at startup with redis.connect_retry (exponential backoff + jitter)
+    → hooks on every command:
+        → New Relic datastore segment in the transaction of ctx (same as nrredis), when the agent runs
+        → failed commands logged with the request scoped logger, redis.Nil (key not found) is not an error
+client.HealthCheck(ctx) → PING round trip, server version and pool usage, for readiness / health checks
+
+Client embeds goredis.UniversalClient, every go-redis command is available on it
+*/
+
+// pingTimeout: deadline of each startup ping
+const pingTimeout = 5 * time.Second
+
+// Client: the go-redis client with the hooks of this package
+type Client struct {
+	goredis.UniversalClient
+	log *zerolog.Logger
+}
+
+// New: connected client of redis.mode, fails when redis doesn't answer within redis.connect_retry
+func New(cfg *config.Config, logger *zerolog.Logger, loggerService *loggerConfig.LoggerService) (*Client, error) {
+	client, err := NewClient(&cfg.Redis)
+	if err != nil {
+		return nil, err
+	}
+
+	if loggerService != nil && loggerService.GetApplication() != nil {
+		client.AddHook(newNewRelicHook(&cfg.Redis))
+	}
+	client.AddHook(errorLogHook{log: logger})
+
+	err = retry.Connect(context.Background(), cfg.Redis.ConnectRetry, logger, "connecting to redis", func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+		defer cancel()
+		return client.Ping(ctx).Err()
+	})
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	logger.Info().Str("mode", cfg.Redis.Mode).Strs("addresses", redactedAddrs(&cfg.Redis)).Msg("connected to redis")
+	return &Client{UniversalClient: client, log: logger}, nil
+}
+
+// redactedAddrs: addresses for the log, a URL can carry the password
+func redactedAddrs(cfg *config.RedisConfig) []string {
+	if cfg.Mode == ModeSentinel || cfg.Mode == ModeCluster {
+		return cfg.Addresses
+	}
+	if isRedisURL(cfg.Address) {
+		if opts, err := goredis.ParseURL(cfg.Address); err == nil {
+			return []string{opts.Addr}
+		}
+	}
+	return []string{cfg.Address}
+}
+
+// Close: closes the connections of the client
+func (c *Client) Close() error {
+	c.log.Info().Msg("closing redis client")
+	return c.UniversalClient.Close()
+}
+
+// Health: result of HealthCheck
+type Health struct {
+	Latency       time.Duration // round trip of PING
+	ServerVersion string
+	Pool          PoolHealth
+}
+
+// PoolHealth: connection usage of the client pool
+type PoolHealth struct {
+	TotalConns uint32
+	IdleConns  uint32
+	Hits       uint32 // a free connection was found in the pool
+	Misses     uint32 // a new connection had to be opened
+	Timeouts   uint32 // waiting for a connection timed out
+}
+
+// HealthCheck: PING round trip, server version (INFO server) and pool stats
+func (c *Client) HealthCheck(ctx context.Context) (Health, error) {
+	stats := c.PoolStats()
+	health := Health{Pool: PoolHealth{
+		TotalConns: stats.TotalConns,
+		IdleConns:  stats.IdleConns,
+		Hits:       stats.Hits,
+		Misses:     stats.Misses,
+		Timeouts:   stats.Timeouts,
+	}}
+
+	started := time.Now()
+	if err := c.Ping(ctx).Err(); err != nil {
+		return health, fmt.Errorf("failed to ping redis: %w", err)
+	}
+	health.Latency = time.Since(started)
+
+	info, err := c.Info(ctx, "server").Result()
+	if err != nil {
+		return health, fmt.Errorf("failed to read redis server info: %w", err)
+	}
+	health.ServerVersion = infoField(info, "redis_version")
+	return health, nil
+}
+
+// infoField: value of key in the "key:value" lines of INFO
+func infoField(info, key string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), key+":"); ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+/**
+@dev generic helpers for repositories without sqlc queries, rows are scanned by column name into structs
+
+type Order struct {
+    ID        uuid.UUID `db:"id,default"`  → ",default": left out of Insert when zero, the column default applies
+    Total     int64     `db:"total"`
+    CreatedAt time.Time `db:"created_at,default"`
+    Internal  string    `db:"-"`           → never read or written
+}
+
+order, err := repository.Get[Order](ctx, db, "SELECT * FROM orders WHERE id = $1", id)
+orders, err := repository.List[Order](ctx, db, "SELECT * FROM orders ORDER BY created_at DESC LIMIT $1", 20)
+order, err = repository.Insert(ctx, db, "orders", Order{Total: 100})
+
+db is *database.Database or a pgx.Tx, errors are mapped like the sqlc repositories (ErrNotFound / ErrConflict)
+*/
+
+// DB: what the helpers need, implemented by *database.Database and pgx.Tx
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Get: exactly one row scanned into T, ErrNotFound when there is none
+func Get[T any](ctx context.Context, db DB, sql string, args ...any) (T, error) {
+	var zero T
+
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return zero, mapError(err, "get")
+	}
+
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[T])
+	if err != nil {
+		return zero, mapError(err, "get")
+	}
+	return row, nil
+}
+
+// List: every row scanned into T, an empty slice (not nil) when there are none
+func List[T any](ctx context.Context, db DB, sql string, args ...any) ([]T, error) {
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, mapError(err, "list")
+	}
+
+	list, err := pgx.CollectRows(rows, pgx.RowToStructByName[T])
+	if err != nil {
+		return nil, mapError(err, "list")
+	}
+	if list == nil {
+		list = []T{}
+	}
+	return list, nil
+}
+
+// Insert: inserts the db tagged fields of row into table, returns the inserted row (RETURNING *)
+func Insert[T any](ctx context.Context, db DB, table string, row T) (T, error) {
+	var zero T
+
+	columns, values, err := insertColumns(reflect.ValueOf(row))
+	if err != nil {
+		return zero, fmt.Errorf("insert into %s: %w", table, err)
+	}
+
+	sql := insertSQL(table, columns)
+	inserted, err := Get[T](ctx, db, sql, values...)
+	if err != nil {
+		return zero, fmt.Errorf("insert into %s: %w", table, err)
+	}
+	return inserted, nil
+}
+
+// insertSQL: INSERT INTO "table" ("a", "b") VALUES ($1, $2) RETURNING *, schema.table is supported
+func insertSQL(table string, columns []string) string {
+	if len(columns) == 0 {
+		return "INSERT INTO " + pgx.Identifier(strings.Split(table, ".")).Sanitize() + " DEFAULT VALUES RETURNING *"
+	}
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = pgx.Identifier{column}.Sanitize()
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING *",
+		pgx.Identifier(strings.Split(table, ".")).Sanitize(),
+		strings.Join(quoted, ", "),
+		strings.Join(placeholders, ", "),
+	)
+}
+
+// insertColumns: column names and values of a struct, same naming rules as pgx.RowToStructByName
+// (db tag, otherwise the lowercased field name; embedded structs are flattened)
+func insertColumns(v reflect.Value) (columns []string, values []any, err error) {
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("row must be a struct, got %s", v.Kind())
+	}
+
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, hasTag := field.Tag.Lookup("db")
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && !hasTag && field.Type.Kind() == reflect.Struct {
+			embeddedColumns, embeddedValues, err := insertColumns(v.Field(i))
+			if err != nil {
+				return nil, nil, err
+			}
+			columns = append(columns, embeddedColumns...)
+			values = append(values, embeddedValues...)
+			continue
+		}
+
+		if options == "default" && v.Field(i).IsZero() {
+			continue
+		}
+		if name == "" {
+			// untagged fields match case insensitively when scanning, unquoted postgres names are lowercase
+			name = strings.ToLower(field.Name)
+		}
+
+		columns = append(columns, name)
+		values = append(values, v.Field(i).Interface())
+	}
+	return columns, values, nil
+}
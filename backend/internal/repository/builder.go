@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+/**
+@dev query builder: squirrel for queries which are put together at runtime, e.g. admin list endpoints with optional filters
+@dev static queries stay in sqlc, the builder is for what sqlc can't express
+
+q := repository.Builder.Select("*").From("users")
+q = repository.WhereIf(q, filter.Email != "", sq.Eq{"email": filter.Email})
+q = repository.WhereIf(q, !filter.Since.IsZero(), sq.GtOrEq{"created_at": filter.Since})
+q, err := repository.OrderBy(q, params.Sort, params.Desc, []string{"created_at", "email"})
+users, err := repository.ListQuery[User](ctx, db, repository.Paginate(q, params.Limit, params.Offset))
+
+values always become bind parameters, only column names from the allowed list reach the SQL
+*/
+
+// Builder: squirrel statement builder with postgres $1 placeholders
+var Builder = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// GetQuery: Get with a built query
+func GetQuery[T any](ctx context.Context, db DB, query sq.Sqlizer) (T, error) {
+	var zero T
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return zero, fmt.Errorf("get: failed to build query: %w", err)
+	}
+	return Get[T](ctx, db, sql, args...)
+}
+
+// ListQuery: List with a built query
+func ListQuery[T any](ctx context.Context, db DB, query sq.Sqlizer) ([]T, error) {
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("list: failed to build query: %w", err)
+	}
+	return List[T](ctx, db, sql, args...)
+}
+
+// ExecQuery: runs a built insert / update / delete, returns the affected rows
+func ExecQuery(ctx context.Context, db DB, query sq.Sqlizer) (int64, error) {
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("exec: failed to build query: %w", err)
+	}
+
+	tag, err := db.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, mapError(err, "exec")
+	}
+	return tag.RowsAffected(), nil
+}
+
+// WhereIf: adds pred only when ok, for optional filters
+func WhereIf(query sq.SelectBuilder, ok bool, pred any, args ...any) sq.SelectBuilder {
+	if !ok {
+		return query
+	}
+	return query.Where(pred, args...)
+}
+
+// OrderBy: ORDER BY column from user input, rejected unless it is in allowed
+// empty column leaves the query unchanged
+func OrderBy(query sq.SelectBuilder, column string, desc bool, allowed []string) (sq.SelectBuilder, error) {
+	if column == "" {
+		return query, nil
+	}
+	if !slices.Contains(allowed, column) {
+		return query, fmt.Errorf("%w: cannot sort by %q", ErrInvalidSort, column)
+	}
+
+	direction := " ASC"
+	if desc {
+		direction = " DESC"
+	}
+	return query.OrderBy(column + direction), nil
+}
+
+// Paginate: LIMIT / OFFSET, 0 leaves them out
+func Paginate(query sq.SelectBuilder, limit, offset uint64) sq.SelectBuilder {
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	return query
+}
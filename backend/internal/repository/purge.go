@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+// @dev purge job: soft deleted rows older than database.soft_delete.retention are removed for good
+// @dev in batches, so a large backlog doesn't hold locks on many rows at once; every instance may run it
+
+// Purge: removes the rows of table soft deleted before before, returns how many
+func Purge(ctx context.Context, db DB, table string, before time.Time, batchSize int) (int64, error) {
+	quoted := pgx.Identifier(strings.Split(table, ".")).Sanitize()
+	sql := fmt.Sprintf(`
+		DELETE FROM %[1]s WHERE id IN (
+			SELECT id FROM %[1]s WHERE %[2]s < $1 LIMIT $2
+		)`, quoted, pgx.Identifier{DeletedAtColumn}.Sanitize())
+
+	var purged int64
+	for {
+		tag, err := db.Exec(ctx, sql, before, batchSize)
+		if err != nil {
+			return purged, mapError(err, "purge "+table)
+		}
+		purged += tag.RowsAffected()
+		if tag.RowsAffected() < int64(batchSize) {
+			return purged, nil
+		}
+	}
+}
+
+// Purger: runs Purge on database.soft_delete.purge_tables every purge_interval
+type Purger struct {
+	db  DB
+	cfg config.SoftDeleteConfig
+	log *zerolog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewPurger(db DB, cfg config.SoftDeleteConfig, logger *zerolog.Logger) *Purger {
+	return &Purger{db: db, cfg: cfg, log: logger}
+}
+
+// Start: purges in the background until ctx is done or Close is called, the first run is right away
+func (p *Purger) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+		p.run(ctx)
+	}()
+}
+
+// Close: stops the purger after the running batch
+func (p *Purger) Close() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
+
+func (p *Purger) run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Purger) purge(ctx context.Context) {
+	before := time.Now().Add(-p.cfg.Retention)
+
+	for _, table := range p.cfg.PurgeTables {
+		purged, err := Purge(ctx, p.db, table, before, p.cfg.BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				loggerConfig.ErrorFields(p.log.Error(), err).Str("table", table).Msg("soft delete purge failed")
+			}
+			continue
+		}
+		if purged > 0 {
+			p.log.Info().Str("table", table).Int64("purged", purged).Msg("purged soft deleted rows")
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+/**
+@dev soft delete: rows get a deleted_at timestamp instead of being removed, they can be restored until they are purged
+
+orders := repository.NewSoftDeleteRepository[Order](db, "orders")
+orders.Get(ctx, id) / orders.List(ctx, q)   → deleted rows are left out (deleted_at IS NULL)
+orders.Delete(ctx, id)                      → SET deleted_at = now()
+orders.Restore(ctx, id)                     → SET deleted_at = NULL
+orders.HardDelete(ctx, id)                  → DELETE, for erasure requests
+rows deleted longer than database.soft_delete.retention ago are purged by the Purger (purge.go)
+
+the column: go-boilerplate migrate new -add-soft-delete orders
+unique columns should only be unique among live rows: CREATE UNIQUE INDEX ... ON orders (number) WHERE deleted_at IS NULL
+*/
+
+// DeletedAtColumn: soft delete timestamp, NULL for live rows
+const DeletedAtColumn = "deleted_at"
+
+// NotDeleted: only live rows, for queries built outside of a SoftDeleteRepository
+func NotDeleted(query sq.SelectBuilder) sq.SelectBuilder {
+	return query.Where(sq.Eq{DeletedAtColumn: nil})
+}
+
+// OnlyDeleted: only soft deleted rows, e.g. a trash view
+func OnlyDeleted(query sq.SelectBuilder) sq.SelectBuilder {
+	return query.Where(sq.NotEq{DeletedAtColumn: nil})
+}
+
+// SoftDeleteRepository: rows of table scanned into T, the table has an "id" and a deleted_at column
+type SoftDeleteRepository[T any] struct {
+	db    DB
+	table string
+}
+
+func NewSoftDeleteRepository[T any](db DB, table string) *SoftDeleteRepository[T] {
+	return &SoftDeleteRepository[T]{db: db, table: table}
+}
+
+// WithTx: same repository running its queries in tx
+func (r *SoftDeleteRepository[T]) WithTx(tx pgx.Tx) *SoftDeleteRepository[T] {
+	return &SoftDeleteRepository[T]{db: tx, table: r.table}
+}
+
+// Select: SELECT * FROM table of the live rows, base of List queries
+func (r *SoftDeleteRepository[T]) Select() sq.SelectBuilder {
+	return NotDeleted(r.SelectWithDeleted())
+}
+
+// SelectWithDeleted: SELECT * FROM table including the soft deleted rows
+func (r *SoftDeleteRepository[T]) SelectWithDeleted() sq.SelectBuilder {
+	return Builder.Select("*").From(r.quotedTable())
+}
+
+// Get: live row id, ErrNotFound when it doesn't exist or is deleted
+func (r *SoftDeleteRepository[T]) Get(ctx context.Context, id any) (T, error) {
+	return GetQuery[T](ctx, r.db, r.Select().Where(sq.Eq{"id": id}))
+}
+
+// List: rows of query, build it from Select to leave the deleted rows out
+func (r *SoftDeleteRepository[T]) List(ctx context.Context, query sq.SelectBuilder) ([]T, error) {
+	return ListQuery[T](ctx, r.db, query)
+}
+
+// Delete: soft deletes row id, ErrNotFound when it doesn't exist or is already deleted
+func (r *SoftDeleteRepository[T]) Delete(ctx context.Context, id any) error {
+	return r.exec(ctx, "delete", Builder.Update(r.quotedTable()).
+		Set(DeletedAtColumn, sq.Expr("now()")).
+		Where(sq.Eq{"id": id, DeletedAtColumn: nil}))
+}
+
+// Restore: undoes Delete, ErrNotFound when row id doesn't exist or isn't deleted
+func (r *SoftDeleteRepository[T]) Restore(ctx context.Context, id any) error {
+	return r.exec(ctx, "restore", Builder.Update(r.quotedTable()).
+		Set(DeletedAtColumn, nil).
+		Where(sq.Eq{"id": id}).
+		Where(sq.NotEq{DeletedAtColumn: nil}))
+}
+
+// HardDelete: removes row id for good, deleted or not
+func (r *SoftDeleteRepository[T]) HardDelete(ctx context.Context, id any) error {
+	return r.exec(ctx, "hard delete", Builder.Delete(r.quotedTable()).Where(sq.Eq{"id": id}))
+}
+
+// exec: ErrNotFound when no row was affected
+func (r *SoftDeleteRepository[T]) exec(ctx context.Context, op string, query sq.Sqlizer) error {
+	affected, err := ExecQuery(ctx, r.db, query)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", op, r.table, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s %s: %w", op, r.table, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *SoftDeleteRepository[T]) quotedTable() string {
+	return pgx.Identifier(strings.Split(r.table, ".")).Sanitize()
+}
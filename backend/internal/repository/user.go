@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/database"
+	"github.com/anuragShingare30/go-boilerplate/internal/database/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+/**
+@dev repository layer: feature code talks to repositories, repositories talk to the sqlc generated queries
+
+internal/database/queries/*.sql   → SQL with "-- name: GetUser :one" annotations
+    → task sqlc:generate          → internal/database/sqlc (never edited by hand)
+        → repository              → maps pgx errors to ErrNotFound / ErrConflict and hides the sqlc types of params
+
+*Database implements sqlc.DBTX, so generated queries get the default query timeout as well
+*/
+
+var (
+	// ErrNotFound: row doesn't exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict: unique constraint violation, e.g. email already taken
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidSort: sort column which is not allowed, see OrderBy
+	ErrInvalidSort = errors.New("invalid sort column")
+)
+
+// pgUniqueViolation: postgres error code of unique constraint violations
+const pgUniqueViolation = "23505"
+
+// UserRepository: CRUD of users
+type UserRepository struct {
+	db      *database.Database
+	queries *sqlc.Queries
+}
+
+func NewUserRepository(db *database.Database) *UserRepository {
+	return &UserRepository{db: db, queries: sqlc.New(db)}
+}
+
+// WithTx: repository running its queries in tx, for use inside db.WithTx
+func (r *UserRepository) WithTx(tx pgx.Tx) *UserRepository {
+	return &UserRepository{db: r.db, queries: r.queries.WithTx(tx)}
+}
+
+// Get: user by id
+func (r *UserRepository) Get(ctx context.Context, id uuid.UUID) (sqlc.User, error) {
+	user, err := r.queries.GetUser(ctx, id)
+	return user, mapError(err, "get user")
+}
+
+// GetByEmail: user by email
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (sqlc.User, error) {
+	user, err := r.queries.GetUserByEmail(ctx, email)
+	return user, mapError(err, "get user by email")
+}
+
+// List: newest users first
+func (r *UserRepository) List(ctx context.Context, limit, offset int32) ([]sqlc.User, error) {
+	users, err := r.queries.ListUsers(ctx, sqlc.ListUsersParams{Limit: limit, Offset: offset})
+	return users, mapError(err, "list users")
+}
+
+// Create: inserts a user, ErrConflict when the email is taken
+func (r *UserRepository) Create(ctx context.Context, email, name string) (sqlc.User, error) {
+	user, err := r.queries.CreateUser(ctx, sqlc.CreateUserParams{Email: email, Name: name})
+	return user, mapError(err, "create user")
+}
+
+// UpdateName: changes the name of a user
+func (r *UserRepository) UpdateName(ctx context.Context, id uuid.UUID, name string) (sqlc.User, error) {
+	user, err := r.queries.UpdateUser(ctx, sqlc.UpdateUserParams{ID: id, Name: name})
+	return user, mapError(err, "update user")
+}
+
+// Delete: deletes a user, ErrNotFound when there was none
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	deleted, err := r.queries.DeleteUser(ctx, id)
+	if err != nil {
+		return mapError(err, "delete user")
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// mapError: pgx errors to repository errors, the original error stays in the chain
+func mapError(err error, op string) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return fmt.Errorf("%s: %w: %w", op, ErrConflict, err)
+	}
+
+	return fmt.Errorf("%s: %w", op, err)
+}
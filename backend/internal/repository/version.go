@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+/**
+@dev optimistic locking: the row carries a version integer, every update bumps it, an update based on an old read is refused
+
+user, err := repository.UpdateWithVersion[User](ctx, db, "users", user.ID, user.Version, map[string]any{"name": name})
+    → UPDATE users SET name = $1, version = version + 1 WHERE id = $2 AND version = $3 RETURNING *
+    → no row updated: ErrNotFound when the id doesn't exist, *StaleObjectError when someone else updated it first
+      reload and retry, or answer 409 (errors.Is(err, ErrConflict) holds as well)
+
+the column: go-boilerplate migrate new -add-version users
+*/
+
+// VersionColumn: column checked and bumped by UpdateWithVersion
+const VersionColumn = "version"
+
+// ErrStaleObject: errors.Is target of StaleObjectError
+var ErrStaleObject = errors.New("stale object")
+
+// StaleObjectError: the row changed since it was read at Version
+type StaleObjectError struct {
+	Table   string
+	ID      any
+	Version int // version the update was based on
+	Current int // version in the database
+}
+
+func (e *StaleObjectError) Error() string {
+	return fmt.Sprintf("%s %v is stale: read at version %d, now at version %d", e.Table, e.ID, e.Version, e.Current)
+}
+
+// Is: errors.Is(err, ErrStaleObject)
+func (e *StaleObjectError) Is(target error) bool {
+	return target == ErrStaleObject
+}
+
+// Unwrap: a stale update is a conflict, handlers answering 409 on ErrConflict cover it
+func (e *StaleObjectError) Unwrap() error {
+	return ErrConflict
+}
+
+// UpdateWithVersion: sets changes on the row id of table if it is still at version, returns the updated row
+func UpdateWithVersion[T any](ctx context.Context, db DB, table string, id any, version int, changes map[string]any) (T, error) {
+	var zero T
+
+	if len(changes) == 0 {
+		return zero, fmt.Errorf("update %s: no changes", table)
+	}
+	if _, ok := changes[VersionColumn]; ok {
+		return zero, fmt.Errorf("update %s: %s is set by UpdateWithVersion", table, VersionColumn)
+	}
+
+	quotedTable := pgx.Identifier(strings.Split(table, ".")).Sanitize()
+	quotedVersion := pgx.Identifier{VersionColumn}.Sanitize()
+
+	query := Builder.Update(quotedTable).
+		Set(quotedVersion, sq.Expr(quotedVersion+" + 1")).
+		Where(sq.Eq{`"id"`: id, quotedVersion: version}).
+		Suffix("RETURNING *")
+	// sorted, so the same update always has the same SQL (statement cache)
+	for _, column := range slices.Sorted(maps.Keys(changes)) {
+		query = query.Set(pgx.Identifier{column}.Sanitize(), changes[column])
+	}
+
+	updated, err := GetQuery[T](ctx, db, query)
+	if err == nil {
+		return updated, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return zero, fmt.Errorf("update %s: %w", table, err)
+	}
+
+	// nothing matched: deleted meanwhile, or updated by someone else
+	var current int
+	err = db.QueryRow(ctx,
+		"SELECT "+quotedVersion+" FROM "+quotedTable+` WHERE "id" = $1`, id,
+	).Scan(&current)
+	if err != nil {
+		return zero, mapError(err, "update "+table)
+	}
+	return zero, &StaleObjectError{Table: table, ID: id, Version: version, Current: current}
+}
@@ -0,0 +1,82 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// @dev startup retry: with docker compose / kubernetes postgres and redis often come up after the service
+// @dev their first connection is retried with exponential backoff + jitter (database.connect_retry, redis.connect_retry)
+// @dev errors wrapped with Permanent (wrong password, missing database) fail right away, waiting doesn't fix them
+
+// Connect: runs connect until it succeeds or cfg.MaxAttempts is reached
+func Connect(ctx context.Context, cfg config.ConnectRetryConfig, logger *zerolog.Logger, what string, connect func(ctx context.Context) error) error {
+	attempts := max(cfg.MaxAttempts, 1)
+	backoff := cfg.InitialBackoff
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = connect(ctx); err == nil {
+			if attempt > 1 {
+				logger.Info().Int("attempt", attempt).Msgf("%s succeeded", what)
+			}
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return fmt.Errorf("%s failed: %w", what, err)
+		}
+		if attempt == attempts {
+			break
+		}
+
+		wait := Jitter(backoff, cfg.Jitter)
+		logger.Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Int("max_attempts", attempts).
+			Dur("retry_in", wait).
+			Msgf("%s failed, retrying", what)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", what, ctx.Err())
+		}
+		backoff = min(backoff*2, cfg.MaxBackoff)
+	}
+
+	return fmt.Errorf("%s failed after %d attempts: %w", what, attempts, err)
+}
+
+// permanentError: an error of connect which another attempt won't change
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent: marks err as not retryable, Connect returns it without waiting for the remaining attempts
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Jitter: d ± fraction*d, keeps replicas booting together from retrying in lockstep
+func Jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || d <= 0 {
+		return d
+	}
+	spread := float64(d) * fraction
+	return time.Duration(float64(d) - spread + rand.Float64()*2*spread)
+}
@@ -0,0 +1,19 @@
+package router
+
+// Doc: OpenAPI description of a route, the document is generated from Routes() by internal/openapi
+// routes without a Doc are not part of the API contract (metrics, admin, health, ...)
+type Doc struct {
+	Summary     string
+	Description string
+	Tags        []string // the first path segment when empty, e.g. "users" for /users/{id}
+	// struct read by the handler: json fields are the body, query / path tagged fields are parameters
+	// a listquery.Spec documents the paging, sort and filter parameters of list endpoints
+	Request any
+	// body of the success response, e.g. User{} or []User{}
+	Response any
+	Status   int // of the success response, 200 when 0
+	// error statuses of the route, each documented with the error envelope
+	Errors     []int
+	Auth       bool // needs "Authorization: Bearer <token>"
+	Deprecated bool
+}
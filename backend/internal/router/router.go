@@ -0,0 +1,273 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev router: net/http ServeMux (method + path patterns, {param}) with route groups and middleware chains
+
+r := router.New(&log, nrApp)
+r.Use(middleware.RequestID, ...)               → global: every request, unmatched ones (404 / 405) too
+r.Get("/users/{id}", h.Get)                     → router.Param(req, "id")
+r.Group("/admin", func(g *router.Router) {
+	g.Use(adminAuth)                            → group: only the routes of g (and its subgroups)
+	g.Put("/loglevel", h.Put)                   → PUT /admin/loglevel
+})
+r.With(timeout).Post("/reports", h.Create)      → middlewares of a single route
+api.Version(router.Version{Name: "v1", ...})    → /api/v1 group, see version.go
+r.Post("/users", h.Create).Describe(router.Doc{...}) → operation of the OpenAPI document (internal/openapi)
+
+request flow:
+ServeHTTP → New Relic transaction + request scoped logger (trace.id / span.id) in the context
+    → global middlewares → ServeMux → route: transaction named after the pattern ("GET /users/{id}")
+        → group middlewares → handler
+
+middlewares are plain func(http.Handler) http.Handler, the ones of internal/middleware fit as they are
+*/
+
+// Middleware: wraps a handler, runs code before and / or after it
+type Middleware = func(http.Handler) http.Handler
+
+// Chain: one middleware running mws in order, the first one is the outermost
+func Chain(mws ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			next = mws[i](next)
+		}
+		return next
+	}
+}
+
+// Route: a registered route, for listing and documenting the API
+type Route struct {
+	Method  string // "" for every method
+	Pattern string // path pattern including the group prefixes
+	Doc     *Doc   // set with Describe, nil for undocumented routes
+	Version string // name of the version group, "" outside of one
+	// Deprecated: the version of the route is deprecated, the operation is marked in the OpenAPI document
+	Deprecated bool
+}
+
+// Describe: adds the API documentation of the route, r.Get("/users/{id}", h.Get).Describe(router.Doc{...})
+func (rt *Route) Describe(doc Doc) *Route {
+	rt.Doc = &doc
+	return rt
+}
+
+// Router: group of routes sharing a path prefix and middlewares
+type Router struct {
+	root        *root
+	top         bool // the router of New, Use adds global middlewares
+	prefix      string
+	middlewares []Middleware
+	version     *Version // set by Version, inherited by the subgroups
+}
+
+// root: state shared by a router and all of its groups
+type root struct {
+	mux     *http.ServeMux
+	log     *zerolog.Logger
+	nrApp   *newrelic.Application // nil without New Relic
+	datadog bool                  // dd.trace_id / dd.span_id of the OTel span on request loggers instead of the New Relic ids
+	global  []Middleware
+	routes  []*Route
+
+	once    sync.Once
+	handler http.Handler // global middlewares around mux, built on the first request
+}
+
+// New: empty router, nrApp may be nil
+func New(logger *zerolog.Logger, nrApp *newrelic.Application) *Router {
+	return &Router{top: true, root: &root{
+		mux:   http.NewServeMux(),
+		log:   logger,
+		nrApp: nrApp,
+	}}
+}
+
+// UseDatadogTraceContext: request loggers carry the Datadog ids of the OpenTelemetry span of the request, for observability.provider datadog
+// the span has to be on the request context already, e.g. started by an otelhttp handler around the router
+func (r *Router) UseDatadogTraceContext() {
+	r.root.datadog = true
+}
+
+// Use: adds middlewares, on the top level router they run for every request,
+// on a group only for its routes registered afterwards
+func (r *Router) Use(mws ...Middleware) {
+	if r.top {
+		r.root.global = append(r.root.global, mws...)
+		return
+	}
+	r.middlewares = append(r.middlewares, mws...)
+}
+
+// Group: routes below prefix, fn registers them on the group
+func (r *Router) Group(prefix string, fn func(g *Router)) *Router {
+	g := r.sub(prefix)
+	if fn != nil {
+		fn(g)
+	}
+	return g
+}
+
+// With: group without prefix for routes which need extra middlewares
+func (r *Router) With(mws ...Middleware) *Router {
+	g := r.sub("")
+	g.middlewares = append(g.middlewares, mws...)
+	return g
+}
+
+func (r *Router) sub(prefix string) *Router {
+	return &Router{
+		root:   r.root,
+		prefix: r.prefix + strings.TrimSuffix(prefix, "/"),
+		// own slice, the middlewares of a group don't leak into its parent
+		middlewares: append([]Middleware(nil), r.middlewares...),
+		version:     r.version,
+	}
+}
+
+// Handle: registers h for pattern, "[METHOD ]/path" like http.ServeMux, the group prefix is put in front of the path
+func (r *Router) Handle(pattern string, h http.Handler) *Route {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+	path = r.path(strings.TrimSpace(path))
+
+	full := path
+	if method != "" {
+		full = method + " " + path
+	}
+
+	route := &Route{Method: method, Pattern: path}
+	if r.version != nil {
+		route.Version = r.version.Name
+		route.Deprecated = r.version.deprecated()
+	}
+	r.root.routes = append(r.root.routes, route)
+	r.root.mux.Handle(full, r.root.route(full, Chain(r.middlewares...)(h)))
+	return route
+}
+
+// HandleFunc: Handle with a handler function
+func (r *Router) HandleFunc(pattern string, fn http.HandlerFunc) *Route {
+	return r.Handle(pattern, fn)
+}
+
+func (r *Router) Get(path string, fn http.HandlerFunc) *Route {
+	return r.Handle(http.MethodGet+" "+path, fn)
+}
+func (r *Router) Post(path string, fn http.HandlerFunc) *Route {
+	return r.Handle(http.MethodPost+" "+path, fn)
+}
+func (r *Router) Put(path string, fn http.HandlerFunc) *Route {
+	return r.Handle(http.MethodPut+" "+path, fn)
+}
+func (r *Router) Patch(path string, fn http.HandlerFunc) *Route {
+	return r.Handle(http.MethodPatch+" "+path, fn)
+}
+func (r *Router) Delete(path string, fn http.HandlerFunc) *Route {
+	return r.Handle(http.MethodDelete+" "+path, fn)
+}
+
+// Mount: h serves everything below prefix, with prefix stripped from the path (pprof, static files, ...)
+func (r *Router) Mount(prefix string, h http.Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	// Handle puts the group prefix in front, StripPrefix needs the full one
+	r.Handle(prefix+"/", http.StripPrefix(r.path(prefix), h))
+}
+
+// path: path below the group prefix, "/" of a group is the prefix itself
+func (r *Router) path(path string) string {
+	if r.prefix != "" && path == "/" {
+		return r.prefix
+	}
+	return r.prefix + path
+}
+
+// Routes: every registered route in registration order
+func (r *Router) Routes() []Route {
+	routes := make([]Route, len(r.root.routes))
+	for i, route := range r.root.routes {
+		routes[i] = *route
+	}
+	return routes
+}
+
+// ServeHTTP implements http.Handler
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rt := r.root
+	// routes and Use calls happen at startup, before the first request
+	rt.once.Do(func() {
+		rt.handler = Chain(rt.global...)(rt.mux)
+	})
+
+	ctx := req.Context()
+	info := &routeInfo{}
+	ctx = context.WithValue(ctx, routeInfoKey{}, info)
+
+	var txn *newrelic.Transaction
+	if rt.nrApp != nil {
+		// renamed after the pattern once a route matches, requests without one stay "NotFound"
+		txn = rt.nrApp.StartTransaction("NotFound")
+		defer txn.End()
+		txn.SetWebRequestHTTP(req)
+		w = txn.SetWebResponse(w)
+		ctx = newrelic.NewContext(ctx, txn)
+	}
+
+	// base of the request scoped logger, loggerConfig.FromContext(ctx) in handlers
+	requestLogger := loggerConfig.WithTraceContext(*rt.log, txn)
+	if rt.datadog {
+		requestLogger = loggerConfig.WithDatadogTraceContext(*rt.log, ctx)
+	}
+	ctx = loggerConfig.WithContext(ctx, requestLogger)
+
+	rt.handler.ServeHTTP(w, req.WithContext(ctx))
+}
+
+// route: the handler of one pattern, records the match before the group middlewares run
+func (rt *root) route(pattern string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if info, ok := req.Context().Value(routeInfoKey{}).(*routeInfo); ok {
+			info.pattern = pattern
+		}
+		if txn := newrelic.FromContext(req.Context()); txn != nil {
+			txn.SetName(pattern)
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+type routeInfoKey struct{}
+
+// routeInfo: filled in when a route matches, readable by the global middlewares after next returns
+type routeInfo struct {
+	pattern string
+}
+
+// Param: value of the {name} segment of the matched pattern
+func Param(req *http.Request, name string) string {
+	return req.PathValue(name)
+}
+
+// RoutePattern: pattern of the matched route ("GET /users/{id}"), "" when none matched (yet)
+// global middlewares see it once next has returned
+func RoutePattern(req *http.Request) string {
+	if req.Pattern != "" {
+		return req.Pattern
+	}
+	if info, ok := req.Context().Value(routeInfoKey{}).(*routeInfo); ok {
+		return info.pattern
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+@dev API versions: groups below the api prefix with their own middlewares, old versions announce their end
+
+api := r.Group("/api", nil)
+v1 := api.Version(router.Version{
+	Name:       "v1",
+	Deprecated: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),   → Deprecation: @1767225600
+	Sunset:     time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),   → Sunset: Wed, 01 Jul 2026 00:00:00 GMT
+	Link:       "https://docs.example.com/migrate-to-v2",      → Link: <...>; rel="deprecation"
+})
+v1.Use(legacyAuth)                               → only the routes of v1
+v2 := api.Version(router.Version{Name: "v2"})
+
+router.Versions(func(g *router.Router) {         → same handlers under /api/v1 and /api/v2 while clients migrate
+	g.Get("/users/{id}", h.Get)
+}, v1, v2)
+v2.Get("/users", h.ListV2)                       → what changed only in the new version
+
+headers follow RFC 9745 (Deprecation) and RFC 8594 (Sunset), routes of deprecated versions are deprecated in the OpenAPI document
+*/
+
+// Version: one API version, zero dates for a current version, a Sunset alone is sent without Deprecation
+type Version struct {
+	Name       string    // path segment, e.g. "v1"
+	Deprecated time.Time // since when the version is deprecated, may be in the future
+	Sunset     time.Time // when the version stops being served
+	Link       string    // migration guide or successor, sent as Link with rel="deprecation"
+}
+
+func (v *Version) deprecated() bool {
+	return !v.Deprecated.IsZero() || !v.Sunset.IsZero()
+}
+
+// Version: group of the routes of version below the prefix of r, e.g. /api/v1
+func (r *Router) Version(version Version) *Router {
+	g := r.sub("/" + strings.Trim(version.Name, "/"))
+	g.version = &version
+	if version.deprecated() {
+		g.middlewares = append(g.middlewares, deprecationHeaders(version))
+	}
+	return g
+}
+
+// Versions: registers the routes of fn in every group of versions, one handler served by several versions
+func Versions(fn func(g *Router), versions ...*Router) {
+	for _, g := range versions {
+		fn(g)
+	}
+}
+
+// deprecationHeaders: Deprecation, Sunset and Link on every response of a deprecated version
+func deprecationHeaders(version Version) Middleware {
+	var deprecation, sunset, link string
+	if !version.Deprecated.IsZero() {
+		deprecation = "@" + strconv.FormatInt(version.Deprecated.Unix(), 10)
+	}
+	if !version.Sunset.IsZero() {
+		sunset = version.Sunset.UTC().Format(http.TimeFormat)
+	}
+	if version.Link != "" {
+		link = "<" + version.Link + `>; rel="deprecation"; type="text/html"`
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			if deprecation != "" {
+				header.Set("Deprecation", deprecation)
+			}
+			if sunset != "" {
+				header.Set("Sunset", sunset)
+			}
+			if link != "" {
+				header.Add("Link", link)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"golang.org/x/net/http2"
+)
+
+/**
+@dev protocols of the main listener: HTTP/1.1 always, HTTP/2 through ALPN with TLS or as h2c without
+h2c is only for a trusted proxy in front, browsers never speak HTTP/2 without TLS
+
+timeouts per protocol:
+    HTTP/1.1 → server.read_timeout / write_timeout per request, server.idle_timeout between keep-alive requests
+    h2       → server.read_timeout per stream, server.http2.write_timeout per stream (none by default),
+               server.http2.idle_timeout without open streams, pings (ping_interval / ping_timeout) find dead peers
+net/http has one IdleTimeout for both protocols, golang.org/x/net/http2 serves h2 so it can have its own
+*/
+
+// protocols: what the listener accepts, tlsEnabled is whether it terminates TLS itself
+func protocols(cfg config.HTTP2Config, tlsEnabled bool) *http.Protocols {
+	p := &http.Protocols{}
+	p.SetHTTP1(true)
+	if cfg.Enabled {
+		if tlsEnabled {
+			p.SetHTTP2(true)
+		} else if cfg.H2C {
+			p.SetUnencryptedHTTP2(true)
+		}
+	}
+	return p
+}
+
+// http2Config: stream limit and connection pings, nil without HTTP/2
+func http2Config(cfg config.HTTP2Config) *http.HTTP2Config {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &http.HTTP2Config{
+		MaxConcurrentStreams: cfg.MaxConcurrentStreams,
+		SendPingTimeout:      cfg.PingInterval,
+		PingTimeout:          cfg.PingTimeout,
+	}
+}
+
+// configureHTTP2: h2 / h2c served by golang.org/x/net/http2 with the idle timeout of server.http2
+// the settings of srv.HTTP2 (streams, pings) are taken over by it
+func configureHTTP2(srv *http.Server, cfg config.HTTP2Config) error {
+	tlsConfig := srv.TLSConfig
+	err := http2.ConfigureServer(srv, &http2.Server{IdleTimeout: cfg.IdleTimeout})
+	// ConfigureServer adds a TLS config, a plaintext (h2c) server has to stay without, Start tells TLS by it
+	if tlsConfig == nil {
+		srv.TLSConfig = nil
+	}
+	return err
+}
+
+// streamWriteTimeout: write deadline of h2 streams, net/http applies server.write_timeout to them as well
+func streamWriteTimeout(next http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 {
+			var deadline time.Time
+			if timeout > 0 {
+				deadline = time.Now().Add(timeout)
+			}
+			_ = http.NewResponseController(w).SetWriteDeadline(deadline)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withoutH2: ALPN list without h2, autocert offers it on its own and clients would pick a protocol the server refuses
+func withoutH2(tlsConfig *tls.Config) *tls.Config {
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.NextProtos = slices.DeleteFunc(slices.Clone(tlsConfig.NextProtos), func(proto string) bool {
+		return proto == "h2"
+	})
+	return tlsConfig
+}
+
+// protocolNames: for the startup log
+func protocolNames(p *http.Protocols) []string {
+	var names []string
+	if p.HTTP1() {
+		names = append(names, "http/1.1")
+	}
+	if p.HTTP2() {
+		names = append(names, "h2")
+	}
+	if p.UnencryptedHTTP2() {
+		names = append(names, "h2c")
+	}
+	return names
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// @dev spins up the HTTP server, used in main.go
+// @dev SIGINT / SIGTERM in main → Shutdown: no new connections, running requests get server.shutdown_timeout to finish
+
+type Server struct {
+	Config      *config.Config
+	Logger      *zerolog.Logger
+	httpServer  *http.Server
+	certManager *autocert.Manager
+	acmeServer  *http.Server // port 80 listener of the autocert challenges, nil without autocert
+	debugServer *http.Server // server.debug.address listener, nil unless ServeDebug was called
+	adminServer *http.Server // server.admin_listener listener, nil unless ServeAdmin was called
+}
+
+// New: creates the http server for the given handler, with TLS when enabled in config and HTTP/2 per server.http2
+func New(cfg *config.Config, logger *zerolog.Logger, handler http.Handler) (*Server, error) {
+	tlsConfig, certManager, err := NewTLSConfig(&cfg.Server.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	serverProtocols := protocols(cfg.Server.HTTP2, tlsConfig != nil)
+	if tlsConfig != nil && !serverProtocols.HTTP2() {
+		tlsConfig = withoutH2(tlsConfig)
+	}
+
+	// server.*_timeout are seconds, a slow or idle client can't hold a connection forever
+	// they are the HTTP/1.1 timeouts, h2 has its own in server.http2 (see http2.go)
+	// ReadHeaderTimeout covers the TLS handshake as well
+	h2 := serverProtocols.HTTP2() || serverProtocols.UnencryptedHTTP2()
+	if h2 {
+		handler = streamWriteTimeout(handler, cfg.Server.HTTP2.WriteTimeout)
+	}
+	httpServer := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		Protocols:         serverProtocols,
+		HTTP2:             http2Config(cfg.Server.HTTP2),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout) * time.Second,
+	}
+	if h2 {
+		if err := configureHTTP2(httpServer, cfg.Server.HTTP2); err != nil {
+			return nil, fmt.Errorf("configuring http2: %w", err)
+		}
+	}
+
+	srv := &Server{
+		Config:      cfg,
+		Logger:      logger,
+		httpServer:  httpServer,
+		certManager: certManager,
+	}
+	if certManager != nil {
+		srv.acmeServer = &http.Server{
+			Addr:              ":http",
+			Handler:           certManager.HTTPHandler(nil),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+	return srv, nil
+}
+
+// ServeDebug: serves handler (pprof, runtime stats) on server.debug.address next to the main listener, call it before Start
+// no read / write timeouts besides the header one, CPU profiles and traces run as long as asked for
+func (s *Server) ServeDebug(handler http.Handler) {
+	s.debugServer = &http.Server{
+		Addr:              s.Config.Server.Debug.Address,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// ServeAdmin: serves handler (probes, metrics, /admin) on server.admin_listener.address next to the main listener, call it before Start
+// no write timeout either, pprof on /admin/debug lives here when the debug listener isn't set
+func (s *Server) ServeAdmin(handler http.Handler) {
+	s.adminServer = &http.Server{
+		Addr:              s.Config.Server.AdminListener.Address,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       time.Duration(s.Config.Server.IdleTimeout) * time.Second,
+	}
+}
+
+// Start: starts listening, blocks until the server is closed
+func (s *Server) Start() error {
+	// bound before the main listener: without it the probes fail, a taken port stops the start right away
+	if s.adminServer != nil {
+		listener, err := net.Listen("tcp", s.adminServer.Addr)
+		if err != nil {
+			return fmt.Errorf("admin listener failed: %w", err)
+		}
+		s.Logger.Info().Str("addr", s.adminServer.Addr).Msg("starting admin server")
+		go func() {
+			if err := ignoreServerClosed(s.adminServer.Serve(listener)); err != nil {
+				s.Logger.Error().Err(err).Msg("admin listener stopped")
+			}
+		}()
+	}
+
+	if s.debugServer != nil {
+		go func() {
+			s.Logger.Info().Str("addr", s.debugServer.Addr).Msg("starting debug server")
+			if err := ignoreServerClosed(s.debugServer.ListenAndServe()); err != nil {
+				s.Logger.Error().Err(err).Msg("debug listener stopped")
+			}
+		}()
+	}
+
+	if s.httpServer.TLSConfig == nil {
+		s.Logger.Info().Str("addr", s.httpServer.Addr).Strs("protocols", protocolNames(s.httpServer.Protocols)).Msg("starting http server")
+		return ignoreServerClosed(s.httpServer.ListenAndServe())
+	}
+
+	// autocert needs port 80 to answer the ACME HTTP-01 challenges
+	if s.acmeServer != nil {
+		go func() {
+			if err := ignoreServerClosed(s.acmeServer.ListenAndServe()); err != nil {
+				s.Logger.Error().Err(err).Msg("acme challenge listener stopped")
+			}
+		}()
+	}
+
+	s.Logger.Info().Str("addr", s.httpServer.Addr).Strs("protocols", protocolNames(s.httpServer.Protocols)).Msg("starting https server")
+	// certificates are already in TLSConfig, so no files are passed here
+	return ignoreServerClosed(s.httpServer.ListenAndServeTLS("", ""))
+}
+
+// Shutdown: stops accepting connections and waits for running requests until ctx is done
+// connections still open at the deadline are closed, their requests are cut off
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.Logger.Info().Msg("shutting down http server")
+
+	if s.acmeServer != nil {
+		_ = s.acmeServer.Shutdown(ctx)
+	}
+	// a running profile would hold the shutdown until the deadline, it is of no use anymore
+	if s.debugServer != nil {
+		_ = s.debugServer.Close()
+	}
+
+	var err error
+	if shutdownErr := s.httpServer.Shutdown(ctx); shutdownErr != nil {
+		err = errors.Join(fmt.Errorf("http server shutdown: %w", shutdownErr), s.httpServer.Close())
+	}
+
+	// probes and metrics stay reachable while the main listener drains, the admin listener stops last
+	if s.adminServer != nil {
+		if shutdownErr := s.adminServer.Shutdown(ctx); shutdownErr != nil {
+			_ = s.adminServer.Close()
+		}
+	}
+	return err
+}
+
+// ignoreServerClosed: ErrServerClosed is returned on every normal shutdown, it is not a failure
+func ignoreServerClosed(err error) error {
+	if err == nil || errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return fmt.Errorf("http server failed: %w", err)
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// @dev TLS termination: normally done by the load balancer, but the server can do it itself
+// @dev either with static cert/key files or with autocert (ACME, e.g. Let's Encrypt)
+
+// NewTLSConfig: builds the tls.Config used by the http server
+// returns the autocert manager as well (nil for static certs), it has to answer the HTTP-01 challenges
+func NewTLSConfig(cfg *config.TLSConfig) (*tls.Config, *autocert.Manager, error) {
+	if !cfg.Enabled {
+		return nil, nil, nil
+	}
+
+	if cfg.Autocert.Enabled {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.Autocert.Hosts...),
+			Cache:      autocert.DirCache(cfg.Autocert.CacheDir),
+			Email:      cfg.Autocert.Email,
+		}
+
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, manager, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load tls key pair: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	return tlsConfig, nil, nil
+}
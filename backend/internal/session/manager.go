@@ -0,0 +1,161 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// @dev cookie handling: the cookie only carries the id, HttpOnly + Secure + SameSite by default
+
+// Manager: sessions bound to a cookie
+type Manager struct {
+	store  *Store
+	cookie config.SessionCookieConfig
+	log    *zerolog.Logger
+}
+
+// NewManager: sessions in redis with the session.* config
+func NewManager(client goredis.UniversalClient, cfg config.SessionConfig, logger *zerolog.Logger) *Manager {
+	return &Manager{
+		store:  NewStore(client, cfg.IdleTimeout, cfg.AbsoluteTimeout),
+		cookie: cfg.Cookie,
+		log:    logger,
+	}
+}
+
+// Store: the underlying store, for work on sessions outside of a request
+func (m *Manager) Store() *Store {
+	return m.store
+}
+
+// Create: new session of userID and its cookie on w, call it after a successful login
+// the session id is never reused, an id from before the login is worthless (session fixation)
+func (m *Manager) Create(ctx context.Context, w http.ResponseWriter, userID string, values map[string]any) (*Session, error) {
+	sess, err := m.store.Create(ctx, userID, values)
+	if err != nil {
+		return nil, err
+	}
+	m.setCookie(w, sess.ID, sess.ExpiresAt)
+	return sess, nil
+}
+
+// Get: session of the request cookie, ErrNotFound without one
+func (m *Manager) Get(ctx context.Context, r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(m.cookie.Name)
+	if err != nil || cookie.Value == "" {
+		return nil, ErrNotFound
+	}
+	return m.store.Get(ctx, cookie.Value)
+}
+
+// Refresh: saves sess (e.g. changed Values) and moves its idle expiry
+func (m *Manager) Refresh(ctx context.Context, sess *Session) error {
+	return m.store.Refresh(ctx, sess)
+}
+
+// Destroy: deletes the session of the request and clears its cookie (logout)
+func (m *Manager) Destroy(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	m.clearCookie(w)
+
+	cookie, err := r.Cookie(m.cookie.Name)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+	return m.store.Destroy(ctx, cookie.Value)
+}
+
+// Middleware: puts the session of the cookie into the request context, requests without one pass through
+func (m *Manager) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sess, err := m.Get(ctx, r)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			if _, cookieErr := r.Cookie(m.cookie.Name); cookieErr == nil {
+				// expired or destroyed elsewhere, the browser can drop the cookie
+				m.clearCookie(w)
+			}
+		case err != nil:
+			// redis down: the request goes on without a session, RequireSession answers 401
+			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to load session")
+		default:
+			if time.Since(sess.LastSeen) >= touchInterval {
+				if err := m.store.Refresh(ctx, sess); err != nil {
+					zerolog.Ctx(ctx).Warn().Err(err).Msg("failed to refresh session")
+				}
+			}
+			ctx = WithSession(ctx, sess)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireSession: 401 for requests without a session, use after Middleware
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if FromContext(r.Context()) == nil {
+			httpx.WriteError(w, r, http.StatusUnauthorized, httpx.ErrorDetail{Code: "unauthorized", Message: "no valid session"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m *Manager) setCookie(w http.ResponseWriter, id string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     m.cookie.Name,
+		Value:    id,
+		Path:     m.cookie.Path,
+		Domain:   m.cookie.Domain,
+		Expires:  expires,
+		Secure:   m.cookie.Secure,
+		HttpOnly: true,
+		SameSite: sameSite(m.cookie.SameSite),
+	})
+}
+
+func (m *Manager) clearCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     m.cookie.Name,
+		Value:    "",
+		Path:     m.cookie.Path,
+		Domain:   m.cookie.Domain,
+		MaxAge:   -1,
+		Secure:   m.cookie.Secure,
+		HttpOnly: true,
+		SameSite: sameSite(m.cookie.SameSite),
+	})
+}
+
+func sameSite(mode string) http.SameSite {
+	switch mode {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+type sessionKey struct{}
+
+// WithSession: ctx carrying sess
+func WithSession(ctx context.Context, sess *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sess)
+}
+
+// FromContext: session put into ctx by Middleware, nil without
+func FromContext(ctx context.Context) *Session {
+	sess, _ := ctx.Value(sessionKey{}).(*Session)
+	return sess
+}
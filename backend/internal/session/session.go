@@ -0,0 +1,160 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+/**
+@dev server side sessions in redis, an alternative to stateless JWTs which can be revoked at any time
+
+manager.Create(ctx, w, userID, values) → random id in the session cookie, session JSON in redis
+manager.Middleware(next) → session of the cookie in the request context, session.FromContext(ctx)
+    → sessions are refreshed at most once per touchInterval, each refresh moves the idle expiry
+manager.Destroy(ctx, w, r) → deleted in redis and the cookie cleared (logout)
+
+expiry: idle (session.idle_timeout without a request) or absolute (session.absolute_timeout after Create),
+whichever comes first, the redis TTL is always the earlier of the two so expired sessions vanish on their own
+
+redis holds sha256(id) as key, ids can't be read back from a dump of redis
+*/
+
+const (
+	// idBytes: 256 bit session ids
+	idBytes = 32
+	// touchInterval: requests closer together than this don't write the session again
+	// session.idle_timeout has to be longer (min 2m), or sessions in use would expire between refreshes
+	touchInterval = time.Minute
+)
+
+// ErrNotFound: no cookie, unknown or expired session
+var ErrNotFound = errors.New("session: not found")
+
+// Session: state of one login
+type Session struct {
+	ID        string         `json:"-"` // cookie value, never stored
+	UserID    string         `json:"user_id"`
+	Values    map[string]any `json:"values,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	LastSeen  time.Time      `json:"last_seen"`
+	ExpiresAt time.Time      `json:"expires_at"` // absolute expiry
+}
+
+// Store: sessions as JSON strings in redis
+type Store struct {
+	client          goredis.UniversalClient
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+}
+
+// NewStore: store on client with the idle / absolute expiry of the session.* config
+func NewStore(client goredis.UniversalClient, idleTimeout, absoluteTimeout time.Duration) *Store {
+	return &Store{client: client, idleTimeout: idleTimeout, absoluteTimeout: absoluteTimeout}
+}
+
+// Create: new session of userID with a fresh random id
+func (s *Store) Create(ctx context.Context, userID string, values map[string]any) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sess := &Session{
+		ID:        id,
+		UserID:    userID,
+		Values:    values,
+		CreatedAt: now,
+		LastSeen:  now,
+		ExpiresAt: now.Add(s.absoluteTimeout),
+	}
+	if err := s.save(ctx, sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// Get: session of id, ErrNotFound when it doesn't exist or expired
+func (s *Store) Get(ctx context.Context, id string) (*Session, error) {
+	data, err := s.client.Get(ctx, redisKey(id)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	sess.ID = id
+
+	// redis expires them too, the check covers clock differences and a TTL changed by hand
+	if s.expired(&sess, time.Now()) {
+		return nil, ErrNotFound
+	}
+	return &sess, nil
+}
+
+// Refresh: marks the session as used now and saves it, moves the idle expiry
+func (s *Store) Refresh(ctx context.Context, sess *Session) error {
+	now := time.Now()
+	if s.expired(sess, now) {
+		return ErrNotFound
+	}
+	sess.LastSeen = now
+	return s.save(ctx, sess)
+}
+
+// Destroy: deletes the session of id, unknown ids are no error
+func (s *Store) Destroy(ctx context.Context, id string) error {
+	if err := s.client.Del(ctx, redisKey(id)).Err(); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// save: writes sess with the TTL of whichever expiry comes first
+func (s *Store) save(ctx context.Context, sess *Session) error {
+	ttl := min(time.Until(sess.ExpiresAt), s.idleTimeout)
+	if ttl <= 0 {
+		return ErrNotFound
+	}
+
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	if err := s.client.Set(ctx, redisKey(sess.ID), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) expired(sess *Session, now time.Time) bool {
+	return !now.Before(sess.ExpiresAt) || now.Sub(sess.LastSeen) >= s.idleTimeout
+}
+
+// redisKey: hash of the id, a leaked key is no valid cookie
+func redisKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return "session:" + hex.EncodeToString(sum[:])
+}
+
+func newID() (string, error) {
+	b := make([]byte, idBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,305 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+)
+
+/**
+@dev server-sent events: one way push over a plain GET, browsers reconnect on their own (EventSource)
+
+func (h *JobHandler) Progress(w http.ResponseWriter, r *http.Request) {
+	stream, err := sse.Stream(w, r)
+	if err != nil {
+		apierror.Write(w, r, err)   → 500, nothing was written yet
+		return
+	}
+	go h.jobs.Watch(stream.Context(), id, func(p Progress) error {
+		return stream.Send(sse.Event{Name: "progress", Data: p})   → queued, Data as JSON
+	})
+	if err := stream.Serve(); err != nil {   → writes and flushes the queue until the client leaves, blocks the handler
+		log.Warn().Err(err).Msg("event stream failed")   → ErrSlowClient, write errors
+	}
+}
+
+    → heartbeat comment every server.sse.heartbeat of silence, idle proxies keep the connection
+    → Send never blocks: a client server.sse.queue_size events behind is closed with ErrSlowClient, it reconnects
+    → reconnect: the browser sends Last-Event-ID with the id of the last event it got, stream.LastEventID()
+    → Shutdown (at server shutdown) ends every stream, the graceful shutdown doesn't wait for them
+
+the write deadline of server.write_timeout is lifted for the stream
+server.request_timeout still ends it: give the route 0 in server.request_timeout.routes
+*/
+
+var (
+	// ErrUnsupported: the response writer can't flush, the stream would be buffered until the handler returns
+	ErrUnsupported = errors.New("sse: response writer does not support flushing")
+	// ErrClosed: the client left, or the stream was closed
+	ErrClosed = errors.New("sse: stream closed")
+	// ErrSlowClient: the send queue ran full, the stream was closed
+	ErrSlowClient = errors.New("sse: client too slow, stream closed")
+)
+
+// Event: one message, Data is sent as it is for string / []byte / json.RawMessage and as JSON otherwise
+type Event struct {
+	ID   string // sent back as Last-Event-ID on reconnect
+	Name string // event type of addEventListener, "message" when empty
+	Data any
+	// reconnect delay of the browser from this event on, 0 leaves it as it is
+	Retry time.Duration
+}
+
+var (
+	defaultMu  sync.RWMutex
+	defaultCfg = config.SSEConfig{Heartbeat: 15 * time.Second, QueueSize: 64, Retry: 3 * time.Second}
+
+	// streams: the open ones, ended by Shutdown
+	streams = make(map[*Conn]struct{})
+)
+
+// SetDefault: server.sse for the streams of Stream, call it once at startup
+func SetDefault(cfg config.SSEConfig) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultCfg = cfg
+}
+
+// Conn: an open event stream
+type Conn struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	cfg         config.SSEConfig
+	ctx         context.Context
+	cancel      context.CancelCauseFunc
+	queue       chan []byte // encoded events
+	lastEventID string
+}
+
+// Stream: switches the response to text/event-stream, nothing is sent before it
+func Stream(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if !canFlush(w) {
+		return nil, ErrUnsupported
+	}
+
+	defaultMu.RLock()
+	cfg := defaultCfg
+	defaultMu.RUnlock()
+
+	ctx, cancel := context.WithCancelCause(r.Context())
+	c := &Conn{
+		w:           w,
+		rc:          http.NewResponseController(w),
+		cfg:         cfg,
+		ctx:         ctx,
+		cancel:      cancel,
+		queue:       make(chan []byte, max(cfg.QueueSize, 1)),
+		lastEventID: r.Header.Get("Last-Event-ID"),
+	}
+
+	// a stream lives longer than any write timeout, ErrNotSupported just keeps it
+	_ = c.rc.SetWriteDeadline(time.Time{})
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	// nginx buffers proxied responses otherwise
+	header.Set("X-Accel-Buffering", "no")
+	header.Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+
+	// the client sees the stream as open once the first bytes arrive
+	first := ": connected\n\n"
+	if cfg.Retry > 0 {
+		first = "retry: " + strconv.FormatInt(cfg.Retry.Milliseconds(), 10) + "\n\n"
+	}
+	if err := c.write([]byte(first)); err != nil {
+		cancel(err)
+		return nil, err
+	}
+
+	// the request context ends with the handler, a stream which never got Serve is dropped as well
+	register(c)
+	context.AfterFunc(ctx, func() { unregister(c) })
+	return c, nil
+}
+
+// Context: done once the stream is over, for the producers of its events
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// LastEventID: id of the last event the client got before it reconnected, "" on the first connect
+func (c *Conn) LastEventID() string {
+	return c.lastEventID
+}
+
+// Send: queues ev for Serve, safe from any goroutine
+// an encoding error is returned as it is, the stream stays open
+func (c *Conn) Send(ev Event) error {
+	if c.ctx.Err() != nil {
+		return ErrClosed
+	}
+	var buf bytes.Buffer
+	if err := encode(&buf, ev); err != nil {
+		return err
+	}
+	select {
+	case c.queue <- buf.Bytes():
+		return nil
+	default:
+		c.cancel(ErrSlowClient)
+		return ErrSlowClient
+	}
+}
+
+// Close: ends the stream, Serve returns
+func (c *Conn) Close() {
+	c.cancel(ErrClosed)
+}
+
+// Serve: writes the queued events and heartbeats until the client leaves or the stream is closed
+// nil when the client left or Close was called, the reason otherwise (ErrSlowClient, write errors)
+func (c *Conn) Serve() error {
+	defer c.cancel(ErrClosed)
+
+	heartbeat := time.NewTicker(c.cfg.Heartbeat)
+	defer heartbeat.Stop()
+
+	var buf bytes.Buffer
+	for {
+		select {
+		case <-c.ctx.Done():
+			if err := context.Cause(c.ctx); errors.Is(err, ErrSlowClient) {
+				return err
+			}
+			return nil
+
+		case event := <-c.queue:
+			buf.Reset()
+			buf.Write(event)
+			// whatever else is queued goes out with the same flush
+			for drained := false; !drained; {
+				select {
+				case event := <-c.queue:
+					buf.Write(event)
+				default:
+					drained = true
+				}
+			}
+			if err := c.write(buf.Bytes()); err != nil {
+				return c.writeErr(err)
+			}
+			heartbeat.Reset(c.cfg.Heartbeat)
+
+		case <-heartbeat.C:
+			if err := c.write([]byte(": heartbeat\n\n")); err != nil {
+				return c.writeErr(err)
+			}
+		}
+	}
+}
+
+// write: b and a flush, a client which is gone fails here at the latest
+func (c *Conn) write(b []byte) error {
+	if _, err := c.w.Write(b); err != nil {
+		return fmt.Errorf("sse: write: %w", err)
+	}
+	if err := c.rc.Flush(); err != nil {
+		return fmt.Errorf("sse: flush: %w", err)
+	}
+	return nil
+}
+
+// writeErr: a write which failed because the client left is the normal end of a stream
+func (c *Conn) writeErr(err error) error {
+	if c.ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// encode: ev in the text/event-stream format, every line of the data on its own "data:" line
+func encode(buf *bytes.Buffer, ev Event) error {
+	var data []byte
+	switch v := ev.Data.(type) {
+	case nil:
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return fmt.Errorf("sse: encode %T: %w", v, err)
+		}
+	}
+
+	// line breaks would end the field and inject fields of their own
+	if ev.ID != "" {
+		buf.WriteString("id: " + singleLine(ev.ID) + "\n")
+	}
+	if ev.Name != "" {
+		buf.WriteString("event: " + singleLine(ev.Name) + "\n")
+	}
+	if ev.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(ev.Retry.Milliseconds(), 10) + "\n")
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for _, line := range lines {
+		buf.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// canFlush: w or a writer it wraps (Unwrap of the middlewares) is an http.Flusher
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		switch t := w.(type) {
+		case http.Flusher:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+func register(c *Conn) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	streams[c] = struct{}{}
+}
+
+func unregister(c *Conn) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	delete(streams, c)
+}
+
+// Shutdown: closes every open stream, call it before the http server shutdown
+// browsers reconnect to another replica, http.Server.Shutdown would wait for the streams until its deadline
+func Shutdown() {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	for c := range streams {
+		c.cancel(ErrClosed)
+	}
+}
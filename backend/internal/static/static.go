@@ -0,0 +1,190 @@
+package static
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/etag"
+	"github.com/anuragShingare30/go-boilerplate/internal/httpx"
+)
+
+/**
+@dev frontend bundle from the same binary: build output in internal/static/dist (embedded) or server.static.dir (disk)
+
+r.Handle("/", staticHandler)   → catch all, every route registered on r wins over it
+GET /assets/app-3f2a9c.js      → file, Cache-Control: public, max-age=31536000, immutable (fingerprinted name)
+GET /favicon.ico               → file, Cache-Control: no-cache, revalidated with its ETag (sha256 of the content)
+GET /settings/profile          → no such file, no extension: index.html (server.static.spa), the client router takes over
+GET /assets/missing.js         → 404, a missing asset must not come back as html
+GET /api/v1/nope               → 404 envelope, server.static.exclude keeps unknown API routes JSON
+
+index.html is always no-cache, a deploy reaches clients on their next navigation
+*/
+
+// dist: the bundle embedded at build time, a placeholder index.html until the frontend is built into it
+//
+//go:embed all:dist
+var dist embed.FS
+
+// Handler: serves the files of the bundle
+type Handler struct {
+	fsys fs.FS
+	cfg  config.StaticConfig
+
+	mu   sync.RWMutex
+	tags map[string]fileTag // content hashes by file name
+}
+
+// fileTag: ETag of a file, recomputed when a file on disk changes
+type fileTag struct {
+	modTime time.Time
+	size    int64
+	tag     string
+}
+
+// New: handler of server.static.dir, or of the embedded bundle without one
+func New(cfg config.StaticConfig) (*Handler, error) {
+	var fsys fs.FS
+	if cfg.Dir != "" {
+		fsys = os.DirFS(cfg.Dir)
+	} else {
+		sub, err := fs.Sub(dist, "dist")
+		if err != nil {
+			return nil, fmt.Errorf("embedded static bundle: %w", err)
+		}
+		fsys = sub
+	}
+	return NewFS(fsys, cfg), nil
+}
+
+// NewFS: handler of any file system, e.g. an embed.FS of the application
+func NewFS(fsys fs.FS, cfg config.StaticConfig) *Handler {
+	return &Handler{fsys: fsys, cfg: cfg, tags: make(map[string]fileTag)}
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		notFound(w, r)
+		return
+	}
+	for _, prefix := range h.cfg.Exclude {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			notFound(w, r)
+			return
+		}
+	}
+
+	// rooted Clean can't climb above the bundle, "/" is the index
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "index.html"
+	}
+
+	err := h.serveFile(w, r, name)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		httpx.Error(w, r, fmt.Errorf("serving static file %s: %w", name, err))
+		return
+	}
+
+	if h.cfg.SPA && path.Ext(name) == "" {
+		if err := h.serveFile(w, r, "index.html"); err != nil {
+			httpx.Error(w, r, fmt.Errorf("serving index.html: %w", err))
+		}
+		return
+	}
+	notFound(w, r)
+}
+
+// serveFile: name with caching headers, directories serve their index.html
+// http.ServeContent answers Range, If-None-Match and If-Modified-Since
+func (h *Handler) serveFile(w http.ResponseWriter, r *http.Request, name string) error {
+	file, err := h.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return h.serveFile(w, r, path.Join(name, "index.html"))
+	}
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		body, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(body)
+	}
+
+	tag, err := h.tag(name, info, content)
+	if err != nil {
+		return err
+	}
+
+	header := w.Header()
+	header.Set("ETag", tag)
+	header.Set("Cache-Control", h.cacheControl("/"+name))
+	http.ServeContent(w, r, name, info.ModTime(), content)
+	return nil
+}
+
+// tag: content hash of a file, computed once per modification time and size
+func (h *Handler) tag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	h.mu.RLock()
+	cached, ok := h.tags[name]
+	h.mu.RUnlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.tag, nil
+	}
+
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	tag := etag.Strong(body)
+	h.mu.Lock()
+	h.tags[name] = fileTag{modTime: info.ModTime(), size: info.Size(), tag: tag}
+	h.mu.Unlock()
+	return tag, nil
+}
+
+// cacheControl: fingerprinted files never change under their name, everything else is revalidated
+func (h *Handler) cacheControl(urlPath string) string {
+	if urlPath != "/index.html" && h.cfg.CacheMaxAge > 0 {
+		for _, prefix := range h.cfg.ImmutablePrefixes {
+			if strings.HasPrefix(urlPath, prefix) {
+				return "public, max-age=" + strconv.Itoa(int(h.cfg.CacheMaxAge.Seconds())) + ", immutable"
+			}
+		}
+	}
+	return "no-cache"
+}
+
+func notFound(w http.ResponseWriter, r *http.Request) {
+	httpx.WriteError(w, r, http.StatusNotFound, httpx.ErrorDetail{Code: "not_found", Message: "route not found"})
+}
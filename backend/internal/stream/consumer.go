@@ -0,0 +1,264 @@
+package stream
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+const (
+	consumerMinBackoff = time.Second
+	consumerMaxBackoff = 30 * time.Second
+)
+
+// errNoHandlerResult: error field of entries out of deliveries whose handler never returned, e.g. the process was killed
+var errNoHandlerResult = errors.New("no handler result, the consumer stopped during the delivery")
+
+// Consumer: member of a consumer group, handles the entries redis assigns to it
+type Consumer struct {
+	client  goredis.UniversalClient
+	cfg     config.StreamsConfig
+	stream  string
+	group   string
+	name    string
+	handler Handler
+	log     *zerolog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewConsumer: consumer of group on stream, the name is unique per process
+func NewConsumer(client goredis.UniversalClient, cfg config.StreamsConfig, stream, group string, handler Handler, logger *zerolog.Logger) *Consumer {
+	name := consumerName()
+	log := logger.With().Str("stream", stream).Str("group", group).Str("consumer", name).Logger()
+
+	return &Consumer{
+		client:  client,
+		cfg:     cfg,
+		stream:  stream,
+		group:   group,
+		name:    name,
+		handler: handler,
+		log:     &log,
+	}
+}
+
+// Start: consumes in the background until ctx is done or Close is called
+func (c *Consumer) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+		c.run(ctx)
+	}()
+}
+
+// Close: stops consuming, the running handler finishes first
+func (c *Consumer) Close() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+// run: group setup, then read / claim, redis failures back off exponentially
+func (c *Consumer) run(ctx context.Context) {
+	backoff := consumerMinBackoff
+	lastClaim := time.Time{}
+
+	for {
+		err := c.createGroup(ctx)
+		if err == nil && time.Since(lastClaim) >= c.cfg.ClaimInterval {
+			err = c.claim(ctx)
+			lastClaim = time.Now()
+		}
+		if err == nil {
+			err = c.read(ctx)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			backoff = consumerMinBackoff
+			continue
+		}
+
+		c.log.Warn().Err(err).Dur("retry_in", backoff).Msg("stream consumer failed")
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, consumerMaxBackoff)
+	}
+}
+
+// createGroup: the group exists afterwards, BUSYGROUP means it already did
+func (c *Consumer) createGroup(ctx context.Context) error {
+	err := c.client.XGroupCreateMkStream(ctx, c.stream, c.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	return nil
+}
+
+// read: waits up to streams.block for new entries and handles them
+func (c *Consumer) read(ctx context.Context) error {
+	streams, err := c.client.XReadGroup(ctx, &goredis.XReadGroupArgs{
+		Group:    c.group,
+		Consumer: c.name,
+		Streams:  []string{c.stream, ">"},
+		Count:    c.cfg.BatchSize,
+		Block:    c.cfg.Block,
+	}).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	for _, s := range streams {
+		for _, entry := range s.Messages {
+			c.process(ctx, entry, 1)
+		}
+	}
+	return nil
+}
+
+// claim: takes over entries pending longer than streams.claim_min_idle, dead letters the ones out of deliveries
+func (c *Consumer) claim(ctx context.Context) error {
+	pending, err := c.client.XPendingExt(ctx, &goredis.XPendingExtArgs{
+		Stream: c.stream,
+		Group:  c.group,
+		Idle:   c.cfg.ClaimMinIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  c.cfg.BatchSize,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list pending entries: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	deliveries := make(map[string]int64, len(pending))
+	ids := make([]string, len(pending))
+	for i, p := range pending {
+		deliveries[p.ID] = p.RetryCount
+		ids[i] = p.ID
+	}
+
+	// another consumer may claim the same entries at the same time, XCLAIM hands each to one of them
+	entries, err := c.client.XClaim(ctx, &goredis.XClaimArgs{
+		Stream:   c.stream,
+		Group:    c.group,
+		Consumer: c.name,
+		MinIdle:  c.cfg.ClaimMinIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to claim pending entries: %w", err)
+	}
+
+	for _, entry := range entries {
+		if deliveries[entry.ID] >= int64(c.cfg.MaxDeliveries) {
+			// failed deliveries are dead lettered right away, these never got a handler result
+			c.deadLetter(ctx, entry, deliveries[entry.ID], errNoHandlerResult)
+			continue
+		}
+		c.log.Info().Str("id", entry.ID).Int64("deliveries", deliveries[entry.ID]).Msg("redelivering pending stream entry")
+		// XCLAIM counted this one as a delivery too
+		c.process(ctx, entry, deliveries[entry.ID]+1)
+	}
+	return nil
+}
+
+// process: runs the handler, XACK on success, the last failed delivery goes to the dead letter stream with its error
+func (c *Consumer) process(ctx context.Context, entry goredis.XMessage, delivery int64) {
+	if err := c.handle(ctx, entry); err != nil {
+		c.log.Error().Err(err).Str("id", entry.ID).Int64("delivery", delivery).Msg("stream handler failed")
+		if delivery >= int64(c.cfg.MaxDeliveries) {
+			c.deadLetter(ctx, entry, delivery, err)
+		}
+		return
+	}
+	if err := c.client.XAck(ctx, c.stream, c.group, entry.ID).Err(); err != nil {
+		c.log.Warn().Err(err).Str("id", entry.ID).Msg("failed to ack stream entry, it will be delivered again")
+	}
+}
+
+// handle: the handler with panics turned into errors, the entry isn't lost to a panic
+func (c *Consumer) handle(ctx context.Context, entry goredis.XMessage) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("stream handler panicked: %v", p)
+		}
+	}()
+
+	msg := Message{ID: entry.ID, Stream: c.stream, Payload: payloadBytes(entry.Values[payloadField])}
+	// an entry taken from redis is finished even during Close, otherwise it waits for the claim
+	return c.handler(context.WithoutCancel(ctx), msg)
+}
+
+// deadLetter: copies the entry with the last handler error to the DLQ stream and acks it
+// no MULTI, stream and DLQ can be in different cluster slots, a lost XACK dead letters the entry once more
+func (c *Consumer) deadLetter(ctx context.Context, entry goredis.XMessage, deliveries int64, handlerErr error) {
+	values := make(map[string]any, len(entry.Values)+5)
+	for field, value := range entry.Values {
+		values[field] = value
+	}
+	values["source_id"] = entry.ID
+	values["source_stream"] = c.stream
+	values["group"] = c.group
+	values["deliveries"] = deliveries
+	values["error"] = handlerErr.Error()
+
+	dlq := DeadLetterStream(c.stream)
+	_, err := c.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.XAdd(ctx, &goredis.XAddArgs{Stream: dlq, Values: values})
+		pipe.XAck(ctx, c.stream, c.group, entry.ID)
+		return nil
+	})
+	if err != nil {
+		c.log.Error().Err(err).Str("id", entry.ID).Msg("failed to move stream entry to the dead letter stream")
+		return
+	}
+	c.log.Warn().Err(handlerErr).Str("id", entry.ID).Int64("deliveries", deliveries).Str("dlq", dlq).Msg("stream entry moved to the dead letter stream")
+}
+
+// payloadBytes: go-redis returns field values as strings
+func payloadBytes(value any) []byte {
+	switch v := value.(type) {
+	case string:
+		return []byte(v)
+	case []byte:
+		return v
+	default:
+		return nil
+	}
+}
+
+// consumerName: host and random suffix, a restarted process is a new consumer and claims the old one's entries
+func consumerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "consumer"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
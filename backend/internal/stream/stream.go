@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+/**
+@dev redis streams: durable queue on the redis which is already there, at least once delivery
+
+producer.Add(ctx, "emails", SendEmail{...}) → XADD emails * payload <json>, trimmed to about streams.max_len entries
+
+consumer := stream.NewConsumer(client, cfg.Streams, "emails", "mailer", stream.JSONHandler(func(ctx, msg SendEmail) error { ... }), &log)
+consumer.Start(ctx)
+    → XGROUP CREATE emails mailer 0 MKSTREAM, a new group starts with the entries already in the stream
+    → XREADGROUP GROUP mailer <consumer> ... > : new entries, handled one after the other
+        → nil error: XACK, the entry is done
+        → error / panic: logged, the entry stays pending and is delivered again after streams.claim_min_idle
+    → every streams.claim_interval: XPENDING for entries idle longer than streams.claim_min_idle
+        → crashed consumers and failed entries: XCLAIM to this consumer, handled again
+        → failed delivery number streams.max_deliveries: XADD to "<stream>:dlq" with the handler error in "error", XACK
+
+handlers must be idempotent, a crash between the work and XACK delivers the entry again
+*/
+
+// payloadField: field of the entry holding the encoded payload
+const payloadField = "payload"
+
+// DeadLetterStream: stream failed entries of stream are moved to
+func DeadLetterStream(stream string) string {
+	return stream + ":dlq"
+}
+
+// Message: one entry of a stream
+type Message struct {
+	ID      string
+	Stream  string
+	Payload []byte
+}
+
+// Decode: JSON payload into v
+func (m Message) Decode(v any) error {
+	if err := json.Unmarshal(m.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode %s entry %s: %w", m.Stream, m.ID, err)
+	}
+	return nil
+}
+
+// Handler: processes a message, an error leaves it pending for another delivery
+type Handler func(ctx context.Context, msg Message) error
+
+// JSONHandler: handler of JSON payloads decoded into T
+func JSONHandler[T any](fn func(ctx context.Context, msg T) error) Handler {
+	return func(ctx context.Context, msg Message) error {
+		var payload T
+		if err := msg.Decode(&payload); err != nil {
+			return err
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// Producer: appends entries to streams
+type Producer struct {
+	client goredis.UniversalClient
+	maxLen int64
+}
+
+func NewProducer(client goredis.UniversalClient, cfg config.StreamsConfig) *Producer {
+	return &Producer{client: client, maxLen: cfg.MaxLen}
+}
+
+// Add: appends payload as JSON to stream, returns the id of the entry
+func (p *Producer) Add(ctx context.Context, stream string, payload any) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s entry: %w", stream, err)
+	}
+
+	args := &goredis.XAddArgs{
+		Stream: stream,
+		Values: map[string]any{payloadField: data},
+	}
+	if p.maxLen > 0 {
+		args.MaxLen = p.maxLen
+		args.Approx = true
+	}
+
+	id, err := p.client.XAdd(ctx, args).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to add %s entry: %w", stream, err)
+	}
+	return id, nil
+}
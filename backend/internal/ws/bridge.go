@@ -0,0 +1,61 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/pubsub"
+)
+
+// bridgeTopic: redis channel of the messages between the hubs of the replicas
+const bridgeTopic = "ws.bridge"
+
+// bridgeMessage: a Broadcast (Room) or SendToUser (UserID) of another replica
+type bridgeMessage struct {
+	Origin  string          `json:"origin"`
+	Room    string          `json:"room,omitempty"`
+	UserID  string          `json:"user_id,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Bridge: fans Broadcast / SendToUser out to the hubs of the other replicas over ps
+// at most once like every pub/sub message, a replica which is reconnecting to redis misses them
+func (h *Hub) Bridge(ps *pubsub.PubSub) error {
+	err := pubsub.SubscribeTo(ps, bridgeTopic, func(ctx context.Context, msg bridgeMessage) error {
+		// delivered locally before it was published
+		if msg.Origin == h.replica {
+			return nil
+		}
+		if msg.UserID != "" {
+			h.deliverUser(msg.UserID, msg.Payload)
+			return nil
+		}
+		h.deliverRoom(msg.Room, msg.Payload)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("ws: bridge subscribe: %w", err)
+	}
+
+	h.mu.Lock()
+	h.bridge = ps
+	h.mu.Unlock()
+	return nil
+}
+
+// publish: msg to the other replicas, nothing without Bridge
+func (h *Hub) publish(ctx context.Context, msg bridgeMessage) error {
+	h.mu.RLock()
+	ps := h.bridge
+	h.mu.RUnlock()
+	if ps == nil {
+		return nil
+	}
+
+	msg.Origin = h.replica
+	if err := ps.Publish(ctx, bridgeTopic, msg); err != nil {
+		return fmt.Errorf("ws: bridge publish: %w", err)
+	}
+	return nil
+}
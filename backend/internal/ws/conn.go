@@ -0,0 +1,245 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/apierror"
+	loggerConfig "github.com/anuragShingare30/go-boilerplate/internal/logger"
+	"github.com/coder/websocket"
+	"github.com/rs/zerolog"
+)
+
+// Conn: one websocket of the hub
+type Conn struct {
+	ID     string
+	UserID string
+
+	hub    *Hub
+	socket *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	send   chan []byte
+	rooms  map[string]struct{} // guarded by hub.mu
+
+	closeOnce sync.Once
+}
+
+func newConn(ctx context.Context, hub *Hub, socket *websocket.Conn, userID string) *Conn {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Conn{
+		ID:     randomID(),
+		UserID: userID,
+		hub:    hub,
+		socket: socket,
+		ctx:    ctx,
+		cancel: cancel,
+		send:   make(chan []byte, max(hub.cfg.SendQueue, 1)),
+		rooms:  make(map[string]struct{}),
+	}
+}
+
+// Context: done once the connection is closed, for work started on its behalf
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// Send: v as JSON to this connection, queued and written in the background
+func (c *Conn) Send(v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("ws: encode %T: %w", v, err)
+	}
+	return c.enqueue(payload)
+}
+
+// Join: the connection gets the broadcasts of room
+func (c *Conn) Join(room string) {
+	c.hub.join(c, room)
+}
+
+func (c *Conn) Leave(room string) {
+	c.hub.leave(c, room)
+}
+
+// Close: normal closure with reason, Serve of the connection returns
+func (c *Conn) Close(reason string) {
+	go c.close(websocket.StatusNormalClosure, reason)
+}
+
+// enqueue: never blocks the sender, a connection which can't keep up is closed
+func (c *Conn) enqueue(payload []byte) error {
+	if c.ctx.Err() != nil {
+		return ErrClosed
+	}
+	select {
+	case c.send <- payload:
+		return nil
+	default:
+		// close waits for the handshake, the broadcasting goroutine doesn't
+		go c.close(websocket.StatusTryAgainLater, "client too slow")
+		return ErrSlowClient
+	}
+}
+
+// close: close handshake with code, once, the loops of the connection end with it
+func (c *Conn) close(code websocket.StatusCode, reason string) {
+	c.closeOnce.Do(func() {
+		_ = c.socket.Close(code, reason)
+		c.cancel(ErrClosed)
+	})
+}
+
+// serve: write and ping loop in the background, reads on the calling goroutine
+func (c *Conn) serve(handler Handler) error {
+	log := loggerConfig.FromContext(c.ctx).With().Str("ws_conn", c.ID).Logger()
+	defer c.cancel(ErrClosed)
+
+	go c.writeLoop(&log)
+	go c.pingLoop(&log)
+
+	err := c.readLoop(handler, &log)
+	switch status := websocket.CloseStatus(err); {
+	case status == websocket.StatusNormalClosure || status == websocket.StatusGoingAway:
+		err = nil
+	case c.ctx.Err() != nil:
+		// closed from this side, close carried the reason
+		err = nil
+	}
+	c.close(websocket.StatusInternalError, "")
+	return err
+}
+
+// readLoop: inbound messages to handler until the connection fails or is closed
+func (c *Conn) readLoop(handler Handler, log *zerolog.Logger) error {
+	limiter := newBucket(c.hub.cfg.Rate, c.hub.cfg.Burst)
+	for {
+		typ, data, err := c.socket.Read(c.ctx)
+		if err != nil {
+			return err
+		}
+		if !limiter.allow(time.Now()) {
+			c.close(websocket.StatusPolicyViolation, "rate limit exceeded")
+			return nil
+		}
+		if typ != websocket.MessageText {
+			c.close(websocket.StatusUnsupportedData, "text messages only")
+			return nil
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Type == "" {
+			c.replyError(apierror.BadRequest("message must be a JSON object with a type"))
+			continue
+		}
+		if handler == nil {
+			continue
+		}
+		if err := c.handle(handler, msg); err != nil {
+			apiErr := apierror.From(err)
+			if apiErr.Status >= 500 {
+				log.Error().Err(err).Str("type", msg.Type).Msg("websocket handler failed")
+			}
+			c.replyError(apiErr)
+		}
+	}
+}
+
+// handle: handler with panics turned into errors, one message doesn't take down the connection
+func (c *Conn) handle(handler Handler, msg Message) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic in websocket handler: %v", recovered)
+		}
+	}()
+	return handler(c.ctx, c, msg)
+}
+
+// replyError: the error envelope of the API as the data of an "error" message
+func (c *Conn) replyError(apiErr *apierror.Error) {
+	_ = c.Send(Message{Type: "error", Data: mustJSON(errorData{Code: string(apiErr.Code), Message: apiErr.Message})})
+}
+
+type errorData struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func mustJSON(v any) json.RawMessage {
+	b, _ := json.Marshal(v)
+	return b
+}
+
+func (c *Conn) writeLoop(log *zerolog.Logger) {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case payload := <-c.send:
+			ctx, cancel := context.WithTimeout(c.ctx, c.hub.cfg.WriteTimeout)
+			err := c.socket.Write(ctx, websocket.MessageText, payload)
+			cancel()
+			if err != nil {
+				if c.ctx.Err() == nil {
+					log.Debug().Err(err).Msg("websocket write failed")
+				}
+				c.close(websocket.StatusInternalError, "")
+				return
+			}
+		}
+	}
+}
+
+// pingLoop: a peer which doesn't answer a ping is gone, TCP alone notices that late or never
+func (c *Conn) pingLoop(log *zerolog.Logger) {
+	ticker := time.NewTicker(c.hub.cfg.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(c.ctx, c.hub.cfg.PongTimeout)
+			err := c.socket.Ping(ctx)
+			cancel()
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					log.Debug().Msg("websocket pong timed out")
+				}
+				c.close(websocket.StatusPolicyViolation, "pong timeout")
+				return
+			}
+		}
+	}
+}
+
+// bucket: token bucket of the inbound messages of one connection, only the read loop uses it
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	return &bucket{rate: rate, burst: float64(max(burst, 1)), tokens: float64(max(burst, 1))}
+}
+
+func (b *bucket) allow(now time.Time) bool {
+	if b.rate <= 0 {
+		return true
+	}
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,286 @@
+package ws
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/anuragShingare30/go-boilerplate/internal/apierror"
+	"github.com/anuragShingare30/go-boilerplate/internal/config"
+	"github.com/anuragShingare30/go-boilerplate/internal/pubsub"
+	"github.com/coder/websocket"
+	"github.com/rs/zerolog"
+)
+
+/**
+@dev websockets: two way messages, the hub knows every connection of the replica by room and user
+
+func (h *ChatHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	h.hub.Serve(w, r, ws.Client{UserID: sess.UserID, Rooms: []string{"lobby"}}, func(ctx context.Context, c *ws.Conn, msg ws.Message) error {
+		switch msg.Type {
+		case "join":   c.Join(msg.Room)   → rooms are joined by the server, the handler decides who may
+		case "say":    return h.hub.Broadcast(ctx, msg.Room, ws.Message{Type: "said", Room: msg.Room, Data: msg.Data})
+		}
+		return nil   → an error is answered with {"type":"error","data":{"code":...,"message":...}}, the connection stays
+	})
+}
+
+hub.Broadcast(ctx, "lobby", v)    → every connection in the room, "" for every connection
+hub.SendToUser(ctx, userID, v)    → every connection of the user, e.g. one per browser tab
+conn.Send(v)                      → that one connection
+    → v is encoded as JSON once, queued per connection (server.websocket.send_queue), a full queue closes the slow client
+    → server.websocket.bridge: published on redis as well, the hubs of the other replicas deliver to their connections
+
+keepalive: ping every server.websocket.ping_interval, no pong within pong_timeout closes the connection
+inbound: messages over max_message_bytes or faster than rate / burst close the connection (1009 / 1008)
+Close at shutdown: 1001 going away for every connection, clients reconnect to another replica
+*/
+
+var (
+	// ErrClosed: the connection or the hub was closed
+	ErrClosed = errors.New("ws: connection closed")
+	// ErrSlowClient: the send queue of the connection ran full, it was closed
+	ErrSlowClient = errors.New("ws: client too slow, connection closed")
+)
+
+// Message: the JSON frame of both directions
+type Message struct {
+	Type string          `json:"type"`
+	Room string          `json:"room,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Handler: called with every inbound message of a connection, one after the other
+type Handler func(ctx context.Context, c *Conn, msg Message) error
+
+// Client: who is connecting, set by the handler from its authentication
+type Client struct {
+	UserID string   // target of SendToUser, "" for anonymous connections
+	Rooms  []string // joined right away
+}
+
+// Hub: the connections of this replica
+type Hub struct {
+	cfg     config.WebSocketConfig
+	log     *zerolog.Logger
+	replica string         // origin of bridged messages, the own ones are skipped
+	bridge  *pubsub.PubSub // nil without Bridge
+
+	mu     sync.RWMutex
+	conns  map[*Conn]struct{}
+	rooms  map[string]map[*Conn]struct{}
+	users  map[string]map[*Conn]struct{}
+	closed bool
+}
+
+func NewHub(cfg config.WebSocketConfig, logger *zerolog.Logger) *Hub {
+	return &Hub{
+		cfg:     cfg,
+		log:     logger,
+		replica: randomID(),
+		conns:   make(map[*Conn]struct{}),
+		rooms:   make(map[string]map[*Conn]struct{}),
+		users:   make(map[string]map[*Conn]struct{}),
+	}
+}
+
+// Serve: upgrades r to a websocket and serves it until it closes, blocks the handler
+// the connection outlives server.request_timeout and the write / read timeouts of the server, keepalive is the ping
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request, client Client, handler Handler) error {
+	h.mu.RLock()
+	closed := h.closed
+	h.mu.RUnlock()
+	if closed {
+		apierror.Write(w, r, apierror.Unavailable("server is shutting down"))
+		return ErrClosed
+	}
+
+	// net/http keeps the deadlines of the request on the hijacked connection
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	socket, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: h.cfg.OriginPatterns})
+	if err != nil {
+		// Accept answered the request already (403 origin, 426 no upgrade)
+		return fmt.Errorf("ws: accept: %w", err)
+	}
+	socket.SetReadLimit(h.cfg.MaxMessageBytes)
+
+	// values of the request (logger, request id, session) stay, its cancellation and deadline don't
+	c := newConn(context.WithoutCancel(r.Context()), h, socket, client.UserID)
+	if !h.add(c, client.Rooms) {
+		socket.Close(websocket.StatusGoingAway, "server is shutting down")
+		return ErrClosed
+	}
+	defer h.remove(c)
+
+	return c.serve(handler)
+}
+
+// Broadcast: v to every connection in room, "" for every connection of the hub
+func (h *Hub) Broadcast(ctx context.Context, room string, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("ws: encode %T: %w", v, err)
+	}
+	h.deliverRoom(room, payload)
+	return h.publish(ctx, bridgeMessage{Room: room, Payload: payload})
+}
+
+// SendToUser: v to every connection of userID
+func (h *Hub) SendToUser(ctx context.Context, userID string, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("ws: encode %T: %w", v, err)
+	}
+	h.deliverUser(userID, payload)
+	return h.publish(ctx, bridgeMessage{UserID: userID, Payload: payload})
+}
+
+// Count: connections of this replica, in room when not ""
+func (h *Hub) Count(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if room == "" {
+		return len(h.conns)
+	}
+	return len(h.rooms[room])
+}
+
+func (h *Hub) deliverRoom(room string, payload []byte) {
+	h.mu.RLock()
+	targets := h.conns
+	if room != "" {
+		targets = h.rooms[room]
+	}
+	conns := collect(targets)
+	h.mu.RUnlock()
+
+	for _, c := range conns {
+		c.enqueue(payload)
+	}
+}
+
+func (h *Hub) deliverUser(userID string, payload []byte) {
+	h.mu.RLock()
+	conns := collect(h.users[userID])
+	h.mu.RUnlock()
+
+	for _, c := range conns {
+		c.enqueue(payload)
+	}
+}
+
+// collect: copy of a connection set, sends happen outside of the lock
+func collect(set map[*Conn]struct{}) []*Conn {
+	conns := make([]*Conn, 0, len(set))
+	for c := range set {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+func (h *Hub) add(c *Conn, rooms []string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.conns[c] = struct{}{}
+	if c.UserID != "" {
+		addTo(h.users, c.UserID, c)
+	}
+	for _, room := range rooms {
+		addTo(h.rooms, room, c)
+		c.rooms[room] = struct{}{}
+	}
+	return true
+}
+
+func (h *Hub) remove(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, c)
+	if c.UserID != "" {
+		removeFrom(h.users, c.UserID, c)
+	}
+	for room := range c.rooms {
+		removeFrom(h.rooms, room, c)
+	}
+}
+
+func (h *Hub) join(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.conns[c]; !ok {
+		return
+	}
+	addTo(h.rooms, room, c)
+	c.rooms[room] = struct{}{}
+}
+
+func (h *Hub) leave(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	removeFrom(h.rooms, room, c)
+	delete(c.rooms, room)
+}
+
+func addTo(index map[string]map[*Conn]struct{}, key string, c *Conn) {
+	set, ok := index[key]
+	if !ok {
+		set = make(map[*Conn]struct{})
+		index[key] = set
+	}
+	set[c] = struct{}{}
+}
+
+// removeFrom: empty sets are dropped, rooms don't pile up
+func removeFrom(index map[string]map[*Conn]struct{}, key string, c *Conn) {
+	set := index[key]
+	delete(set, c)
+	if len(set) == 0 {
+		delete(index, key)
+	}
+}
+
+// Close: refuses new connections and closes the open ones with 1001, waits for their close handshakes until ctx is done
+func (h *Hub) Close(ctx context.Context) {
+	h.mu.Lock()
+	h.closed = true
+	conns := collect(h.conns)
+	h.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, c := range conns {
+		wg.Go(func() {
+			c.close(websocket.StatusGoingAway, "server is shutting down")
+		})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		h.log.Warn().Int("connections", len(conns)).Msg("websocket close handshakes incomplete")
+	}
+}
+
+// randomID: 16 hex characters, ids of connections and replicas
+func randomID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}